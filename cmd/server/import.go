@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/handlers/legacy"
+)
+
+var importBSSCmd = &cobra.Command{
+	Use:   "import-bss [file|-]",
+	Short: "Import boot parameters from a BSS export",
+	Long: `Convert a BSS dumpstate or GET /boot/v1/bootparameters export into boot
+configurations. Entries with identical kernel, initrd, params and cloud-init
+data are merged into shared configurations. Use --dry-run to report what would
+be created and which entries cannot be represented. Configurations that already
+exist are left alone, so an interrupted import can be run again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runImportBSS,
+}
+
+func init() {
+	importBSSCmd.Flags().String("server", "http://localhost:8080", "Boot service URL")
+	importBSSCmd.Flags().Bool("dry-run", false, "Report the planned configurations without creating them")
+	importBSSCmd.Flags().String("output", "text", "Report format: text or json")
+	importBSSCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for each boot service request")
+
+	rootCmd.AddCommand(importBSSCmd)
+}
+
+func runImportBSS(cmd *cobra.Command, args []string) error {
+	server, _ := cmd.Flags().GetString("server")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	output, _ := cmd.Flags().GetString("output")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unknown output format %q (expected text or json)", output)
+	}
+
+	input := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening export: %w", err)
+		}
+		defer file.Close() //nolint:errcheck
+		input = file
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return fmt.Errorf("reading export: %w", err)
+	}
+
+	entries, err := legacy.ParseBSSExport(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	plan := legacy.PlanImport(ctx, entries)
+
+	bootClient, err := client.NewClient(server, &http.Client{Timeout: timeout})
+	if err != nil {
+		return fmt.Errorf("creating boot service client: %w", err)
+	}
+	result, err := legacy.ApplyImport(ctx, *bootClient, plan, dryRun)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	} else {
+		legacy.WriteImportReport(out, result)
+	}
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d configurations failed to import", len(result.Failed))
+	}
+	return nil
+}
@@ -7,9 +7,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -22,12 +25,25 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/openchami/boot-service/internal/storage"
+	"github.com/openchami/boot-service/pkg/auth"
 	"github.com/openchami/boot-service/pkg/client"
 	"github.com/openchami/boot-service/pkg/clients/hsm"
 	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+	"github.com/openchami/boot-service/pkg/handlers/admin"
+	"github.com/openchami/boot-service/pkg/handlers/cloudinit"
+	"github.com/openchami/boot-service/pkg/handlers/discovery"
+	"github.com/openchami/boot-service/pkg/handlers/health"
+	"github.com/openchami/boot-service/pkg/handlers/ignition"
 	"github.com/openchami/boot-service/pkg/handlers/legacy"
 )
 
+// Build info, set at link time with -ldflags "-X main.version=..."
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
 // Config holds all configuration for the boot service
 type Config struct {
 	// Server Configuration
@@ -55,27 +71,93 @@ type Config struct {
 	HSMURL          string `mapstructure:"hsm_url"`
 	HSMSyncEnabled  bool   `mapstructure:"hsm_sync_enabled"`
 	HSMSyncInterval int    `mapstructure:"hsm_sync_interval"` // in minutes
+	HSMSyncMaxAge   int    `mapstructure:"hsm_sync_max_age"`  // in minutes, 0 for three sync intervals
+	HSMMaxStaleness int    `mapstructure:"hsm_max_staleness"` // in minutes, 0 to never serve stale HSM data
+
+	// HSM Orphan Handling Configuration
+	HSMOrphanPolicy      string  `mapstructure:"hsm_orphan_policy"`       // delete, tombstone, label or ignore
+	HSMMaxRemovalPercent float64 `mapstructure:"hsm_max_removal_percent"` // abort orphan handling above this share of synced nodes
+
+	// HSM Boot MAC Configuration
+	HSMBootMACRule        string `mapstructure:"hsm_boot_mac_rule"`        // first, lowest or description
+	HSMBootMACDescription string `mapstructure:"hsm_boot_mac_description"` // interface description matched by the description rule
+
+	// HSM State Change Notification Configuration
+	HSMSCNCallbackURL    string   `mapstructure:"hsm_scn_callback_url"`    // URL HSM posts state changes to (empty to poll only)
+	HSMSCNAllowedSources []string `mapstructure:"hsm_scn_allowed_sources"` // HSM addresses or CIDRs allowed to post state changes
+
+	// HSMSyncFilter selects the HSM components synced as nodes (config file only)
+	HSMSyncFilter hsm.ComponentFilter `mapstructure:"hsm_sync_filter"`
+
+	// HSMAuth configures the credentials for HSM requests (config file only)
+	HSMAuth auth.TokenSourceConfig `mapstructure:"hsm_auth"`
+
+	// Boot Script Cache Configuration
+	CacheTTL        int `mapstructure:"cache_ttl"`         // in seconds
+	CacheMaxEntries int `mapstructure:"cache_max_entries"` // 0 for unbounded
+
+	// Boot Storm Admission Configuration (enabled when any limit is set)
+	AdmissionGlobalLimit   int            `mapstructure:"admission_global_limit"`
+	AdmissionCabinetLimit  int            `mapstructure:"admission_cabinet_limit"`
+	AdmissionGroupLimits   map[string]int `mapstructure:"admission_group_limits"`
+	AdmissionStaggerWindow int            `mapstructure:"admission_stagger_window"` // in seconds
+
+	// Discovery Configuration
+	DiscoveryEnabled bool   `mapstructure:"discovery_enabled"`
+	DiscoveryURL     string `mapstructure:"discovery_url"` // registration URL reachable by booting nodes
+
+	// Cloud-init Configuration
+	CloudInitBaseURL string `mapstructure:"cloud_init_base_url"` // boot service URL reachable by nodes, enables ds=nocloud-net params
+
+	// Ignition Configuration
+	IgnitionBaseURL string `mapstructure:"ignition_base_url"` // boot service URL reachable by nodes, enables ignition.config.url params
+
+	// Requester Identification Configuration
+	BootScriptIPFallback bool     `mapstructure:"bootscript_ip_fallback"` // identify nodes by requester IP when no identifier is given
+	TrustedProxies       []string `mapstructure:"trusted_proxies"`        // CIDRs allowed to set X-Forwarded-For/X-Real-IP (empty trusts none)
+
+	// Requester Verification Configuration
+	SpoofCheckMode         string   `mapstructure:"spoof_check_mode"`          // off, log, count or reject
+	SpoofCheckRelaySubnets []string `mapstructure:"spoof_check_relay_subnets"` // CIDRs accepted for any node
+
+	// HSM State Policy Configuration (config file only). Unset keeps the
+	// default policy of halting components disabled in HSM; an empty list
+	// boots every node regardless of its HSM state.
+	BootStatePolicy []bootscript.StatePolicyRule `mapstructure:"boot_state_policy"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		Port:            8080,
-		Host:            "0.0.0.0",
-		ReadTimeout:     30,
-		WriteTimeout:    30,
-		IdleTimeout:     120,
-		DataDir:         "./data",
-		StorageType:     "file",
-		EnableAuth:      false,
-		EnableMetrics:   false,
-		EnableLegacyAPI: true,
-		MetricsPort:     9090,
-		TokenSmithURL:   "",
-		JWKSEndpoint:    "",
-		HSMURL:          "",
-		HSMSyncEnabled:  true,
-		HSMSyncInterval: 5, // 5 minutes
+		Port:             8080,
+		Host:             "0.0.0.0",
+		ReadTimeout:      30,
+		WriteTimeout:     30,
+		IdleTimeout:      120,
+		DataDir:          "./data",
+		StorageType:      "file",
+		EnableAuth:       false,
+		EnableMetrics:    false,
+		EnableLegacyAPI:  true,
+		MetricsPort:      9090,
+		TokenSmithURL:    "",
+		JWKSEndpoint:     "",
+		HSMURL:           "",
+		HSMSyncEnabled:   true,
+		HSMSyncInterval:  5,   // 5 minutes
+		CacheTTL:         300, // 5 minutes
+		CacheMaxEntries:  10000,
+		DiscoveryEnabled: false,
+		DiscoveryURL:     "",
+
+		HSMOrphanPolicy:      hsm.OrphanPolicyTombstone,
+		HSMMaxRemovalPercent: 10,
+		HSMBootMACRule:       hsm.BootMACFirst,
+		HSMSyncFilter:        hsm.DefaultComponentFilter(),
+		HSMMaxStaleness:      30,
+
+		BootScriptIPFallback: true,
+		SpoofCheckMode:       "off",
 	}
 }
 
@@ -118,6 +200,41 @@ func init() {
 	serveCmd.Flags().String("hsm-url", "", "Hardware State Manager service URL (enables HSM when provided)")
 	serveCmd.Flags().Bool("hsm-sync-enabled", true, "Enable background sync with HSM")
 	serveCmd.Flags().Int("hsm-sync-interval", 5, "HSM sync interval in minutes")
+	serveCmd.Flags().String("hsm-orphan-policy", hsm.OrphanPolicyTombstone, "What sync does with nodes deleted from HSM: delete, tombstone (State=Removed), label or ignore")
+	serveCmd.Flags().Float64("hsm-max-removal-percent", 10, "Abort orphan handling when more than this percentage of synced nodes would be removed")
+	serveCmd.Flags().String("hsm-boot-mac-rule", hsm.BootMACFirst, "How the boot MAC is chosen among a node's HSM interfaces: first, lowest or description")
+	serveCmd.Flags().String("hsm-boot-mac-description", "", "Interface description (substring) chosen as boot MAC by the description rule")
+	serveCmd.Flags().String("hsm-scn-callback-url", "", "URL of this service's "+hsm.SCNPath+" endpoint that HSM posts state change notifications to (empty to poll only)")
+	serveCmd.Flags().StringSlice("hsm-scn-allowed-sources", nil, "HSM addresses or CIDRs allowed to post state change notifications (required with hsm-scn-callback-url)")
+	serveCmd.Flags().Int("hsm-sync-max-age", 0, "Report not ready when the last successful HSM sync is older than this many minutes (0 for three sync intervals)")
+	serveCmd.Flags().Int("hsm-max-staleness", 30, "Serve cached HSM data up to this many minutes past expiry when HSM is unreachable (0 to disable)")
+
+	// Boot script cache configuration flags
+	serveCmd.Flags().Int("cache-ttl", 300, "Boot script cache TTL in seconds")
+	serveCmd.Flags().Int("cache-max-entries", 10000, "Maximum cached boot scripts before LRU eviction (0 for unbounded)")
+
+	// Boot storm admission configuration flags
+	serveCmd.Flags().Int("admission-global-limit", 0, "Maximum nodes booting at once (0 for unlimited)")
+	serveCmd.Flags().Int("admission-cabinet-limit", 0, "Maximum nodes booting at once per cabinet (0 for unlimited)")
+	serveCmd.Flags().Int("admission-stagger-window", 0, "Spread kernel/initrd downloads over this many seconds by NID (0 disables)")
+
+	// Discovery configuration flags
+	serveCmd.Flags().Bool("discovery-enabled", false, "Register unknown PXE-booting nodes for operator approval")
+	serveCmd.Flags().String("discovery-url", "", "Registration URL reachable by booting nodes (e.g., http://boot:8080/discovery/register)")
+
+	// Cloud-init configuration flags
+	serveCmd.Flags().String("cloud-init-base-url", "", "Boot service URL reachable by nodes; adds ds=nocloud-net kernel params pointing at /cloud-init (e.g., http://boot:8080)")
+
+	// Ignition configuration flags
+	serveCmd.Flags().String("ignition-base-url", "", "Boot service URL reachable by nodes; adds ignition.config.url kernel params pointing at /ignition (e.g., http://boot:8080)")
+
+	// Requester identification flags
+	serveCmd.Flags().Bool("bootscript-ip-fallback", true, "Identify nodes by requester IP when a boot script request has no host, mac or nid")
+	serveCmd.Flags().StringSlice("trusted-proxies", nil, "Proxy addresses or CIDRs whose X-Forwarded-For/X-Real-IP headers are honored (default: none)")
+
+	// Requester verification flags
+	serveCmd.Flags().String("spoof-check-mode", "off", "Verify requester IPs against the node's interfaces: off, log, count or reject")
+	serveCmd.Flags().StringSlice("spoof-check-relay-subnets", nil, "Relay subnets (CIDRs) whose requests are accepted for any node")
 
 	// Bind flags to viper
 	viper.BindPFlags(serveCmd.Flags()) //nolint:errcheck
@@ -143,6 +260,27 @@ func main() {
 	viper.RegisterAlias("hsm_url", "hsm-url")
 	viper.RegisterAlias("hsm_sync_enabled", "hsm-sync-enabled")
 	viper.RegisterAlias("hsm_sync_interval", "hsm-sync-interval")
+	viper.RegisterAlias("hsm_sync_max_age", "hsm-sync-max-age")
+	viper.RegisterAlias("hsm_max_staleness", "hsm-max-staleness")
+	viper.RegisterAlias("hsm_orphan_policy", "hsm-orphan-policy")
+	viper.RegisterAlias("hsm_max_removal_percent", "hsm-max-removal-percent")
+	viper.RegisterAlias("hsm_boot_mac_rule", "hsm-boot-mac-rule")
+	viper.RegisterAlias("hsm_boot_mac_description", "hsm-boot-mac-description")
+	viper.RegisterAlias("hsm_scn_callback_url", "hsm-scn-callback-url")
+	viper.RegisterAlias("hsm_scn_allowed_sources", "hsm-scn-allowed-sources")
+	viper.RegisterAlias("cache_ttl", "cache-ttl")
+	viper.RegisterAlias("cache_max_entries", "cache-max-entries")
+	viper.RegisterAlias("admission_global_limit", "admission-global-limit")
+	viper.RegisterAlias("admission_cabinet_limit", "admission-cabinet-limit")
+	viper.RegisterAlias("admission_stagger_window", "admission-stagger-window")
+	viper.RegisterAlias("discovery_enabled", "discovery-enabled")
+	viper.RegisterAlias("discovery_url", "discovery-url")
+	viper.RegisterAlias("cloud_init_base_url", "cloud-init-base-url")
+	viper.RegisterAlias("ignition_base_url", "ignition-base-url")
+	viper.RegisterAlias("bootscript_ip_fallback", "bootscript-ip-fallback")
+	viper.RegisterAlias("trusted_proxies", "trusted-proxies")
+	viper.RegisterAlias("spoof_check_mode", "spoof-check-mode")
+	viper.RegisterAlias("spoof_check_relay_subnets", "spoof-check-relay-subnets")
 
 	// Read config file if present
 	if err := viper.ReadInConfig(); err != nil {
@@ -161,17 +299,26 @@ func runServe(cmd *cobra.Command, args []string) error { //nolint:revive
 	config := DefaultConfig()
 	if err := viper.Unmarshal(&config); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %v", err)
-	} // Validate configuration
+	}
+	if config.HSMAuth.Type == auth.TokenSourceService && config.HSMAuth.TokenEndpoint == "" && config.TokenSmithURL != "" {
+		config.HSMAuth.TokenEndpoint = strings.TrimSuffix(config.TokenSmithURL, "/") + "/token"
+	}
+	// Validate configuration
 	if err := validateConfig(config); err != nil {
 		return fmt.Errorf("invalid configuration: %v", err)
 	}
 
 	// Print startup configuration
-	log.Printf("Starting boot service with configuration:")
+	build := health.NewBuildInfo(version, commit, date)
+	log.Printf("Starting boot service %s (commit %s, built %s) with configuration:", build.Version, build.Commit, build.Date)
 	log.Printf("  Server: %s:%d", config.Host, config.Port)
 	log.Printf("  Storage: %s (%s)", config.StorageType, config.DataDir)
-	log.Printf("  Features: auth=%v, hsm=%v, metrics=%v, legacy-api=%v",
-		config.EnableAuth, config.HSMURL != "", config.EnableMetrics, config.EnableLegacyAPI)
+	log.Printf("  Features: auth=%v, hsm=%v, metrics=%v, legacy-api=%v, discovery=%v",
+		config.EnableAuth, config.HSMURL != "", config.EnableMetrics, config.EnableLegacyAPI, config.DiscoveryEnabled)
+	if config.AdmissionGlobalLimit > 0 || config.AdmissionCabinetLimit > 0 || len(config.AdmissionGroupLimits) > 0 {
+		log.Printf("  Admission: global=%d, cabinet=%d, groups=%v",
+			config.AdmissionGlobalLimit, config.AdmissionCabinetLimit, config.AdmissionGroupLimits)
+	}
 
 	// Initialize storage backend
 	if err := storage.InitFileBackend(config.DataDir); err != nil {
@@ -182,12 +329,18 @@ func runServe(cmd *cobra.Command, args []string) error { //nolint:revive
 	// When HSM URL is provided, the service will use FlexibleBootScriptController
 	// with HSM as the node provider for boot script generation
 	var hsmClient *hsm.HSMClient
+	var hsmTokenSource auth.TokenSource
 	if config.HSMURL != "" {
+		// Every HSM client shares the token source so tokens are obtained once
+		var err error
+		hsmTokenSource, err = auth.NewTokenSource(config.HSMAuth, nil, log.New(os.Stdout, "hsm-auth: ", log.LstdFlags))
+		if err != nil {
+			return fmt.Errorf("failed to create HSM token source: %v", err)
+		}
+
 		hsmConfig := hsm.DefaultHSMConfig()
 		hsmConfig.BaseURL = config.HSMURL
-		if config.TokenSmithURL != "" {
-			hsmConfig.AuthToken = config.TokenSmithURL // TODO: Get actual token from TokenSmith
-		}
+		hsmConfig.TokenSource = hsmTokenSource
 
 		hsmLogger := log.New(os.Stdout, "hsm: ", log.LstdFlags)
 		hsmClient = hsm.NewHSMClient(hsmConfig, hsmLogger)
@@ -207,22 +360,31 @@ func runServe(cmd *cobra.Command, args []string) error { //nolint:revive
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Requester addresses may only be rewritten by trusted proxies
+	trustedProxies, err := parseNetworks(config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid trusted-proxies: %w", err)
+	}
+	scnSources, err := parseNetworks(config.HSMSCNAllowedSources)
+	if err != nil {
+		return fmt.Errorf("invalid hsm-scn-allowed-sources: %w", err)
+	}
+
 	// Setup router
 	r := chi.NewRouter()
 
 	// Add all middleware first, before any routes
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(trustedRealIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(time.Duration(config.ReadTimeout) * time.Second))
 
-	// Register health check
-	r.Get("/health", func(w http.ResponseWriter, req *http.Request) { //nolint:revive
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok","service":"boot-service"}`)) //nolint:errcheck
-	})
+	// Register liveness and readiness checks; dependency checks are added as they are set up
+	checker := health.NewChecker(health.DefaultConfig(), build)
+	checker.Register(health.CheckStorage, health.DirectoryCheck(config.DataDir))
+	healthHandler := health.NewHandler(checker, log.New(os.Stdout, "health: ", log.LstdFlags))
+	healthHandler.RegisterRoutes(r)
 
 	// Setup metrics endpoint if enabled (before other routes)
 	if config.EnableMetrics {
@@ -238,52 +400,135 @@ func runServe(cmd *cobra.Command, args []string) error { //nolint:revive
 	// Register generated routes (modern API) - middleware already applied above
 	RegisterGeneratedRoutes(r)
 
-	// Register legacy BSS API routes if enabled
-	if config.EnableLegacyAPI {
-		bootClient, err := client.NewClient(fmt.Sprintf("http://%s:%d", config.Host, config.Port),
-			&http.Client{Timeout: 30 * time.Second})
+	// Client used by the boot script controllers and handlers to reach the modern API
+	bootClient, err := client.NewClient(fmt.Sprintf("http://%s:%d", config.Host, config.Port),
+		&http.Client{Timeout: 30 * time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to create boot service client: %v", err)
+	}
+
+	// Controller configuration shared by all boot script controllers
+	controllerConfig := bootscript.DefaultControllerConfig()
+	controllerConfig.Cache.TTL = time.Duration(config.CacheTTL) * time.Second
+	controllerConfig.Cache.MaxEntries = config.CacheMaxEntries
+	// Shared resolutions get no longer than the request that started them
+	controllerConfig.Cache.RenderTimeout = time.Duration(config.ReadTimeout) * time.Second
+	controllerConfig.Admission.Enabled = config.AdmissionGlobalLimit > 0 || config.AdmissionCabinetLimit > 0 ||
+		len(config.AdmissionGroupLimits) > 0
+	controllerConfig.Admission.GlobalLimit = config.AdmissionGlobalLimit
+	controllerConfig.Admission.CabinetLimit = config.AdmissionCabinetLimit
+	controllerConfig.Admission.GroupLimits = config.AdmissionGroupLimits
+	controllerConfig.Admission.StaggerWindow = time.Duration(config.AdmissionStaggerWindow) * time.Second
+	controllerConfig.Discovery.Enabled = config.DiscoveryEnabled
+	controllerConfig.Discovery.RegistrationURL = config.DiscoveryURL
+	controllerConfig.CloudInit.BaseURL = config.CloudInitBaseURL
+	controllerConfig.Ignition.BaseURL = config.IgnitionBaseURL
+	controllerConfig.SpoofCheck.Mode = config.SpoofCheckMode
+	controllerConfig.SpoofCheck.RelaySubnets = config.SpoofCheckRelaySubnets
+	if config.BootStatePolicy != nil {
+		controllerConfig.StatePolicy.Rules = config.BootStatePolicy
+	}
+
+	// Register discovery routes if enabled
+	if config.DiscoveryEnabled {
+		discoveryLogger := log.New(os.Stdout, "discovery: ", log.LstdFlags)
+		discoveryHandler := discovery.NewHandler(*bootClient, discovery.DefaultConfig(), discoveryLogger)
+		discoveryHandler.RegisterRoutes(r)
+		log.Printf("Node discovery enabled, registering at: %s", config.DiscoveryURL)
+	}
+
+	// Create the boot script controller, using HSM as the node provider when configured
+	controllerLogger := log.New(os.Stdout, "bootscript: ", log.LstdFlags)
+	var controller *bootscript.BootScriptController
+	var legacyController legacy.BootController
+
+	if hsmClient != nil {
+		// Use FlexibleBootScriptController with HSM provider
+		hsmIntegrationConfig := hsm.DefaultIntegrationConfig()
+		hsmIntegrationConfig.HSMConfig.BaseURL = config.HSMURL
+		hsmIntegrationConfig.HSMConfig.Timeout = 30 * time.Second
+		hsmIntegrationConfig.HSMConfig.MaxStaleness = time.Duration(config.HSMMaxStaleness) * time.Minute
+		hsmIntegrationConfig.HSMConfig.TokenSource = hsmTokenSource
+		hsmIntegrationConfig.SyncEnabled = config.HSMSyncEnabled
+		hsmIntegrationConfig.SyncInterval = time.Duration(config.HSMSyncInterval) * time.Minute
+		hsmIntegrationConfig.OrphanPolicy = config.HSMOrphanPolicy
+		hsmIntegrationConfig.MaxRemovalPercent = config.HSMMaxRemovalPercent
+		hsmIntegrationConfig.Filter = config.HSMSyncFilter
+		hsmIntegrationConfig.BootMACRule = config.HSMBootMACRule
+		hsmIntegrationConfig.BootMACDescription = config.HSMBootMACDescription
+		hsmIntegrationConfig.SCNCallbackURL = config.HSMSCNCallbackURL
+
+		providerConfig := bootscript.ProviderConfig{
+			Type:       "hsm",
+			HSMConfig:  &hsmIntegrationConfig,
+			Controller: &controllerConfig,
+		}
+
+		flexController, err := bootscript.NewFlexibleBootScriptController(*bootClient, providerConfig, controllerLogger)
 		if err != nil {
-			return fmt.Errorf("failed to create client for legacy API: %v", err)
+			return fmt.Errorf("failed to create flexible controller with HSM: %v", err)
 		}
 
-		logger := log.New(os.Stdout, "legacy: ", log.LstdFlags)
+		// Start background sync worker if enabled
+		checker.Register(health.CheckNodeProvider, flexController.HealthCheck)
+		if config.HSMSyncEnabled {
+			// State change notifications are applied by the sync worker
+			if handler := flexController.NotificationHandler(); handler != nil && config.HSMSCNCallbackURL != "" {
+				r.With(allowSources(scnSources)).Method(http.MethodPost, hsm.SCNPath, handler)
+				log.Printf("HSM state change notifications enabled (callback: %s, sources: %v)",
+					config.HSMSCNCallbackURL, config.HSMSCNAllowedSources)
+			}
 
-		var legacyHandler *legacy.LegacyHandler
+			go flexController.StartBackgroundSync(ctx)
+			log.Printf("HSM background sync enabled (interval: %d minutes)", config.HSMSyncInterval)
 
-		if hsmClient != nil {
-			// Use FlexibleBootScriptController with HSM provider
-			hsmIntegrationConfig := hsm.DefaultIntegrationConfig()
-			hsmIntegrationConfig.HSMConfig.BaseURL = config.HSMURL
-			hsmIntegrationConfig.HSMConfig.Timeout = 30 * time.Second
-			hsmIntegrationConfig.SyncEnabled = config.HSMSyncEnabled
-			hsmIntegrationConfig.SyncInterval = time.Duration(config.HSMSyncInterval) * time.Minute
-
-			providerConfig := bootscript.ProviderConfig{
-				Type:      "hsm",
-				HSMConfig: &hsmIntegrationConfig,
+			maxAge := time.Duration(config.HSMSyncMaxAge) * time.Minute
+			if maxAge == 0 {
+				maxAge = 3 * time.Duration(config.HSMSyncInterval) * time.Minute
 			}
+			checker.Register(health.CheckProviderSync, func(ctx context.Context) error {
+				return flexController.SyncHealthCheck(ctx, maxAge)
+			})
+		}
 
-			controllerLogger := log.New(os.Stdout, "bootscript: ", log.LstdFlags)
-			flexController, err := bootscript.NewFlexibleBootScriptController(*bootClient, providerConfig, controllerLogger)
-			if err != nil {
-				return fmt.Errorf("failed to create flexible controller with HSM: %v", err)
-			}
+		controller = flexController.BootScriptController
+		legacyController = flexController
+	} else {
+		// Use standard controller with local storage
+		controller = bootscript.NewBootScriptControllerWithConfig(*bootClient, controllerConfig, controllerLogger)
+		legacyController = controller
+	}
 
-			// Start background sync worker if enabled
-			if config.HSMSyncEnabled {
-				go flexController.StartBackgroundSync(ctx)
-				log.Printf("HSM background sync enabled (interval: %d minutes)", config.HSMSyncInterval)
-			}
+	// Run controller background workers (cache cleanup) for the life of the server
+	controller.Start(ctx)
+	defer controller.Stop()
+
+	// Register admin routes
+	adminHandler := admin.NewHandler(controller, log.New(os.Stdout, "admin: ", log.LstdFlags))
+	adminHandler.RegisterRoutes(r)
+
+	// Register cloud-init NoCloud datasource routes
+	cloudInitHandler := cloudinit.NewHandler(controller, log.New(os.Stdout, "cloud-init: ", log.LstdFlags))
+	cloudInitHandler.RegisterRoutes(r)
+
+	// Register Ignition config routes
+	ignitionHandler := ignition.NewHandler(controller, log.New(os.Stdout, "ignition: ", log.LstdFlags))
+	ignitionHandler.RegisterRoutes(r)
+
+	// Register legacy BSS API routes if enabled
+	if config.EnableLegacyAPI {
+		logger := log.New(os.Stdout, "legacy: ", log.LstdFlags)
+		legacyConfig := legacy.DefaultHandlerConfig()
+		legacyConfig.IPFallback = config.BootScriptIPFallback
+		legacyConfig.Status = checker
+		legacyHandler := legacy.NewLegacyHandlerWithConfig(*bootClient, legacyController, legacyConfig, logger)
+		legacyHandler.RegisterRoutes(r)
 
-			legacyHandler = legacy.NewLegacyHandlerWithController(*bootClient, flexController, logger)
+		if hsmClient != nil {
 			log.Println("Legacy BSS API enabled with HSM integration at: /boot/v1/")
 		} else {
-			// Use standard controller with local storage
-			legacyHandler = legacy.NewLegacyHandler(*bootClient, logger)
 			log.Println("Legacy BSS API enabled at: /boot/v1/")
 		}
-
-		legacyHandler.RegisterRoutes(r)
 	}
 
 	// Configure server
@@ -331,10 +576,159 @@ func validateConfig(config Config) error {
 	if config.EnableAuth && config.TokenSmithURL == "" {
 		return fmt.Errorf("tokensmith-url is required when auth is enabled")
 	}
+	if config.DiscoveryEnabled && config.DiscoveryURL == "" {
+		return fmt.Errorf("discovery-url is required when discovery is enabled")
+	}
+	if config.DiscoveryEnabled && !config.EnableLegacyAPI {
+		// Pending and registered nodes chain to /boot/v1/bootscript, a legacy API route
+		return fmt.Errorf("enable-legacy-api is required when discovery is enabled")
+	}
+	switch config.SpoofCheckMode {
+	case bootscript.SpoofCheckOff, bootscript.SpoofCheckLog, bootscript.SpoofCheckCount, bootscript.SpoofCheckReject:
+	default:
+		return fmt.Errorf("invalid spoof-check-mode: %s", config.SpoofCheckMode)
+	}
+	for _, subnet := range config.SpoofCheckRelaySubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("invalid spoof-check-relay-subnets entry: %s", subnet)
+		}
+	}
+	if err := (bootscript.StatePolicyConfig{Rules: config.BootStatePolicy}).Validate(); err != nil {
+		return fmt.Errorf("invalid boot-state-policy: %w", err)
+	}
+	switch config.HSMOrphanPolicy {
+	case hsm.OrphanPolicyDelete, hsm.OrphanPolicyTombstone, hsm.OrphanPolicyLabel, hsm.OrphanPolicyIgnore:
+	default:
+		return fmt.Errorf("invalid hsm-orphan-policy: %s", config.HSMOrphanPolicy)
+	}
+	if config.HSMMaxRemovalPercent < 0 || config.HSMMaxRemovalPercent > 100 {
+		return fmt.Errorf("invalid hsm-max-removal-percent: %v", config.HSMMaxRemovalPercent)
+	}
+	if config.HSMSCNCallbackURL != "" {
+		if u, err := url.Parse(config.HSMSCNCallbackURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid hsm-scn-callback-url: %s", config.HSMSCNCallbackURL)
+		}
+		// Notifications trigger HSM reads and node updates, so only HSM may post them
+		if len(config.HSMSCNAllowedSources) == 0 {
+			return errors.New("hsm-scn-allowed-sources is required with hsm-scn-callback-url")
+		}
+	}
+	if _, err := parseNetworks(config.HSMSCNAllowedSources); err != nil {
+		return fmt.Errorf("invalid hsm-scn-allowed-sources: %w", err)
+	}
+	if _, err := parseNetworks(config.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted-proxies: %w", err)
+	}
+	switch config.HSMBootMACRule {
+	case hsm.BootMACFirst, hsm.BootMACLowest:
+	case hsm.BootMACDescription:
+		if config.HSMBootMACDescription == "" {
+			return fmt.Errorf("hsm-boot-mac-description is required for the %s boot MAC rule", hsm.BootMACDescription)
+		}
+	default:
+		return fmt.Errorf("invalid hsm-boot-mac-rule: %s", config.HSMBootMACRule)
+	}
+	if err := config.HSMSyncFilter.Validate(); err != nil {
+		return fmt.Errorf("invalid hsm-sync-filter: %w", err)
+	}
+	if config.HSMSyncMaxAge < 0 {
+		return fmt.Errorf("invalid hsm-sync-max-age: %d", config.HSMSyncMaxAge)
+	}
+	if err := config.HSMAuth.Validate(); err != nil {
+		return fmt.Errorf("invalid hsm-auth: %w", err)
+	}
+	if config.HSMMaxStaleness < 0 {
+		return fmt.Errorf("invalid hsm-max-staleness: %d", config.HSMMaxStaleness)
+	}
+	if config.CacheTTL <= 0 {
+		return fmt.Errorf("invalid cache-ttl: %d", config.CacheTTL)
+	}
+	if config.CacheMaxEntries < 0 {
+		return fmt.Errorf("invalid cache-max-entries: %d", config.CacheMaxEntries)
+	}
 	// Note: HSM is auto-enabled when hsm-url is provided, no explicit validation needed
 	return nil
 }
 
+// parseNetworks parses addresses and CIDRs; a bare address is treated as a single host
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address: %s", value)
+			}
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 8 * net.IPv6len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %s", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// trustedRealIP applies middleware.RealIP to requests from trusted proxies only, so
+// other clients cannot choose their requester address with forwarding headers.
+// With no trusted proxies configured forwarding headers are ignored.
+func trustedRealIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		realIP := middleware.RealIP(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peerIn(r, trusted) {
+				realIP.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowSources refuses requests from peers outside the given networks with 403
+func allowSources(allowed []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !peerIn(r, allowed) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// peerIn reports whether the peer address of a request is in one of networks
+func peerIn(r *http.Request, networks []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func startMetricsServer(config Config) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/metrics", metricsHandler)
@@ -0,0 +1,114 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+	"github.com/openchami/boot-service/pkg/handlers/legacy"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// TestTrustedRealIP tests that forwarding headers cannot defeat spoof checking
+func TestTrustedRealIP(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01",
+					Interfaces: []node.Interface{{MAC: "aa:bb:cc:dd:ee:01", IP: "10.1.0.1"}}}},
+			})
+		case "/bootconfigurations":
+			config := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0"},
+				Kernel: "http://files.example.com/vmlinuz",
+			}}
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{config}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+	controllerConfig := bootscript.DefaultControllerConfig()
+	controllerConfig.SpoofCheck.Mode = bootscript.SpoofCheckReject
+	controller := bootscript.NewBootScriptControllerWithConfig(*bootClient, controllerConfig, logger)
+
+	request := func(proxies []string, remoteAddr string) int {
+		trusted, err := parseNetworks(proxies)
+		if err != nil {
+			t.Fatalf("parseNetworks failed: %v", err)
+		}
+		r := chi.NewRouter()
+		r.Use(trustedRealIP(trusted))
+		legacy.NewLegacyHandlerWithController(*bootClient, controller, logger).RegisterRoutes(r)
+
+		req := httptest.NewRequest(http.MethodGet, "/boot/v1/bootscript?mac=aa:bb:cc:dd:ee:01", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Real-IP", "10.1.0.1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A forged address from an untrusted peer is ignored and the peer is checked
+	if code := request(nil, "10.9.9.9:41234"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for forged X-Real-IP with no trusted proxies, got %d", code)
+	}
+	if code := request([]string{"10.100.0.0/16"}, "10.9.9.9:41234"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for forged X-Real-IP from an untrusted peer, got %d", code)
+	}
+
+	// Trusted proxies forward the node's address
+	if code := request([]string{"10.100.0.0/16"}, "10.100.0.5:41234"); code != http.StatusOK {
+		t.Errorf("Expected 200 for X-Real-IP from a trusted proxy, got %d", code)
+	}
+}
+
+// TestAllowSources tests that state change notifications are only accepted from allowed sources
+func TestAllowSources(t *testing.T) {
+	allowed, err := parseNetworks([]string{"10.1.0.10", "10.2.0.0/16"})
+	if err != nil {
+		t.Fatalf("parseNetworks failed: %v", err)
+	}
+	handler := allowSources(allowed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for remoteAddr, want := range map[string]int{
+		"10.1.0.10:41234": http.StatusOK,
+		"10.2.3.4:41234":  http.StatusOK,
+		"10.1.0.11:41234": http.StatusForbidden,
+		"garbage":         http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/hsm/scn", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Expected %d for a notification from %s, got %d", want, remoteAddr, rec.Code)
+		}
+	}
+
+	if _, err := parseNetworks([]string{"10.1.0.0/33"}); err == nil {
+		t.Errorf("Expected an invalid CIDR to be rejected")
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token source types
+const (
+	TokenSourceStatic  = "static"        // A fixed token from the configuration
+	TokenSourceFile    = "file"          // A token read from a file, reloaded when the file changes
+	TokenSourceService = "service_token" // A TokenSmith service-to-service token from client credentials
+)
+
+// TokenSource provides bearer tokens for outbound requests
+type TokenSource interface {
+	// Token returns a token that is valid now
+	Token(ctx context.Context) (string, error)
+	// Invalidate discards the rejected token after a service refused it, so
+	// the next call to Token obtains a new one. A token that has already been
+	// replaced is kept, so concurrent rejections of the same token replace it once.
+	Invalidate(rejected string)
+}
+
+// TokenSourceConfig configures the token source for calls to another service
+type TokenSourceConfig struct {
+	Type string `json:"type" yaml:"type" mapstructure:"type"` // static, file or service_token; empty for none
+
+	// Static token
+	Token string `json:"token,omitempty" yaml:"token,omitempty" mapstructure:"token"`
+
+	// Token file
+	TokenFile string `json:"tokenFile,omitempty" yaml:"token_file,omitempty" mapstructure:"token_file"`
+
+	// TokenSmith client credentials
+	TokenEndpoint    string   `json:"tokenEndpoint,omitempty" yaml:"token_endpoint,omitempty" mapstructure:"token_endpoint"`
+	ServiceName      string   `json:"serviceName,omitempty" yaml:"service_name,omitempty" mapstructure:"service_name"`
+	ClientSecret     string   `json:"-" yaml:"client_secret,omitempty" mapstructure:"client_secret"`
+	ClientSecretFile string   `json:"clientSecretFile,omitempty" yaml:"client_secret_file,omitempty" mapstructure:"client_secret_file"`
+	Scopes           []string `json:"scopes,omitempty" yaml:"scopes,omitempty" mapstructure:"scopes"`
+
+	// RefreshBefore is how long before expiry a service token is replaced
+	RefreshBefore time.Duration `json:"refreshBefore,omitempty" yaml:"refresh_before,omitempty" mapstructure:"refresh_before"`
+}
+
+// Validate checks that the settings required by the token source type are present
+func (c TokenSourceConfig) Validate() error {
+	switch c.Type {
+	case "":
+	case TokenSourceStatic:
+		if c.Token == "" {
+			return errors.New("token is required for a static token source")
+		}
+	case TokenSourceFile:
+		if c.TokenFile == "" {
+			return errors.New("token_file is required for a file token source")
+		}
+	case TokenSourceService:
+		if c.TokenEndpoint == "" || c.ServiceName == "" {
+			return errors.New("token_endpoint and service_name are required for a service token source")
+		}
+		if c.ClientSecret == "" && c.ClientSecretFile == "" {
+			return errors.New("client_secret or client_secret_file is required for a service token source")
+		}
+	default:
+		return fmt.Errorf("unknown token source type: %s", c.Type)
+	}
+	return nil
+}
+
+// NewTokenSource creates the configured token source, or returns nil when no type is set
+func NewTokenSource(config TokenSourceConfig, httpClient *http.Client, logger *log.Logger) (TokenSource, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case TokenSourceStatic:
+		return StaticTokenSource(config.Token), nil
+	case TokenSourceFile:
+		return NewFileTokenSource(config.TokenFile), nil
+	case TokenSourceService:
+		return NewServiceTokenSource(config, httpClient, logger), nil
+	default:
+		return nil, nil
+	}
+}
+
+// StaticTokenSource always returns the same token
+type StaticTokenSource string
+
+// Token implements TokenSource
+func (s StaticTokenSource) Token(ctx context.Context) (string, error) { //nolint:revive
+	return string(s), nil
+}
+
+// Invalidate implements TokenSource; a static token can't be replaced
+func (s StaticTokenSource) Invalidate(rejected string) {} //nolint:revive
+
+// FileTokenSource reads a token from a file, e.g., a mounted Kubernetes
+// secret, and rereads it whenever the file changes
+type FileTokenSource struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileTokenSource creates a token source for a token file
+func NewFileTokenSource(path string) *FileTokenSource {
+	return &FileTokenSource{path: path}
+}
+
+// Token implements TokenSource
+func (s *FileTokenSource) Token(ctx context.Context) (string, error) { //nolint:revive
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.token, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", s.path)
+	}
+	s.token, s.modTime, s.size = token, info.ModTime(), info.Size()
+	return s.token, nil
+}
+
+// Invalidate implements TokenSource by rereading the file on the next call
+func (s *FileTokenSource) Invalidate(rejected string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == rejected {
+		s.token = ""
+	}
+}
+
+// ServiceTokenSource obtains TokenSmith service-to-service tokens with the
+// OAuth 2.0 client credentials grant and replaces them before they expire
+type ServiceTokenSource struct {
+	config     TokenSourceConfig
+	httpClient *http.Client
+	logger     *log.Logger
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	refreshAt time.Time
+	fetching  *tokenFetch // Request to the token endpoint in progress, if any
+}
+
+// tokenFetch is a request to the token endpoint that concurrent callers of
+// Token wait for instead of requesting tokens of their own
+type tokenFetch struct {
+	done  chan struct{}
+	token string // Set with err before done is closed
+	err   error
+}
+
+// NewServiceTokenSource creates a client credentials token source
+func NewServiceTokenSource(config TokenSourceConfig, httpClient *http.Client, logger *log.Logger) *ServiceTokenSource {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if logger == nil {
+		logger = log.New(log.Writer(), "auth: ", log.LstdFlags)
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = time.Minute
+	}
+	return &ServiceTokenSource{
+		config:     config,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+// tokenResponse is an OAuth 2.0 token endpoint response
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token implements TokenSource, requesting a new token when there is none or
+// the current one is about to expire. Concurrent callers share one request,
+// which is made without holding the lock. If a refresh fails while the
+// current token is still valid, the current token is used.
+func (s *ServiceTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	if s.token != "" && time.Now().Before(s.refreshAt) {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+
+	if fetching := s.fetching; fetching != nil {
+		s.mu.Unlock()
+		select {
+		case <-fetching.done:
+			return fetching.token, fetching.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	fetching := &tokenFetch{done: make(chan struct{})}
+	s.fetching = fetching
+	s.mu.Unlock()
+
+	// The token is shared, so the caller giving up must not fail the
+	// others; the HTTP client's timeout bounds the request
+	fetching.token, fetching.err = s.refresh(context.WithoutCancel(ctx))
+	s.mu.Lock()
+	s.fetching = nil
+	s.mu.Unlock()
+	close(fetching.done)
+	return fetching.token, fetching.err
+}
+
+// refresh requests a new token and stores it, returning the token to use
+func (s *ServiceTokenSource) refresh(ctx context.Context) (string, error) {
+	now := time.Now()
+	token, expiresAt, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if s.token != "" && now.Before(s.expiresAt) {
+			s.logger.Printf("Failed to refresh service token, using the current one until it expires: %v", err)
+			return s.token, nil
+		}
+		return "", err
+	}
+
+	s.token, s.expiresAt = token, expiresAt
+	// Short-lived tokens are replaced halfway through their lifetime
+	s.refreshAt = expiresAt.Add(-min(s.config.RefreshBefore, expiresAt.Sub(now)/2))
+	s.logger.Printf("Obtained service token for %s, expires at %s", s.config.ServiceName, expiresAt.Format(time.RFC3339))
+	return s.token, nil
+}
+
+// Invalidate implements TokenSource
+func (s *ServiceTokenSource) Invalidate(rejected string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == rejected {
+		s.token = ""
+	}
+}
+
+// fetch requests a token from the token endpoint
+func (s *ServiceTokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	secret := s.config.ClientSecret
+	if s.config.ClientSecretFile != "" {
+		data, err := os.ReadFile(s.config.ClientSecretFile)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to read client secret: %w", err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.config.ServiceName},
+		"client_secret": {secret},
+	}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to request service token: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", time.Time{}, errors.New("token response has no access_token")
+	}
+
+	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	if tokenResp.ExpiresIn <= 0 {
+		var ok bool
+		if expiresAt, ok = jwtExpiry(tokenResp.AccessToken); !ok {
+			return "", time.Time{}, errors.New("token response has no expiry")
+		}
+	}
+	return tokenResp.AccessToken, expiresAt, nil
+}
+
+// jwtExpiry reads the exp claim of a JWT without verifying it, which is
+// only used to schedule the refresh of a token this service obtained itself
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
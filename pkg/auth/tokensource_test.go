@@ -0,0 +1,188 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSourceConfig(t *testing.T) {
+	assert.NoError(t, TokenSourceConfig{}.Validate())
+	assert.Error(t, TokenSourceConfig{Type: TokenSourceStatic}.Validate())
+	assert.Error(t, TokenSourceConfig{Type: TokenSourceFile}.Validate())
+	assert.Error(t, TokenSourceConfig{Type: TokenSourceService, TokenEndpoint: "http://tokensmith/token", ServiceName: "boot-service"}.Validate())
+	assert.Error(t, TokenSourceConfig{Type: "kerberos"}.Validate())
+
+	source, err := NewTokenSource(TokenSourceConfig{}, nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, source, "No token source is created without a type")
+
+	source, err = NewTokenSource(TokenSourceConfig{Type: TokenSourceStatic, Token: "abc"}, nil, nil)
+	require.NoError(t, err)
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "abc", token)
+}
+
+func TestFileTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	source := NewFileTokenSource(path)
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	// A rotated secret is picked up without a restart
+	require.NoError(t, os.WriteFile(path, []byte("second-token\n"), 0o600))
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second-token", token)
+
+	require.NoError(t, os.Remove(path))
+	_, err = source.Token(context.Background())
+	assert.Error(t, err)
+}
+
+func TestServiceTokenSource(t *testing.T) {
+	var issued atomic.Int32
+	expiresIn := int64(3600)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "boot-service" ||
+			r.Form.Get("client_secret") != "s3cret" || r.Form.Get("scope") != "smd:read" {
+			http.Error(w, "invalid client", http.StatusUnauthorized)
+			return
+		}
+		n := issued.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer server.Close()
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("s3cret\n"), 0o600))
+	source := NewServiceTokenSource(TokenSourceConfig{
+		Type:             TokenSourceService,
+		TokenEndpoint:    server.URL,
+		ServiceName:      "boot-service",
+		ClientSecretFile: secretFile,
+		Scopes:           []string{"smd:read"},
+	}, nil, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	token, err := source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// The token is reused until it is about to expire
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// A rejected token is replaced
+	source.Invalidate("token-1")
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// A late rejection of a replaced token keeps the current one
+	source.Invalidate("token-1")
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// A token within RefreshBefore of its expiry is refreshed
+	source.mu.Lock()
+	source.refreshAt = time.Now().Add(-time.Second)
+	source.mu.Unlock()
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-3", token)
+
+	// A failed refresh keeps using the current token while it is valid
+	server.Close()
+	source.mu.Lock()
+	source.refreshAt = time.Now().Add(-time.Second)
+	source.mu.Unlock()
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-3", token)
+}
+
+func TestServiceTokenSourceShortLived(t *testing.T) {
+	source := NewServiceTokenSource(TokenSourceConfig{RefreshBefore: time.Minute}, nil, log.New(io.Discard, "", 0))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		// No expires_in: the expiry comes from the JWT exp claim
+		payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(40*time.Second).Unix())))
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "header." + payload + ".signature"}) //nolint:errcheck
+	}))
+	defer server.Close()
+	source.config.TokenEndpoint = server.URL
+
+	_, err := source.Token(context.Background())
+	require.NoError(t, err)
+
+	// A token shorter-lived than RefreshBefore is refreshed halfway through its lifetime
+	source.mu.Lock()
+	refreshIn := time.Until(source.refreshAt)
+	source.mu.Unlock()
+	assert.InDelta(t, 20*time.Second, refreshIn, float64(2*time.Second))
+}
+
+func TestServiceTokenSourceConcurrent(t *testing.T) {
+	var issued atomic.Int32
+	var source *ServiceTokenSource
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		// The lock is not held while the token is requested
+		source.Invalidate("unknown")
+		time.Sleep(50 * time.Millisecond)
+		n := issued.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": fmt.Sprintf("token-%d", n), "expires_in": 3600}) //nolint:errcheck
+	}))
+	defer server.Close()
+	source = NewServiceTokenSource(TokenSourceConfig{TokenEndpoint: server.URL}, nil, log.New(io.Discard, "", 0))
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			assert.NoError(t, err)
+			tokens[i] = token
+		}()
+	}
+	wg.Wait()
+
+	// Concurrent callers share a single request
+	assert.Equal(t, int32(1), issued.Load())
+	for _, token := range tokens {
+		assert.Equal(t, "token-1", token)
+	}
+}
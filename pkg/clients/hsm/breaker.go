@@ -0,0 +1,135 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Requests are sent to HSM
+	BreakerOpen     = "open"      // Requests fail fast until the cooldown has passed
+	BreakerHalfOpen = "half-open" // One trial request decides whether to close again
+)
+
+// ErrCircuitOpen is returned without calling HSM while the circuit breaker is open
+var ErrCircuitOpen = errors.New("HSM circuit breaker is open")
+
+// circuitBreaker stops calls to HSM after consecutive failures so boot
+// requests fail fast, and lets a trial request through after a cooldown
+type circuitBreaker struct {
+	enabled   bool
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial request is in flight
+	opens    int
+}
+
+func newCircuitBreaker(enabled bool, threshold int, cooldown time.Duration) *circuitBreaker {
+	defaults := DefaultHSMConfig()
+	if threshold <= 0 {
+		threshold = defaults.CircuitBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaults.CircuitBreakerCooldown
+	}
+	return &circuitBreaker{
+		enabled:   enabled,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// allow reports whether a request may be sent to HSM
+func (b *circuitBreaker) allow() error {
+	if !b.enabled {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record records the outcome of a request that allow let through
+func (b *circuitBreaker) record(failed bool) {
+	if !b.enabled {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !failed {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		if b.state != BreakerOpen {
+			b.opens++
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a request that allow let through without recording an
+// outcome, e.g., when the caller canceled it
+func (b *circuitBreaker) release() {
+	if !b.enabled {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// stats returns the breaker state for client statistics
+func (b *circuitBreaker) stats() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"enabled":              b.enabled,
+		"state":                b.state,
+		"consecutive_failures": b.failures,
+		"failure_threshold":    b.threshold,
+		"cooldown":             b.cooldown.String(),
+		"times_opened":         b.opens,
+	}
+	if b.state != BreakerClosed {
+		stats["opened_at"] = b.openedAt.UTC().Format(time.RFC3339)
+	}
+	return stats
+}
@@ -0,0 +1,245 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openchami/boot-service/pkg/validation"
+)
+
+// HSMCache provides caching for HSM responses to reduce load
+type HSMCache struct { //nolint:revive
+	components         map[string]*CacheEntry
+	ethernetInterfaces map[string]*CacheEntry
+	mu                 sync.RWMutex
+	expiry             time.Duration
+}
+
+// CacheEntry represents a cached item with expiration
+type CacheEntry struct {
+	Data      interface{}
+	ExpiresAt time.Time
+}
+
+// cacheTable selects the HSMCache map holding an entry
+type cacheTable int
+
+const (
+	componentTable cacheTable = iota
+	ethernetTable
+)
+
+// NewHSMCache creates a new HSM cache
+func NewHSMCache(expiry time.Duration) *HSMCache {
+	return &HSMCache{
+		components:         make(map[string]*CacheEntry),
+		ethernetInterfaces: make(map[string]*CacheEntry),
+		expiry:             expiry,
+	}
+}
+
+// GetComponent retrieves a component from cache if not expired
+func (c *HSMCache) GetComponent(key string) (interface{}, bool) {
+	return c.getFresh(componentTable, key)
+}
+
+// GetEthernet retrieves an ethernet interface from cache if not expired
+func (c *HSMCache) GetEthernet(key string) (interface{}, bool) {
+	return c.getFresh(ethernetTable, key)
+}
+
+// SetComponent stores a component in cache with expiration
+func (c *HSMCache) SetComponent(key string, data interface{}) {
+	c.set(componentTable, key, data)
+}
+
+// SetEthernet stores an ethernet interface in cache with expiration
+func (c *HSMCache) SetEthernet(key string, data interface{}) {
+	c.set(ethernetTable, key, data)
+}
+
+// GetStaleComponent retrieves a component from cache if it expired no more than maxStaleness ago
+func (c *HSMCache) GetStaleComponent(key string, maxStaleness time.Duration) (interface{}, bool) {
+	return c.getStale(componentTable, key, maxStaleness)
+}
+
+// GetStaleEthernet retrieves an ethernet interface from cache if it expired no more than maxStaleness ago
+func (c *HSMCache) GetStaleEthernet(key string, maxStaleness time.Duration) (interface{}, bool) {
+	return c.getStale(ethernetTable, key, maxStaleness)
+}
+
+func (c *HSMCache) table(table cacheTable) map[string]*CacheEntry {
+	if table == ethernetTable {
+		return c.ethernetInterfaces
+	}
+	return c.components
+}
+
+// entry returns a copy of an entry whether or not it has expired
+func (c *HSMCache) entry(table cacheTable, key string) (CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.table(table)[key]
+	if !exists {
+		return CacheEntry{}, false
+	}
+	return *entry, true
+}
+
+func (c *HSMCache) getFresh(table cacheTable, key string) (interface{}, bool) {
+	entry, exists := c.entry(table, key)
+	if !exists || time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+func (c *HSMCache) getStale(table cacheTable, key string, maxStaleness time.Duration) (interface{}, bool) {
+	entry, exists := c.entry(table, key)
+	if !exists || time.Since(entry.ExpiresAt) > maxStaleness {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+func (c *HSMCache) set(table cacheTable, key string, data interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.table(table)[key] = &CacheEntry{
+		Data:      data,
+		ExpiresAt: time.Now().Add(c.expiry),
+	}
+}
+
+// componentByID finds a component in the unexpired cached component lists
+func (c *HSMCache) componentByID(id string) (*HSMComponent, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	for _, entry := range c.components {
+		list, ok := entry.Data.(*componentList)
+		if !ok || now.After(entry.ExpiresAt) {
+			continue
+		}
+		if comp, found := list.byID[id]; found {
+			return comp, true
+		}
+	}
+	return nil, false
+}
+
+// invalidateComponents drops the cached entries of components and every
+// cached component list, since a change can move a component into or out of
+// a query's results. Groups, partitions and other components stay cached.
+func (c *HSMCache) invalidateComponents(ids []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.components {
+		if _, isList := entry.Data.(*componentList); isList {
+			delete(c.components, key)
+		}
+	}
+	for _, id := range ids {
+		delete(c.components, "component_"+id)
+	}
+}
+
+// replaceInterfaces replaces the ethernet interfaces of components in the
+// cached interface lists with current ones, keeping the lists' expiry
+func (c *HSMCache) replaceInterfaces(ids []string, current []HSMEthernetInterface) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.ethernetInterfaces {
+		list, ok := entry.Data.(*interfaceList)
+		if !ok {
+			continue
+		}
+		interfaces := make([]HSMEthernetInterface, 0, len(list.interfaces)+len(current))
+		for _, iface := range list.interfaces {
+			if !slices.Contains(ids, iface.ComponentID) {
+				interfaces = append(interfaces, iface)
+			}
+		}
+		interfaces = append(interfaces, current...)
+		c.ethernetInterfaces[key] = &CacheEntry{Data: newInterfaceList(interfaces), ExpiresAt: entry.ExpiresAt}
+	}
+}
+
+// componentList is a cached component list indexed by component ID
+type componentList struct {
+	components []HSMComponent
+	byID       map[string]*HSMComponent
+}
+
+func newComponentList(components []HSMComponent) *componentList {
+	list := &componentList{
+		components: components,
+		byID:       make(map[string]*HSMComponent, len(components)),
+	}
+	for i := range components {
+		list.byID[components[i].ID] = &components[i]
+	}
+	return list
+}
+
+// interfaceList is the cached ethernet interface list indexed by MAC
+// address, IP address and component ID
+type interfaceList struct {
+	interfaces  []HSMEthernetInterface
+	byMAC       map[string]*HSMEthernetInterface
+	byIP        map[string]*HSMEthernetInterface
+	byComponent map[string][]HSMEthernetInterface
+}
+
+func newInterfaceList(interfaces []HSMEthernetInterface) *interfaceList {
+	list := &interfaceList{
+		interfaces:  interfaces,
+		byMAC:       make(map[string]*HSMEthernetInterface, len(interfaces)),
+		byIP:        make(map[string]*HSMEthernetInterface),
+		byComponent: make(map[string][]HSMEthernetInterface),
+	}
+	for i := range interfaces {
+		iface := &interfaces[i]
+		// The first interface listed with an address wins
+		if _, found := list.byMAC[macKey(iface.MACAddress)]; !found && iface.MACAddress != "" {
+			list.byMAC[macKey(iface.MACAddress)] = iface
+		}
+		for _, address := range iface.IPAddresses {
+			if key := ipKey(address.IPAddress); key != "" {
+				if _, found := list.byIP[key]; !found {
+					list.byIP[key] = iface
+				}
+			}
+		}
+		list.byComponent[iface.ComponentID] = append(list.byComponent[iface.ComponentID], *iface)
+	}
+	return list
+}
+
+// macKey returns the index key of a MAC address in any notation
+func macKey(mac string) string {
+	if normalized, err := validation.NormalizeMAC(mac); err == nil {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(mac))
+}
+
+// ipKey returns the index key of an IP address in any notation, or "" if it is invalid
+func ipKey(ip string) string {
+	if parsed := net.ParseIP(strings.TrimSpace(ip)); parsed != nil {
+		return parsed.String()
+	}
+	return ""
+}
@@ -6,13 +6,21 @@
 package hsm
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/openchami/boot-service/pkg/auth"
 )
 
 // HSMComponent represents a component from HSM
@@ -21,7 +29,7 @@ type HSMComponent struct { //nolint:revive
 	Type            string            `json:"Type"`
 	State           string            `json:"State"`
 	Flag            string            `json:"Flag"`
-	Enabled         bool              `json:"Enabled"`
+	Enabled         *bool             `json:"Enabled,omitempty"` // nil when HSM omits it
 	Role            string            `json:"Role"`
 	SubRole         string            `json:"SubRole"`
 	NID             int32             `json:"NID,omitempty"`
@@ -39,12 +47,50 @@ type HSMResponse struct { //nolint:revive
 
 // HSMEthernetInterface represents network interface information from HSM
 type HSMEthernetInterface struct { //nolint:revive
-	MACAddress  string `json:"MACAddress"`
-	IPAddress   string `json:"IPAddress,omitempty"`
-	ComponentID string `json:"ComponentID"`
-	Description string `json:"Description,omitempty"`
-	Type        string `json:"Type,omitempty"`
-	LastUpdate  string `json:"LastUpdate,omitempty"`
+	MACAddress  string         `json:"MACAddress"`
+	IPAddresses []HSMIPAddress `json:"IPAddresses,omitempty"`
+	ComponentID string         `json:"ComponentID"`
+	Description string         `json:"Description,omitempty"`
+	Type        string         `json:"Type,omitempty"`
+	LastUpdate  string         `json:"LastUpdate,omitempty"`
+}
+
+// HSMIPAddress is one address of an HSM ethernet interface
+type HSMIPAddress struct { //nolint:revive
+	IPAddress string `json:"IPAddress"`
+	Network   string `json:"Network,omitempty"`
+}
+
+// primaryIP returns the first address HSM lists for the interface, or "" if it has none
+func (i HSMEthernetInterface) primaryIP() string {
+	for _, address := range i.IPAddresses {
+		if address.IPAddress != "" {
+			return address.IPAddress
+		}
+	}
+	return ""
+}
+
+// HSMMembers lists the component IDs of an HSM group or partition
+type HSMMembers struct { //nolint:revive
+	IDs []string `json:"ids"`
+}
+
+// HSMGroup represents a group from HSM
+type HSMGroup struct { //nolint:revive
+	Label          string     `json:"label"`
+	Description    string     `json:"description,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	ExclusiveGroup string     `json:"exclusiveGroup,omitempty"`
+	Members        HSMMembers `json:"members"`
+}
+
+// HSMPartition represents a partition from HSM
+type HSMPartition struct { //nolint:revive
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Members     HSMMembers `json:"members"`
 }
 
 // HSMEthernetResponse represents the response from HSM ethernet interfaces endpoint
@@ -61,8 +107,25 @@ type HSMConfig struct { //nolint:revive
 	CacheExpiry          time.Duration `json:"cacheExpiry"`
 	AuthToken            string        `json:"authToken,omitempty"`
 	EnableCircuitBreaker bool          `json:"enableCircuitBreaker"`
+
+	// TokenSource provides HSM bearer tokens and takes precedence over AuthToken
+	TokenSource auth.TokenSource `json:"-"`
+
+	// CircuitBreakerThreshold is the number of consecutive failed requests that opens the breaker
+	CircuitBreakerThreshold int `json:"circuitBreakerThreshold"`
+	// CircuitBreakerCooldown is how long the breaker stays open before a trial request
+	CircuitBreakerCooldown time.Duration `json:"circuitBreakerCooldown"`
+
+	// RefreshAhead reloads cached lists in the background when they expire within
+	// this duration, at most half of CacheExpiry (0 to disable)
+	RefreshAhead time.Duration `json:"refreshAhead"`
+	// MaxStaleness is how long after expiry cached data is still served when HSM can't be reached (0 to disable)
+	MaxStaleness time.Duration `json:"maxStaleness"`
 }
 
+// maxRetryDelay caps the backoff between retries
+const maxRetryDelay = 30 * time.Second
+
 // DefaultHSMConfig returns a default HSM configuration
 func DefaultHSMConfig() HSMConfig {
 	return HSMConfig{
@@ -72,6 +135,12 @@ func DefaultHSMConfig() HSMConfig {
 		RetryDelay:           1 * time.Second,
 		CacheExpiry:          5 * time.Minute,
 		EnableCircuitBreaker: true,
+
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerCooldown:  30 * time.Second,
+
+		RefreshAhead: 1 * time.Minute,
+		MaxStaleness: 30 * time.Minute,
 	}
 }
 
@@ -81,77 +150,13 @@ type HSMClient struct { //nolint:revive
 	httpClient *http.Client
 	logger     *log.Logger
 	cache      *HSMCache
-}
-
-// HSMCache provides caching for HSM responses to reduce load
-type HSMCache struct { //nolint:revive
-	components         map[string]*CacheEntry
-	ethernetInterfaces map[string]*CacheEntry
-	mu                 sync.RWMutex
-	expiry             time.Duration
-}
-
-// CacheEntry represents a cached item with expiration
-type CacheEntry struct {
-	Data      interface{}
-	ExpiresAt time.Time
-}
-
-// NewHSMCache creates a new HSM cache
-func NewHSMCache(expiry time.Duration) *HSMCache {
-	return &HSMCache{
-		components:         make(map[string]*CacheEntry),
-		ethernetInterfaces: make(map[string]*CacheEntry),
-		expiry:             expiry,
-	}
-}
-
-// GetComponent retrieves a component from cache if not expired
-func (c *HSMCache) GetComponent(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, exists := c.components[key]
-	if !exists || time.Now().After(entry.ExpiresAt) {
-		return nil, false
-	}
-
-	return entry.Data, true
-}
-
-// GetEthernet retrieves an ethernet interface from cache if not expired
-func (c *HSMCache) GetEthernet(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, exists := c.ethernetInterfaces[key]
-	if !exists || time.Now().After(entry.ExpiresAt) {
-		return nil, false
-	}
-
-	return entry.Data, true
-}
-
-// SetComponent stores a component in cache with expiration
-func (c *HSMCache) SetComponent(key string, data interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.components[key] = &CacheEntry{
-		Data:      data,
-		ExpiresAt: time.Now().Add(c.expiry),
-	}
-}
 
-// SetEthernet stores an ethernet interface in cache with expiration
-func (c *HSMCache) SetEthernet(key string, data interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	breaker        *circuitBreaker
+	staleResponses atomic.Int64
+	refreshes      atomic.Int64
 
-	c.ethernetInterfaces[key] = &CacheEntry{
-		Data:      data,
-		ExpiresAt: time.Now().Add(c.expiry),
-	}
+	refreshMu  sync.Mutex
+	refreshing map[refreshKey]bool
 }
 
 // NewHSMClient creates a new HSM client
@@ -176,163 +181,399 @@ func NewHSMClient(config HSMConfig, logger *log.Logger) *HSMClient {
 		httpClient: httpClient,
 		logger:     logger,
 		cache:      cache,
+		breaker:    newCircuitBreaker(config.EnableCircuitBreaker, config.CircuitBreakerThreshold, config.CircuitBreakerCooldown),
+		refreshing: make(map[refreshKey]bool),
 	}
 }
 
 // GetComponents retrieves all components from HSM
 func (c *HSMClient) GetComponents(ctx context.Context) ([]HSMComponent, error) {
-	// Check cache first
-	if data, found := c.cache.GetComponent("all_components"); found {
-		c.logger.Printf("HSM components cache hit")
-		return data.([]HSMComponent), nil
-	}
+	return c.GetComponentsWithQuery(ctx, nil)
+}
 
-	url := fmt.Sprintf("%s/hsm/v2/State/Components", c.config.BaseURL)
+// GetComponentsWithQuery retrieves the components matching HSM query
+// parameters, e.g., type=Node&role=Compute, so HSM does the filtering
+func (c *HSMClient) GetComponentsWithQuery(ctx context.Context, query url.Values) ([]HSMComponent, error) {
+	cacheKey := "all_components"
+	path := "/hsm/v2/State/Components"
+	if encoded := query.Encode(); encoded != "" {
+		cacheKey = "components?" + encoded
+		path += "?" + encoded
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	data, err := c.load(ctx, componentTable, cacheKey, true, func(ctx context.Context) (interface{}, error) {
+		var hsmResp HSMResponse
+		if err := c.get(ctx, path, &hsmResp); err != nil {
+			return nil, err
+		}
+		c.logger.Printf("Retrieved %d components from HSM", len(hsmResp.Components))
+		return newComponentList(hsmResp.Components), nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HSM request: %w", err)
+		return nil, err
 	}
+	return data.(*componentList).components, nil
+}
 
-	// Add authentication if provided
-	if c.config.AuthToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+// GetComponent retrieves a specific component by ID from HSM
+func (c *HSMClient) GetComponent(ctx context.Context, componentID string) (*HSMComponent, error) {
+	// Cached component lists are indexed by ID
+	if comp, found := c.cache.componentByID(componentID); found {
+		return comp, nil
 	}
 
-	resp, err := c.httpClient.Do(req)
+	cacheKey := fmt.Sprintf("component_%s", componentID)
+	data, err := c.load(ctx, componentTable, cacheKey, false, func(ctx context.Context) (interface{}, error) {
+		var component HSMComponent
+		if err := c.get(ctx, "/hsm/v2/State/Components/"+url.PathEscape(componentID), &component); err != nil {
+			return nil, err
+		}
+		c.logger.Printf("Retrieved component %s from HSM", componentID)
+		return &component, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to call HSM: %w", err)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("component %s not found in HSM", componentID)
+		}
+		return nil, fmt.Errorf("failed to get component %s: %w", componentID, err)
 	}
-	defer resp.Body.Close() //nolint:errcheck //nolint:errcheck
+	return data.(*HSMComponent), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HSM returned status %d", resp.StatusCode)
+// GetEthernetInterfaces retrieves all ethernet interfaces from HSM
+func (c *HSMClient) GetEthernetInterfaces(ctx context.Context) ([]HSMEthernetInterface, error) {
+	list, err := c.interfaceList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return list.interfaces, nil
+}
+
+// GetComponentInterfaces retrieves the ethernet interfaces of a component from HSM
+func (c *HSMClient) GetComponentInterfaces(ctx context.Context, componentID string) ([]HSMEthernetInterface, error) {
+	list, err := c.interfaceList(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return list.byComponent[componentID], nil
+}
 
-	var hsmResp HSMResponse
-	if err := json.NewDecoder(resp.Body).Decode(&hsmResp); err != nil {
-		return nil, fmt.Errorf("failed to decode HSM response: %w", err)
+// interfaceList returns the indexed ethernet interface list
+func (c *HSMClient) interfaceList(ctx context.Context) (*interfaceList, error) {
+	data, err := c.load(ctx, ethernetTable, "all_ethernet", true, func(ctx context.Context) (interface{}, error) {
+		var interfaces ethernetInterfaceList
+		if err := c.get(ctx, "/hsm/v2/Inventory/EthernetInterfaces", &interfaces); err != nil {
+			return nil, err
+		}
+		c.logger.Printf("Retrieved %d ethernet interfaces from HSM", len(interfaces))
+		return newInterfaceList(interfaces), nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return data.(*interfaceList), nil
+}
 
-	// Cache the result
-	c.cache.SetComponent("all_components", hsmResp.Components)
+// ethernetInterfaceList decodes the ethernet interfaces returned by HSM
+// either as a bare array or wrapped in an EthernetInterfaces object
+type ethernetInterfaceList []HSMEthernetInterface
 
-	c.logger.Printf("Retrieved %d components from HSM", len(hsmResp.Components))
-	return hsmResp.Components, nil
+// UnmarshalJSON implements json.Unmarshaler
+func (l *ethernetInterfaceList) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped HSMEthernetResponse
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return err
+		}
+		*l = wrapped.EthernetInterfaces
+		return nil
+	}
+	return json.Unmarshal(data, (*[]HSMEthernetInterface)(l))
 }
 
-// GetComponent retrieves a specific component by ID from HSM
-func (c *HSMClient) GetComponent(ctx context.Context, componentID string) (*HSMComponent, error) {
-	// Check cache first
-	cacheKey := fmt.Sprintf("component_%s", componentID)
-	if data, found := c.cache.GetComponent(cacheKey); found {
-		c.logger.Printf("HSM component cache hit for %s", componentID)
-		return data.(*HSMComponent), nil
+// GetComponentByMAC finds a component by its MAC address
+func (c *HSMClient) GetComponentByMAC(ctx context.Context, macAddress string) (*HSMComponent, error) {
+	// Get ethernet interfaces to find the component ID
+	list, err := c.interfaceList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ethernet interfaces: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/hsm/v2/State/Components/%s", c.config.BaseURL, componentID)
+	iface, found := list.byMAC[macKey(macAddress)]
+	if !found || macAddress == "" {
+		return nil, fmt.Errorf("no component found for MAC address %s", macAddress)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Get the component details
+	return c.GetComponent(ctx, iface.ComponentID)
+}
+
+// GetComponentByIP finds a component by the IP address of one of its ethernet interfaces
+func (c *HSMClient) GetComponentByIP(ctx context.Context, ipAddress string) (*HSMComponent, error) {
+	// Get ethernet interfaces to find the component ID
+	list, err := c.interfaceList(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HSM request: %w", err)
+		return nil, fmt.Errorf("failed to get ethernet interfaces: %w", err)
 	}
 
-	// Add authentication if provided
-	if c.config.AuthToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+	iface, found := list.byIP[ipKey(ipAddress)]
+	if !found {
+		return nil, fmt.Errorf("no component found for IP address %s", ipAddress)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	// Get the component details
+	return c.GetComponent(ctx, iface.ComponentID)
+}
+
+// GetGroups retrieves all groups and their members from HSM
+func (c *HSMClient) GetGroups(ctx context.Context) ([]HSMGroup, error) {
+	data, err := c.load(ctx, componentTable, "all_groups", true, func(ctx context.Context) (interface{}, error) {
+		var groups []HSMGroup
+		if err := c.get(ctx, "/hsm/v2/groups", &groups); err != nil {
+			return nil, err
+		}
+		c.logger.Printf("Retrieved %d groups from HSM", len(groups))
+		return groups, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to call HSM: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close() //nolint:errcheck //nolint:errcheck
+	return data.([]HSMGroup), nil
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("component %s not found in HSM", componentID)
+// GetPartitions retrieves all partitions and their members from HSM
+func (c *HSMClient) GetPartitions(ctx context.Context) ([]HSMPartition, error) {
+	data, err := c.load(ctx, componentTable, "all_partitions", true, func(ctx context.Context) (interface{}, error) {
+		var partitions []HSMPartition
+		if err := c.get(ctx, "/hsm/v2/partitions", &partitions); err != nil {
+			return nil, err
+		}
+		c.logger.Printf("Retrieved %d partitions from HSM", len(partitions))
+		return partitions, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return data.([]HSMPartition), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HSM returned status %d for component %s", resp.StatusCode, componentID)
+// fetchFunc retrieves a value to cache from HSM
+type fetchFunc func(ctx context.Context) (interface{}, error)
+
+// load returns a cached value, fetching it from HSM when it is missing or
+// expired. If HSM can't be reached, a value that expired less than
+// MaxStaleness ago is served instead. With refreshAhead, values about to
+// expire are reloaded in the background so requests don't wait on HSM.
+func (c *HSMClient) load(ctx context.Context, table cacheTable, key string, refreshAhead bool, fetch fetchFunc) (interface{}, error) {
+	entry, cached := c.cache.entry(table, key)
+	if cached && time.Now().Before(entry.ExpiresAt) {
+		// The window is capped at half the expiry so short-lived entries aren't reloaded on every hit
+		window := min(c.config.RefreshAhead, c.cache.expiry/2)
+		if refreshAhead && window > 0 && time.Until(entry.ExpiresAt) <= window {
+			c.refresh(table, key, fetch)
+		}
+		return entry.Data, nil
 	}
 
-	var component HSMComponent
-	if err := json.NewDecoder(resp.Body).Decode(&component); err != nil {
-		return nil, fmt.Errorf("failed to decode HSM response: %w", err)
+	data, err := fetch(ctx)
+	if err == nil {
+		c.cache.set(table, key, data)
+		return data, nil
 	}
 
-	// Cache the result
-	c.cache.SetComponent(cacheKey, &component)
+	if cached && transient(err) && ctx.Err() == nil && !freshRequired(ctx) {
+		if stale, found := c.cache.getStale(table, key, c.config.MaxStaleness); found {
+			c.staleResponses.Add(1)
+			c.logger.Printf("Serving %s from cache, expired %v ago, after HSM error: %v",
+				key, time.Since(entry.ExpiresAt).Round(time.Second), err)
+			return stale, nil
+		}
+	}
+	return nil, err
+}
 
-	c.logger.Printf("Retrieved component %s from HSM", componentID)
-	return &component, nil
+// refresh reloads a cached value in the background unless a reload is already running
+func (c *HSMClient) refresh(table cacheTable, key string, fetch fetchFunc) {
+	id := refreshKey{table: table, key: key}
+	c.refreshMu.Lock()
+	if c.refreshing[id] {
+		c.refreshMu.Unlock()
+		return
+	}
+	c.refreshing[id] = true
+	c.refreshMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.refreshMu.Lock()
+			delete(c.refreshing, id)
+			c.refreshMu.Unlock()
+		}()
+
+		data, err := fetch(context.Background())
+		if err != nil {
+			c.logger.Printf("Failed to refresh %s ahead of expiry: %v", key, err)
+			return
+		}
+		c.cache.set(table, key, data)
+		c.refreshes.Add(1)
+	}()
 }
 
-// GetEthernetInterfaces retrieves all ethernet interfaces from HSM
-func (c *HSMClient) GetEthernetInterfaces(ctx context.Context) ([]HSMEthernetInterface, error) {
-	// Check cache first
-	if data, found := c.cache.GetEthernet("all_ethernet"); found {
-		c.logger.Printf("HSM ethernet interfaces cache hit")
-		return data.([]HSMEthernetInterface), nil
-	}
+// refreshKey identifies a background refresh
+type refreshKey struct {
+	table cacheTable
+	key   string
+}
 
-	url := fmt.Sprintf("%s/hsm/v2/Inventory/EthernetInterfaces", c.config.BaseURL)
+// freshRequiredKey marks contexts that must not be served stale data
+type freshRequiredKey struct{}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HSM request: %w", err)
+// withFreshData returns a context whose HSM requests fail instead of being
+// served stale data, e.g., for sync, which must not report success while HSM is down
+func withFreshData(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshRequiredKey{}, true)
+}
+
+func freshRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(freshRequiredKey{}).(bool)
+	return required
+}
+
+// StatusError is returned when HSM responds with a status other than 200 OK
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HSM returned status %d", e.StatusCode)
+}
+
+// transient reports whether a request failing with err may succeed when retried
+func transient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
 	}
+	var decodeErr *decodeError
+	return !errors.As(err, &decodeErr)
+}
 
-	// Add authentication if provided
-	if c.config.AuthToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+// decodeError wraps an HSM response that could not be decoded
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string { return "failed to decode HSM response: " + e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// get requests an HSM path and decodes the JSON response into out. Transient
+// failures are retried with jittered exponential backoff, and the circuit
+// breaker fails requests fast while HSM keeps failing.
+func (c *HSMClient) get(ctx context.Context, path string, out interface{}) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call HSM: %w", err)
+	attempts := 1 + max(c.config.RetryAttempts, 0)
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = c.do(ctx, http.MethodGet, path, nil, out)
+		if err == nil || !transient(err) || attempt >= attempts || ctx.Err() != nil {
+			break
+		}
+
+		delay := c.backoff(attempt)
+		c.logger.Printf("HSM request %s failed (attempt %d of %d), retrying in %v: %v", path, attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
 	}
-	defer resp.Body.Close() //nolint:errcheck //nolint:errcheck
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HSM returned status %d", resp.StatusCode)
+	if ctx.Err() != nil {
+		// The caller gave up, which says nothing about HSM
+		c.breaker.release()
+	} else {
+		c.breaker.record(err != nil && transient(err))
 	}
+	return err
+}
 
-	var hsmResp []HSMEthernetInterface
-	if err := json.NewDecoder(resp.Body).Decode(&hsmResp); err != nil {
-		return nil, fmt.Errorf("failed to decode HSM response: %w", err)
+// do makes a single request to HSM with an optional JSON body and decodes
+// the JSON response into out unless out is nil
+func (c *HSMClient) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	resp, token, err := c.send(ctx, method, path, body)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.config.TokenSource != nil {
+		// The token may have been revoked or rotated early: get a new one and try once more
+		resp.Body.Close() //nolint:errcheck
+		c.config.TokenSource.Invalidate(token)
+		c.logger.Printf("HSM rejected the token for %s, retrying with a new token", path)
+		resp, _, err = c.send(ctx, method, path, body)
 	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
 
-	// Cache the result
-	c.cache.SetEthernet("all_ethernet", hsmResp)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
 
-	c.logger.Printf("Retrieved %d ethernet interfaces from HSM", len(hsmResp))
-	return hsmResp, nil
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &decodeError{err: err}
+	}
+	return nil
 }
 
-// GetComponentByMAC finds a component by its MAC address
-func (c *HSMClient) GetComponentByMAC(ctx context.Context, macAddress string) (*HSMComponent, error) {
-	// Get ethernet interfaces to find the component ID
-	interfaces, err := c.GetEthernetInterfaces(ctx)
+// send makes an authenticated request to HSM and returns the token it sent
+func (c *HSMClient) send(ctx context.Context, method, path string, body []byte) (*http.Response, string, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ethernet interfaces: %w", err)
+		return nil, "", fmt.Errorf("failed to create HSM request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	// Find the component ID for this MAC address
-	var componentID string
-	for _, iface := range interfaces {
-		if iface.MACAddress == macAddress {
-			componentID = iface.ComponentID
-			break
+	// Add authentication if provided
+	token := c.config.AuthToken
+	if c.config.TokenSource != nil {
+		if token, err = c.config.TokenSource.Token(ctx); err != nil {
+			return nil, "", fmt.Errorf("failed to get HSM token: %w", err)
 		}
 	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	if componentID == "" {
-		return nil, fmt.Errorf("no component found for MAC address %s", macAddress)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to call HSM: %w", err)
 	}
+	return resp, token, nil
+}
 
-	// Get the component details
-	return c.GetComponent(ctx, componentID)
+// backoff returns the jittered delay before the retry following an attempt:
+// between half and all of RetryDelay doubled for each previous attempt
+func (c *HSMClient) backoff(attempt int) time.Duration {
+	delay := c.config.RetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
 }
 
 // Health checks if HSM is reachable and responding
@@ -357,6 +598,18 @@ func (c *HSMClient) Health(ctx context.Context) error {
 	return nil
 }
 
+// invalidateComponents drops cached data that changes of the given components
+// make stale; see HSMCache.invalidateComponents
+func (c *HSMClient) invalidateComponents(ids []string) {
+	c.cache.invalidateComponents(ids)
+}
+
+// replaceInterfaces updates the cached ethernet interfaces of components with
+// ones just read from HSM
+func (c *HSMClient) replaceInterfaces(ids []string, interfaces []HSMEthernetInterface) {
+	c.cache.replaceInterfaces(ids, interfaces)
+}
+
 // ClearCache clears all cached HSM data
 func (c *HSMClient) ClearCache() {
 	c.cache.mu.Lock()
@@ -373,7 +626,7 @@ func (c *HSMClient) GetStats(ctx context.Context) map[string]interface{} { //nol
 	stats := map[string]interface{}{
 		"hsm_base_url":    c.config.BaseURL,
 		"cache_enabled":   c.cache != nil,
-		"authenticated":   c.config.AuthToken != "",
+		"authenticated":   c.config.AuthToken != "" || c.config.TokenSource != nil,
 		"cache_expiry":    c.cache.expiry.String(),
 		"request_timeout": c.config.Timeout.String(),
 	}
@@ -389,5 +642,11 @@ func (c *HSMClient) GetStats(ctx context.Context) map[string]interface{} { //nol
 		stats["cached_interfaces"] = interfaceCount
 	}
 
+	stats["circuit_breaker"] = c.breaker.stats()
+	stats["stale_responses"] = c.staleResponses.Load()
+	stats["max_staleness"] = c.config.MaxStaleness.String()
+	stats["refresh_ahead"] = c.config.RefreshAhead.String()
+	stats["background_refreshes"] = c.refreshes.Load()
+
 	return stats
 }
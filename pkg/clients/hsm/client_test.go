@@ -8,16 +8,20 @@ package hsm
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 // TestHSMClient_GetComponents tests retrieving components from HSM
 func TestHSMClient_GetComponents(t *testing.T) {
+	enabled := true
 	// Mock HSM server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/hsm/v2/State/Components" {
@@ -30,7 +34,7 @@ func TestHSMClient_GetComponents(t *testing.T) {
 					ID:      "x1000c0s0b0n0",
 					Type:    "Node",
 					State:   "Ready",
-					Enabled: true,
+					Enabled: &enabled,
 					Role:    "Compute",
 					NID:     123,
 				},
@@ -38,7 +42,7 @@ func TestHSMClient_GetComponents(t *testing.T) {
 					ID:      "x1000c0s0b0n1",
 					Type:    "Node",
 					State:   "Ready",
-					Enabled: true,
+					Enabled: &enabled,
 					Role:    "Compute",
 					NID:     124,
 				},
@@ -83,6 +87,7 @@ func TestHSMClient_GetComponents(t *testing.T) {
 
 // TestHSMClient_GetComponent tests retrieving a specific component
 func TestHSMClient_GetComponent(t *testing.T) {
+	enabled := true
 	// Mock HSM server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		expectedPath := "/hsm/v2/State/Components/x1000c0s0b0n0"
@@ -94,7 +99,7 @@ func TestHSMClient_GetComponent(t *testing.T) {
 			ID:      "x1000c0s0b0n0",
 			Type:    "Node",
 			State:   "Ready",
-			Enabled: true,
+			Enabled: &enabled,
 			Role:    "Compute",
 			NID:     123,
 		}
@@ -189,6 +194,7 @@ func TestHSMClient_GetEthernetInterfaces(t *testing.T) {
 
 // TestHSMClient_GetComponentByMAC tests finding a component by MAC address
 func TestHSMClient_GetComponentByMAC(t *testing.T) {
+	enabled := true
 	// Mock HSM server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -210,7 +216,7 @@ func TestHSMClient_GetComponentByMAC(t *testing.T) {
 				ID:      "x1000c0s0b0n0",
 				Type:    "Node",
 				State:   "Ready",
-				Enabled: true,
+				Enabled: &enabled,
 				Role:    "Compute",
 				NID:     123,
 			}
@@ -251,6 +257,7 @@ func TestHSMClient_GetComponentByMAC(t *testing.T) {
 
 // TestHSMClient_Cache tests caching functionality
 func TestHSMClient_Cache(t *testing.T) {
+	enabled := true
 	callCount := 0
 
 	// Mock HSM server that tracks calls
@@ -263,7 +270,7 @@ func TestHSMClient_Cache(t *testing.T) {
 					ID:      "x1000c0s0b0n0",
 					Type:    "Node",
 					State:   "Ready",
-					Enabled: true,
+					Enabled: &enabled,
 					Role:    "Compute",
 					NID:     123,
 				},
@@ -366,6 +373,7 @@ func TestHSMClient_ErrorHandling(t *testing.T) {
 	// Create client
 	config := DefaultHSMConfig()
 	config.BaseURL = server.URL
+	config.RetryDelay = time.Millisecond
 
 	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 	client := NewHSMClient(config, logger)
@@ -386,3 +394,301 @@ func TestHSMClient_ErrorHandling(t *testing.T) {
 
 	t.Logf("✅ Error handling working correctly")
 }
+
+// TestHSMClient_Retry tests that transient failures are retried and others are not
+func TestHSMClient_Retry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch {
+		case r.URL.Path == "/hsm/v2/State/Components/x1000c0s0b0n9":
+			http.NotFound(w, r)
+		case n <= 2:
+			http.Error(w, "upgrading", http.StatusServiceUnavailable)
+		default:
+			json.NewEncoder(w).Encode(HSMResponse{Components: []HSMComponent{{ID: "x1000c0s0b0n0"}}}) //nolint:errcheck
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	config.RetryDelay = time.Millisecond
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+
+	components, err := client.GetComponents(context.Background())
+	if err != nil || len(components) != 1 {
+		t.Fatalf("Expected the request to succeed after retries, got %v, %v", components, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls.Load())
+	}
+
+	calls.Store(10)
+	if _, err := client.GetComponent(context.Background(), "x1000c0s0b0n9"); err == nil {
+		t.Errorf("Expected an error for a missing component")
+	}
+	if calls.Load() != 11 {
+		t.Errorf("Expected a 404 not to be retried, got %d calls", calls.Load()-10)
+	}
+}
+
+// TestHSMClient_CircuitBreaker tests that the breaker opens after consecutive
+// failures, serves stale cache while open and closes after a successful trial
+func TestHSMClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		calls.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(HSMResponse{Components: []HSMComponent{{ID: "x1000c0s0b0n0"}}}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	config.RetryAttempts = 0
+	config.CacheExpiry = time.Nanosecond
+	config.CircuitBreakerThreshold = 2
+	config.CircuitBreakerCooldown = 50 * time.Millisecond
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+	ctx := context.Background()
+
+	if _, err := client.GetComponents(ctx); err != nil {
+		t.Fatalf("Failed to get components: %v", err)
+	}
+
+	// Failed requests are answered from the expired cache
+	healthy.Store(false)
+	for i := 0; i < 2; i++ {
+		if components, err := client.GetComponents(ctx); err != nil || len(components) != 1 {
+			t.Fatalf("Expected stale components after HSM failure %d, got %v, %v", i+1, components, err)
+		}
+	}
+
+	// The breaker is open: the expired cache is served without calling HSM
+	calls.Store(0)
+	components, err := client.GetComponents(ctx)
+	if err != nil || len(components) != 1 || calls.Load() != 0 {
+		t.Errorf("Expected stale components without an HSM call, got %v, %v after %d calls", components, err, calls.Load())
+	}
+	if _, err := client.GetComponent(ctx, "x1000c0s0b0n1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected uncached requests to fail fast, got %v", err)
+	}
+	stats := client.GetStats(ctx)
+	if breaker := stats["circuit_breaker"].(map[string]interface{}); breaker["state"] != BreakerOpen {
+		t.Errorf("Expected the breaker state in stats, got %v", breaker)
+	}
+	if stats["stale_responses"] != int64(3) {
+		t.Errorf("Expected 3 stale responses, got %v", stats["stale_responses"])
+	}
+
+	// After the cooldown a successful trial request closes the breaker
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.GetComponents(ctx); err != nil || calls.Load() != 1 {
+		t.Fatalf("Expected a trial request to reach HSM, got %v after %d calls", err, calls.Load())
+	}
+	if state := client.breaker.stats()["state"]; state != BreakerClosed {
+		t.Errorf("Expected the breaker to close, got %v", state)
+	}
+}
+
+// TestHSMClient_MaxStaleness tests that stale data is only served up to the configured age
+func TestHSMClient_MaxStaleness(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]HSMEthernetInterface{ //nolint:errcheck
+			{MACAddress: "00:1B:63:84:45:E6", ComponentID: "x1000c0s0b0n0", Type: "Node"},
+		})
+	}))
+	defer server.Close()
+
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	config.RetryAttempts = 0
+	config.EnableCircuitBreaker = false
+	config.CacheExpiry = time.Nanosecond
+	config.MaxStaleness = 50 * time.Millisecond
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+	ctx := context.Background()
+
+	if _, err := client.GetEthernetInterfaces(ctx); err != nil {
+		t.Fatalf("Failed to get ethernet interfaces: %v", err)
+	}
+	healthy.Store(false)
+	if interfaces, err := client.GetEthernetInterfaces(ctx); err != nil || len(interfaces) != 1 {
+		t.Errorf("Expected stale interfaces within the maximum staleness, got %v, %v", interfaces, err)
+	}
+	if _, err := client.GetEthernetInterfaces(withFreshData(ctx)); err == nil {
+		t.Errorf("Expected requests requiring fresh data to fail")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.GetEthernetInterfaces(ctx); err == nil {
+		t.Errorf("Expected data older than the maximum staleness not to be served")
+	}
+}
+
+// TestHSMClient_RefreshAhead tests that lists about to expire are reloaded in the background
+func TestHSMClient_RefreshAhead(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		calls.Add(1)
+		json.NewEncoder(w).Encode(HSMResponse{Components: []HSMComponent{{ID: "x1000c0s0b0n0"}}}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	config.CacheExpiry = 200 * time.Millisecond
+	config.RefreshAhead = 150 * time.Millisecond // Capped at 100ms
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+	ctx := context.Background()
+
+	if _, err := client.GetComponents(ctx); err != nil {
+		t.Fatalf("Failed to get components: %v", err)
+	}
+	time.Sleep(120 * time.Millisecond)
+
+	// A hit inside the refresh window is served from cache and reloads it in the background
+	if _, err := client.GetComponents(ctx); err != nil {
+		t.Fatalf("Failed to get components: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for client.refreshes.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if calls.Load() != 2 || client.refreshes.Load() != 1 {
+		t.Fatalf("Expected one background refresh, got %d calls and %d refreshes", calls.Load(), client.refreshes.Load())
+	}
+
+	// The refreshed entry is valid past the original expiry
+	time.Sleep(100 * time.Millisecond)
+	if _, err := client.GetComponents(ctx); err != nil || calls.Load() != 2 {
+		t.Errorf("Expected the refreshed entry to be served, got %v after %d calls", err, calls.Load())
+	}
+}
+
+// TestHSMClient_Indexes tests lookups through the MAC, IP and ID indexes
+func TestHSMClient_Indexes(t *testing.T) {
+	var componentCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hsm/v2/Inventory/EthernetInterfaces":
+			// HSM v2 lists every address of an interface with its network
+			w.Write([]byte(`[
+				{"ID": "001b638445e6", "MACAddress": "00:1B:63:84:45:E6", "ComponentID": "x1000c0s0b0n0", "Type": "Node",
+					"IPAddresses": [{"IPAddress": "10.0.0.1", "Network": "NMN"}, {"IPAddress": "10.100.0.1", "Network": "HMN"}]},
+				{"ID": "001b638445e7", "MACAddress": "00:1B:63:84:45:E7", "ComponentID": "x1000c0s0b0n1", "Type": "Node",
+					"IPAddresses": [{"IPAddress": "10.0.0.2"}]}
+			]`)) //nolint:errcheck
+		case "/hsm/v2/State/Components":
+			componentCalls.Add(1)
+			json.NewEncoder(w).Encode(HSMResponse{Components: []HSMComponent{ //nolint:errcheck
+				{ID: "x1000c0s0b0n0", NID: 1}, {ID: "x1000c0s0b0n1", NID: 2},
+			}})
+		default:
+			t.Errorf("Unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+	ctx := context.Background()
+
+	if _, err := client.GetComponents(ctx); err != nil {
+		t.Fatalf("Failed to get components: %v", err)
+	}
+
+	// Components are found in the cached list without per-component requests
+	if comp, err := client.GetComponentByMAC(ctx, "001b.6384.45e7"); err != nil || comp.NID != 2 {
+		t.Errorf("Expected the MAC lookup to find NID 2, got %+v, %v", comp, err)
+	}
+	for _, ip := range []string{"10.0.0.1", "10.100.0.1"} {
+		if comp, err := client.GetComponentByIP(ctx, ip); err != nil || comp.NID != 1 {
+			t.Errorf("Expected the IP lookup of %s to find NID 1, got %+v, %v", ip, comp, err)
+		}
+	}
+	if _, err := client.GetComponentByMAC(ctx, "00:1B:63:84:45:E8"); err == nil {
+		t.Errorf("Expected an unknown MAC address not to be found")
+	}
+	if interfaces, err := client.GetComponentInterfaces(ctx, "x1000c0s0b0n1"); err != nil || len(interfaces) != 1 {
+		t.Errorf("Expected one interface for x1000c0s0b0n1, got %v, %v", interfaces, err)
+	}
+	if componentCalls.Load() != 1 {
+		t.Errorf("Expected one component list request, got %d", componentCalls.Load())
+	}
+}
+
+// countingTokenSource issues a new token each time the current one is invalidated
+type countingTokenSource struct {
+	issued atomic.Int32
+}
+
+func (s *countingTokenSource) Token(ctx context.Context) (string, error) { //nolint:revive
+	s.issued.CompareAndSwap(0, 1)
+	return fmt.Sprintf("token-%d", s.issued.Load()), nil
+}
+
+func (s *countingTokenSource) Invalidate(rejected string) {
+	current := s.issued.Load()
+	if rejected == fmt.Sprintf("token-%d", current) {
+		s.issued.CompareAndSwap(current, current+1)
+	}
+}
+
+// TestHSMClient_TokenSource tests that requests use the token source and
+// retry once with a new token when HSM rejects the current one
+func TestHSMClient_TokenSource(t *testing.T) {
+	var accepted atomic.Value
+	accepted.Store("Bearer token-2")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != accepted.Load().(string) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(HSMResponse{Components: []HSMComponent{{ID: "x1000c0s0b0n0"}}}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	tokens := &countingTokenSource{}
+	config := DefaultHSMConfig()
+	config.BaseURL = server.URL
+	config.RetryAttempts = 0
+	config.CacheExpiry = 0
+	config.MaxStaleness = 0
+	config.TokenSource = tokens
+	client := NewHSMClient(config, log.New(os.Stdout, "test: ", log.LstdFlags))
+	ctx := context.Background()
+
+	if _, err := client.GetComponents(ctx); err != nil {
+		t.Fatalf("Expected the request to succeed with a refreshed token: %v", err)
+	}
+	if tokens.issued.Load() != 2 {
+		t.Errorf("Expected one token refresh, got %d tokens", tokens.issued.Load())
+	}
+
+	// A token that is still rejected after the refresh fails the request
+	accepted.Store("Bearer never")
+	_, err := client.GetComponents(ctx)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 error, got %v", err)
+	}
+	if tokens.issued.Load() != 3 {
+		t.Errorf("Expected a single retry, got %d tokens", tokens.issued.Load())
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ComponentMatch lists component attribute values. Values of one attribute are
+// alternatives; a component matches when every listed attribute has a matching value.
+type ComponentMatch struct {
+	Type    []string `json:"type,omitempty" yaml:"type,omitempty"`
+	Role    []string `json:"role,omitempty" yaml:"role,omitempty"`
+	SubRole []string `json:"subrole,omitempty" yaml:"subrole,omitempty"`
+	State   []string `json:"state,omitempty" yaml:"state,omitempty"`
+	Arch    []string `json:"arch,omitempty" yaml:"arch,omitempty"`
+	Class   []string `json:"class,omitempty" yaml:"class,omitempty"`
+	Enabled *bool    `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// ComponentFilter selects the HSM components synced as nodes
+type ComponentFilter struct {
+	Include ComponentMatch `json:"include" yaml:"include"`
+	Exclude ComponentMatch `json:"exclude" yaml:"exclude"`
+}
+
+// DefaultComponentFilter returns the filter for compute and application nodes
+func DefaultComponentFilter() ComponentFilter {
+	return ComponentFilter{
+		Include: ComponentMatch{
+			Type: []string{"Node"},
+			Role: []string{"Compute", "Application"},
+		},
+	}
+}
+
+// fields lists the attribute values of a match with their HSM query parameter names
+func (m ComponentMatch) fields() []struct {
+	param  string
+	values []string
+} {
+	return []struct {
+		param  string
+		values []string
+	}{
+		{"type", m.Type},
+		{"role", m.Role},
+		{"subrole", m.SubRole},
+		{"state", m.State},
+		{"arch", m.Arch},
+		{"class", m.Class},
+	}
+}
+
+// Validate checks that no filter value is empty
+func (f ComponentFilter) Validate() error {
+	for _, match := range []ComponentMatch{f.Include, f.Exclude} {
+		for _, field := range match.fields() {
+			if slices.Contains(field.values, "") {
+				return errors.New("empty " + field.param + " in HSM component filter")
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the HSM /State/Components query parameters for the filter.
+// Included values are sent as alternatives and excluded values are negated
+// with "!", which HSM only honors for attributes without included values.
+func (f ComponentFilter) Query() url.Values {
+	query := url.Values{}
+	include, exclude := f.Include.fields(), f.Exclude.fields()
+	for i, field := range include {
+		for _, value := range field.values {
+			query.Add(field.param, value)
+		}
+		if len(field.values) == 0 {
+			for _, value := range exclude[i].values {
+				query.Add(field.param, "!"+value)
+			}
+		}
+	}
+
+	switch {
+	case f.Include.Enabled != nil:
+		query.Set("enabled", strconv.FormatBool(*f.Include.Enabled))
+	case f.Exclude.Enabled != nil:
+		query.Set("enabled", strconv.FormatBool(!*f.Exclude.Enabled))
+	}
+	return query
+}
+
+// Matches reports whether a component passes the filter. Sync applies it to
+// query results too, so HSM versions that ignore a parameter are still filtered.
+func (f ComponentFilter) Matches(comp HSMComponent) bool {
+	values := []string{comp.Type, comp.Role, comp.SubRole, comp.State, comp.Arch, comp.Class}
+	for i, field := range f.Include.fields() {
+		if len(field.values) > 0 && !containsFold(field.values, values[i]) {
+			return false
+		}
+	}
+	for i, field := range f.Exclude.fields() {
+		if containsFold(field.values, values[i]) {
+			return false
+		}
+	}
+
+	// Components whose enabled setting HSM omits are not filtered by it
+	if f.Include.Enabled != nil && comp.Enabled != nil && *comp.Enabled != *f.Include.Enabled {
+		return false
+	}
+	if f.Exclude.Enabled != nil && comp.Enabled != nil && *comp.Enabled == *f.Exclude.Enabled {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether values contains value, ignoring case as HSM does
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
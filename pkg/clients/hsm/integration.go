@@ -7,14 +7,37 @@ package hsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/openchami/boot-service/pkg/client"
 	"github.com/openchami/boot-service/pkg/resources/node"
+	"github.com/openchami/boot-service/pkg/validation"
 )
 
+// Orphan policies decide what a sync does with nodes that are no longer in HSM
+const (
+	OrphanPolicyDelete    = "delete"    // Delete the node
+	OrphanPolicyTombstone = "tombstone" // Keep the node with State=Removed; it gets no boot script, cloud-init or Ignition data
+	OrphanPolicyLabel     = "label"     // Keep the node and label it for an operator to review
+	OrphanPolicyIgnore    = "ignore"    // Leave the node alone
+)
+
+// LabelOrphaned is set to "true" on nodes orphaned under the label policy and
+// back to "false" if they reappear in HSM
+const LabelOrphaned = "hsm-orphaned"
+
+// ErrRemovalThresholdExceeded is returned when a sync would remove more nodes than allowed
+var ErrRemovalThresholdExceeded = errors.New("orphan removal threshold exceeded")
+
 // IntegrationService provides HSM integration for the boot service
 type IntegrationService struct {
 	hsmClient    *HSMClient
@@ -22,6 +45,27 @@ type IntegrationService struct {
 	logger       *log.Logger
 	syncEnabled  bool
 	syncInterval time.Duration
+
+	orphanPolicy      string
+	maxRemovalPercent float64
+	filter            ComponentFilter
+	bootMACRule       string
+	bootMACDesc       string
+	onNodeChange      func(xname string)
+
+	// runMu keeps full syncs and state change notifications from applying changes at once
+	runMu sync.Mutex
+	// nodeUIDs maps the xnames of boot service nodes to their UIDs, so state
+	// change notifications can read just the nodes they list. Guarded by runMu.
+	nodeUIDs          map[string]string
+	scnCallbackURL    string
+	scnQueue          chan StateChangeNotification
+	scnSubscriptionID atomic.Int64
+	scnStats          scnStats
+
+	syncMu          sync.RWMutex
+	lastSyncSuccess time.Time
+	lastSyncError   error
 }
 
 // IntegrationConfig holds configuration for HSM integration
@@ -29,6 +73,26 @@ type IntegrationConfig struct {
 	HSMConfig    HSMConfig     `json:"hsm"`
 	SyncEnabled  bool          `json:"syncEnabled"`
 	SyncInterval time.Duration `json:"syncInterval"`
+
+	// OrphanPolicy is applied to synced nodes that are no longer in HSM:
+	// "delete", "tombstone", "label" or "ignore"
+	OrphanPolicy string `json:"orphanPolicy"`
+	// MaxRemovalPercent aborts orphan handling when more than this percentage
+	// of the synced nodes would be removed, e.g., when HSM returns a partial inventory
+	MaxRemovalPercent float64 `json:"maxRemovalPercent"`
+	// Filter selects the HSM components synced as nodes. Nodes that stop
+	// matching it are handled as orphans.
+	Filter ComponentFilter `json:"filter"`
+	// BootMACRule chooses a node's boot MAC among its HSM ethernet
+	// interfaces: "first", "lowest" or "description"
+	BootMACRule string `json:"bootMACRule"`
+	// BootMACDescription is matched, without regard to case, against
+	// interface descriptions by the "description" rule
+	BootMACDescription string `json:"bootMACDescription,omitempty"`
+	// SCNCallbackURL is the URL of this service's SCNPath that HSM posts
+	// state change notifications to. When set, the sync worker subscribes on
+	// startup and applies notifications between full syncs.
+	SCNCallbackURL string `json:"scnCallbackURL,omitempty"`
 }
 
 // DefaultIntegrationConfig returns default integration configuration
@@ -37,6 +101,11 @@ func DefaultIntegrationConfig() IntegrationConfig {
 		HSMConfig:    DefaultHSMConfig(),
 		SyncEnabled:  true,
 		SyncInterval: 5 * time.Minute,
+
+		OrphanPolicy:      OrphanPolicyTombstone,
+		MaxRemovalPercent: 10,
+		Filter:            DefaultComponentFilter(),
+		BootMACRule:       BootMACFirst,
 	}
 }
 
@@ -54,41 +123,45 @@ func NewIntegrationService(config IntegrationConfig, bootClient client.Client, l
 		logger:       logger,
 		syncEnabled:  config.SyncEnabled,
 		syncInterval: config.SyncInterval,
+
+		orphanPolicy:      config.OrphanPolicy,
+		maxRemovalPercent: config.MaxRemovalPercent,
+		filter:            config.Filter,
+		bootMACRule:       config.BootMACRule,
+		bootMACDesc:       config.BootMACDescription,
+		scnCallbackURL:    config.SCNCallbackURL,
+		scnQueue:          make(chan StateChangeNotification, scnQueueSize),
 	}
 }
 
 // SyncNodesFromHSM synchronizes node data from HSM to the boot service
-func (s *IntegrationService) SyncNodesFromHSM(ctx context.Context) error {
+func (s *IntegrationService) SyncNodesFromHSM(ctx context.Context) (err error) {
 	s.logger.Printf("Starting HSM node synchronization")
+	defer func() { s.recordSync(err) }()
 
-	// Get components from HSM
-	components, err := s.hsmClient.GetComponents(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get components from HSM: %w", err)
-	}
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 
-	// Filter for compute nodes
-	var computeNodes []HSMComponent
-	for _, comp := range components {
-		if comp.Type == "Node" && (comp.Role == "Compute" || comp.Role == "Application") {
-			computeNodes = append(computeNodes, comp)
-		}
+	// A sync from stale data would hide an HSM outage from readiness
+	ctx = withFreshData(ctx)
+
+	computeNodes, err := s.filteredComponents(ctx)
+	if err != nil {
+		return err
 	}
 
-	s.logger.Printf("Found %d compute nodes in HSM", len(computeNodes))
+	s.logger.Printf("Found %d nodes matching the sync filter in HSM", len(computeNodes))
 
-	// Get ethernet interfaces for MAC address mapping
-	interfaces, err := s.hsmClient.GetEthernetInterfaces(ctx)
+	// Get ethernet interfaces of each component
+	interfaces, err := s.hsmClient.interfaceList(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get ethernet interfaces from HSM: %w", err)
 	}
 
-	// Create MAC address lookup map
-	macMap := make(map[string]string) // componentID -> MAC
-	for _, iface := range interfaces {
-		if iface.Type == "Node" {
-			macMap[iface.ComponentID] = iface.MACAddress
-		}
+	// Get group and partition membership
+	groupMap, err := s.getMemberships(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Get existing nodes from boot service
@@ -96,90 +169,357 @@ func (s *IntegrationService) SyncNodesFromHSM(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get existing nodes: %w", err)
 	}
+	s.indexNodes(existingNodes)
+
+	// Sync each compute node, recording the outcome in its status
+	counts := s.syncComponents(ctx, computeNodes, interfaces, groupMap, existingNodes)
 
-	// Create lookup map for existing nodes
-	existingMap := make(map[string]*node.Node)
+	inHSM := make(map[string]bool, len(computeNodes))
+	for _, comp := range computeNodes {
+		inHSM[comp.ID] = true
+	}
+	removed, err := s.reconcileOrphans(ctx, existingNodes, inHSM)
+	s.logger.Printf("HSM sync complete: %d created, %d updated, %d skipped, %d failed, %d orphaned (%s)",
+		counts.created, counts.updated, counts.skipped, counts.failed, removed, s.orphanPolicy)
+	return err
+}
+
+// syncCounts counts the outcomes of syncing components
+type syncCounts struct {
+	created, updated, skipped, failed int
+}
+
+// syncComponents syncs HSM components into nodes, recording the outcome in each node's status
+func (s *IntegrationService) syncComponents(ctx context.Context, components []HSMComponent, interfaces *interfaceList, groupMap map[string][]string, existingNodes []node.Node) syncCounts {
+	existingMap := make(map[string]*node.Node, len(existingNodes))
 	for i := range existingNodes {
 		existingMap[existingNodes[i].Spec.XName] = &existingNodes[i]
 	}
 
-	// Sync each compute node
-	var created, updated, skipped int
-	for _, comp := range computeNodes {
-		err := s.syncNode(ctx, comp, macMap, existingMap)
+	syncedAt := time.Now().UTC().Format(time.RFC3339)
+	var counts syncCounts
+	for _, comp := range components {
+		existing, exists := existingMap[comp.ID]
+		changed := !exists || s.needsUpdate(comp, interfaces, groupMap, existing)
+
+		synced, err := s.syncNode(ctx, comp, interfaces, groupMap, existingMap)
 		if err != nil {
 			s.logger.Printf("Warning: Failed to sync node %s: %v", comp.ID, err)
+			counts.failed++
+		} else {
+			switch {
+			case !exists:
+				counts.created++
+			case changed:
+				counts.updated++
+			default:
+				counts.skipped++
+			}
+		}
+		if synced != nil {
+			s.nodeUIDs[synced.Spec.XName] = synced.Metadata.UID
+			s.recordNodeSync(ctx, synced, comp, syncedAt, err)
+		}
+	}
+	return counts
+}
+
+// indexNodes records the UIDs of the boot service nodes
+func (s *IntegrationService) indexNodes(nodes []node.Node) {
+	s.nodeUIDs = make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		s.nodeUIDs[n.Spec.XName] = n.Metadata.UID
+	}
+}
+
+// affectedNodes returns the boot service nodes of components. Known nodes are
+// read one by one; all nodes are listed when a component has no known node,
+// e.g., new hardware, or a known node can't be read.
+func (s *IntegrationService) affectedNodes(ctx context.Context, ids []string) ([]node.Node, error) {
+	if nodes, ok := s.knownNodes(ctx, ids); ok {
+		return nodes, nil
+	}
+
+	existingNodes, err := s.bootClient.GetNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing nodes: %w", err)
+	}
+	s.indexNodes(existingNodes)
+	return existingNodes, nil
+}
+
+// knownNodes reads the indexed nodes of components, reporting false unless
+// every one of them could be read
+func (s *IntegrationService) knownNodes(ctx context.Context, ids []string) ([]node.Node, bool) {
+	nodes := make([]node.Node, 0, len(ids))
+	for _, id := range ids {
+		uid, known := s.nodeUIDs[id]
+		if !known {
+			return nil, false
+		}
+		n, err := s.bootClient.GetNode(ctx, uid)
+		if err != nil || n.Spec.XName != id {
+			return nil, false
+		}
+		nodes = append(nodes, *n)
+	}
+	return nodes, true
+}
+
+// recordNodeSync records the time and outcome of a node's sync and its HSM
+// component state in its status. Tombstoned nodes that reappear in HSM become
+// ready again.
+func (s *IntegrationService) recordNodeSync(ctx context.Context, n *node.Node, comp HSMComponent, syncedAt string, syncErr error) {
+	status := n.Status
+	status.LastHSMSync = syncedAt
+	status.Error = ""
+	if syncErr != nil {
+		status.Error = syncErr.Error()
+	}
+	if status.State == node.StateRemoved {
+		status.State = node.StateReady
+		s.logger.Printf("Node %s reappeared in HSM", n.Spec.XName)
+	}
+	setComponentState(&status, comp)
+
+	// Boot decisions depend on the component state, so scripts cached for the
+	// node's previous state are dropped
+	if n.Status.LastHSMSync != "" && componentStateChanged(n.Status, status) {
+		s.logger.Printf("Node %s HSM state changed from %s/%s/enabled=%s to %s/%s/enabled=%s", n.Spec.XName,
+			n.Status.HSMState, n.Status.HSMFlag, formatEnabled(n.Status.HSMEnabled),
+			status.HSMState, status.HSMFlag, formatEnabled(status.HSMEnabled))
+		if s.onNodeChange != nil {
+			s.onNodeChange(n.Spec.XName)
+		}
+	}
+
+	if _, err := s.bootClient.UpdateNodeStatus(ctx, n.Metadata.UID, status); err != nil {
+		s.logger.Printf("Warning: Failed to record sync status of node %s: %v", n.Spec.XName, err)
+	}
+}
+
+// setComponentState copies the state, flag and enabled setting of an HSM
+// component into a node status. An enabled setting HSM omits stays unknown.
+func setComponentState(status *node.NodeStatus, comp HSMComponent) {
+	status.HSMState = comp.State
+	status.HSMFlag = comp.Flag
+	status.HSMEnabled = nil
+	if comp.Enabled != nil {
+		enabled := *comp.Enabled
+		status.HSMEnabled = &enabled
+	}
+}
+
+// componentStateChanged reports whether the HSM component state of a node status changed
+func componentStateChanged(before, after node.NodeStatus) bool {
+	return before.HSMState != after.HSMState || before.HSMFlag != after.HSMFlag ||
+		formatEnabled(before.HSMEnabled) != formatEnabled(after.HSMEnabled)
+}
+
+// formatEnabled formats an optional enabled setting for logs
+func formatEnabled(enabled *bool) string {
+	if enabled == nil {
+		return "unknown"
+	}
+	return fmt.Sprint(*enabled)
+}
+
+// reconcileOrphans applies the orphan policy to previously synced nodes that
+// are no longer in HSM and returns how many were handled. Only nodes with a
+// recorded HSM sync are considered, so discovered and manually created nodes
+// are never removed.
+func (s *IntegrationService) reconcileOrphans(ctx context.Context, existing []node.Node, inHSM map[string]bool) (int, error) {
+	if s.orphanPolicy == OrphanPolicyIgnore || s.orphanPolicy == "" {
+		return 0, nil
+	}
+
+	var synced int
+	var orphans []*node.Node
+	for i := range existing {
+		n := &existing[i]
+		if n.Status.LastHSMSync == "" {
 			continue
 		}
+		synced++
+		if inHSM[n.Spec.XName] || isOrphaned(n) {
+			continue
+		}
+		orphans = append(orphans, n)
+	}
+	if len(orphans) == 0 {
+		return 0, nil
+	}
 
-		// Track what we did
-		if existing, exists := existingMap[comp.ID]; exists {
-			if s.needsUpdate(comp, macMap, existing) {
-				updated++
-			} else {
-				skipped++
-			}
-		} else {
-			created++
+	if percent := 100 * float64(len(orphans)) / float64(synced); percent > s.maxRemovalPercent {
+		return 0, fmt.Errorf("%w: %d of %d synced nodes (%.1f%%) are missing from HSM, limit is %.1f%%",
+			ErrRemovalThresholdExceeded, len(orphans), synced, percent, s.maxRemovalPercent)
+	}
+
+	handled := 0
+	for _, n := range orphans {
+		if err := s.handleOrphan(ctx, n); err != nil {
+			s.logger.Printf("Warning: Failed to handle orphaned node %s: %v", n.Spec.XName, err)
+			continue
+		}
+		s.logger.Printf("Node %s is no longer in HSM (%s)", n.Spec.XName, s.orphanPolicy)
+		handled++
+	}
+	return handled, nil
+}
+
+// isOrphaned reports whether an orphan policy was already applied to a node
+func isOrphaned(n *node.Node) bool {
+	label, _ := n.GetLabel(LabelOrphaned)
+	return n.IsRemoved() || label == "true"
+}
+
+// handleOrphan applies the orphan policy to one node
+func (s *IntegrationService) handleOrphan(ctx context.Context, n *node.Node) error {
+	switch s.orphanPolicy {
+	case OrphanPolicyDelete:
+		return s.bootClient.DeleteNode(ctx, n.Metadata.UID)
+
+	case OrphanPolicyTombstone:
+		status := n.Status
+		status.State = node.StateRemoved
+		_, err := s.bootClient.UpdateNodeStatus(ctx, n.Metadata.UID, status)
+		return err
+
+	case OrphanPolicyLabel:
+		_, err := s.bootClient.UpdateNode(ctx, n.Metadata.UID, client.UpdateNodeRequest{
+			NodeSpec: n.Spec,
+			Labels:   map[string]string{LabelOrphaned: "true"},
+		})
+		return err
+
+	default:
+		return fmt.Errorf("unknown orphan policy: %s", s.orphanPolicy)
+	}
+}
+
+// recordSync records the outcome of a sync attempt
+func (s *IntegrationService) recordSync(err error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	s.lastSyncError = err
+	if err == nil {
+		s.lastSyncSuccess = time.Now()
+	}
+}
+
+// LastSync returns the time of the last successful sync (zero if none has
+// succeeded) and the error of the most recent attempt
+func (s *IntegrationService) LastSync() (time.Time, error) {
+	s.syncMu.RLock()
+	defer s.syncMu.RUnlock()
+	return s.lastSyncSuccess, s.lastSyncError
+}
+
+// getMemberships returns the sorted HSM group labels and partition names of
+// each component. Partitions are targeted like groups, as in BSS.
+func (s *IntegrationService) getMemberships(ctx context.Context) (map[string][]string, error) {
+	groups, err := s.hsmClient.GetGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups from HSM: %w", err)
+	}
+	partitions, err := s.hsmClient.GetPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions from HSM: %w", err)
+	}
+
+	memberships := make(map[string][]string) // componentID -> groups
+	for _, group := range groups {
+		for _, id := range group.Members.IDs {
+			memberships[id] = append(memberships[id], group.Label)
 		}
 	}
+	for _, partition := range partitions {
+		for _, id := range partition.Members.IDs {
+			memberships[id] = append(memberships[id], partition.Name)
+		}
+	}
+	for id, names := range memberships {
+		sort.Strings(names)
+		memberships[id] = slices.Compact(names)
+	}
+	return memberships, nil
+}
 
-	s.logger.Printf("HSM sync complete: %d created, %d updated, %d skipped", created, updated, skipped)
-	return nil
+// OnNodeChange registers a function called with the xname of each node whose
+// HSM data, such as group membership, changed during a sync
+func (s *IntegrationService) OnNodeChange(fn func(xname string)) {
+	s.onNodeChange = fn
 }
 
-// syncNode synchronizes a single node from HSM
-func (s *IntegrationService) syncNode(ctx context.Context, comp HSMComponent, macMap map[string]string, existingMap map[string]*node.Node) error {
+// syncNode synchronizes a single node from HSM and returns the synced node
+func (s *IntegrationService) syncNode(ctx context.Context, comp HSMComponent, interfaces *interfaceList, groupMap map[string][]string, existingMap map[string]*node.Node) (*node.Node, error) {
 	// Check if node already exists
 	existing, exists := existingMap[comp.ID]
 
-	// Get MAC address for this component
-	bootMAC := macMap[comp.ID]
+	// Get the interfaces and boot MAC for this component
+	nodeIfaces, bootMAC := s.componentInterfaces(interfaces.byComponent[comp.ID])
 
 	// Create node spec from HSM data
 	nodeSpec := node.NodeSpec{
-		XName:   comp.ID,
-		NID:     comp.NID,
-		BootMAC: bootMAC,
-		Role:    comp.Role,
-		SubRole: comp.SubRole,
-		Groups:  []string{}, // Will be populated from inventory service later
+		XName:      comp.ID,
+		NID:        comp.NID,
+		BootMAC:    bootMAC,
+		Interfaces: nodeIfaces,
+		Role:       comp.Role,
+		SubRole:    comp.SubRole,
+		Groups:     groupMap[comp.ID],
+	}
+	if nodeSpec.Groups == nil {
+		nodeSpec.Groups = []string{}
 	}
 
 	if exists {
+		// Nodes labeled as orphans are relabeled when they reappear
+		orphanLabel, _ := existing.GetLabel(LabelOrphaned)
+
 		// Update existing node if needed
-		if s.needsUpdate(comp, macMap, existing) {
+		if s.needsUpdate(comp, interfaces, groupMap, existing) || orphanLabel == "true" {
 			updateReq := client.UpdateNodeRequest{
 				NodeSpec: nodeSpec,
 			}
+			if orphanLabel == "true" {
+				updateReq.Labels = map[string]string{LabelOrphaned: "false"}
+			}
 
-			_, err := s.bootClient.UpdateNode(ctx, existing.Metadata.UID, updateReq)
+			updatedNode, err := s.bootClient.UpdateNode(ctx, existing.Metadata.UID, updateReq)
 			if err != nil {
-				return fmt.Errorf("failed to update node %s: %w", comp.ID, err)
+				return existing, fmt.Errorf("failed to update node %s: %w", comp.ID, err)
 			}
 
+			if !slices.Equal(nodeSpec.Groups, existing.Spec.Groups) {
+				s.logger.Printf("Node %s groups changed from %v to %v", comp.ID, existing.Spec.Groups, nodeSpec.Groups)
+			}
 			s.logger.Printf("Updated node %s from HSM", comp.ID)
+			if s.onNodeChange != nil {
+				s.onNodeChange(comp.ID)
+			}
+			return updatedNode, nil
 		}
-	} else {
-		// Create new node
-		createReq := client.CreateNodeRequest{
-			Name:     comp.ID,
-			NodeSpec: nodeSpec,
-		}
+		return existing, nil
+	}
 
-		_, err := s.bootClient.CreateNode(ctx, createReq)
-		if err != nil {
-			return fmt.Errorf("failed to create node %s: %w", comp.ID, err)
-		}
+	// Create new node
+	createReq := client.CreateNodeRequest{
+		Name:     comp.ID,
+		NodeSpec: nodeSpec,
+	}
 
-		s.logger.Printf("Created node %s from HSM", comp.ID)
+	createdNode, err := s.bootClient.CreateNode(ctx, createReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node %s: %w", comp.ID, err)
 	}
 
-	return nil
+	s.logger.Printf("Created node %s from HSM", comp.ID)
+	return createdNode, nil
 }
 
 // needsUpdate checks if a node needs to be updated based on HSM data
-func (s *IntegrationService) needsUpdate(comp HSMComponent, macMap map[string]string, existing *node.Node) bool {
+func (s *IntegrationService) needsUpdate(comp HSMComponent, interfaces *interfaceList, groupMap map[string][]string, existing *node.Node) bool {
 	// Check if NID changed
 	if comp.NID != existing.Spec.NID {
 		return true
@@ -195,11 +535,26 @@ func (s *IntegrationService) needsUpdate(comp HSMComponent, macMap map[string]st
 		return true
 	}
 
-	// Check if MAC address changed
-	bootMAC := macMap[comp.ID]
+	// Check if group or partition membership changed
+	if !slices.Equal(groupMap[comp.ID], existing.Spec.Groups) {
+		return true
+	}
+
+	// Check if the interfaces or boot MAC address changed
+	nodeIfaces, bootMAC := s.componentInterfaces(interfaces.byComponent[comp.ID])
+	if !slices.Equal(nodeIfaces, existing.Spec.Interfaces) {
+		return true
+	}
 	return bootMAC != existing.Spec.BootMAC
 }
 
+// componentInterfaces maps a component's HSM ethernet interfaces to node
+// interfaces and chooses its boot MAC by the configured rule
+func (s *IntegrationService) componentInterfaces(interfaces []HSMEthernetInterface) ([]node.Interface, string) {
+	nodeIfaces := nodeInterfaces(interfaces)
+	return nodeIfaces, chooseBootMAC(nodeIfaces, s.bootMACRule, s.bootMACDesc)
+}
+
 // ResolveNodeByIdentifier resolves a node using HSM as fallback
 func (s *IntegrationService) ResolveNodeByIdentifier(ctx context.Context, identifier string) (*node.Node, error) {
 	// First try to find in our local database
@@ -208,7 +563,7 @@ func (s *IntegrationService) ResolveNodeByIdentifier(ctx context.Context, identi
 		return nil, fmt.Errorf("failed to get nodes from boot service: %w", err)
 	}
 
-	// Try to match by XName, NID, or MAC
+	// Try to match by XName, NID, MAC (boot or any interface), interface IP, or hostname
 	for i := range nodes {
 		n := &nodes[i]
 		if n.Spec.XName == identifier {
@@ -217,7 +572,19 @@ func (s *IntegrationService) ResolveNodeByIdentifier(ctx context.Context, identi
 		if fmt.Sprintf("%d", n.Spec.NID) == identifier {
 			return n, nil
 		}
-		if n.Spec.BootMAC == identifier {
+		if validation.EqualMAC(n.Spec.BootMAC, identifier) {
+			return n, nil
+		}
+		for _, iface := range n.Spec.Interfaces {
+			if validation.EqualMAC(iface.MAC, identifier) {
+				return n, nil
+			}
+			if validation.EqualIP(iface.IP, identifier) {
+				s.logger.Printf("Matched IP %s to node %s interface", identifier, n.Spec.XName)
+				return n, nil
+			}
+		}
+		if n.Spec.Hostname != "" && strings.EqualFold(n.Spec.Hostname, identifier) {
 			return n, nil
 		}
 	}
@@ -237,35 +604,97 @@ func (s *IntegrationService) ResolveNodeByIdentifier(ctx context.Context, identi
 		return s.convertHSMComponentToNode(ctx, comp)
 	}
 
+	// Try to find by ethernet interface IP address
+	if net.ParseIP(identifier) != nil {
+		comp, err = s.hsmClient.GetComponentByIP(ctx, identifier)
+		if err == nil {
+			s.logger.Printf("Matched IP %s to HSM component %s ethernet interface", identifier, comp.ID)
+			return s.convertHSMComponentToNode(ctx, comp)
+		}
+	}
+
 	return nil, fmt.Errorf("node %s not found in boot service or HSM", identifier)
 }
 
+// filteredComponents retrieves the components selected by the sync filter from HSM
+func (s *IntegrationService) filteredComponents(ctx context.Context) ([]HSMComponent, error) {
+	components, err := s.hsmClient.GetComponentsWithQuery(ctx, s.filter.Query())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get components from HSM: %w", err)
+	}
+
+	// HSM ignores negations on attributes that also have included values,
+	// so apply the whole filter again
+	var matching []HSMComponent
+	for _, comp := range components {
+		if s.filter.Matches(comp) {
+			matching = append(matching, comp)
+		}
+	}
+	return matching, nil
+}
+
+// ListNodes lists the HSM components the sync filter selects with their interfaces (not persisted)
+func (s *IntegrationService) ListNodes(ctx context.Context) ([]node.Node, error) {
+	components, err := s.filteredComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces, err := s.hsmClient.interfaceList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ethernet interfaces from HSM: %w", err)
+	}
+
+	var nodes []node.Node
+	for _, comp := range components {
+		nodeIfaces, bootMAC := s.componentInterfaces(interfaces.byComponent[comp.ID])
+		var status node.NodeStatus
+		setComponentState(&status, comp)
+		nodes = append(nodes, node.Node{
+			Spec: node.NodeSpec{
+				XName:      comp.ID,
+				NID:        comp.NID,
+				BootMAC:    bootMAC,
+				Interfaces: nodeIfaces,
+				Role:       comp.Role,
+				SubRole:    comp.SubRole,
+			},
+			Status: status,
+		})
+	}
+	return nodes, nil
+}
+
 // convertHSMComponentToNode converts an HSM component to a Node (for fallback scenarios)
 func (s *IntegrationService) convertHSMComponentToNode(ctx context.Context, comp *HSMComponent) (*node.Node, error) {
-	// Get MAC address
-	interfaces, err := s.hsmClient.GetEthernetInterfaces(ctx)
+	// Get the interfaces and boot MAC address
+	interfaces, err := s.hsmClient.GetComponentInterfaces(ctx, comp.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ethernet interfaces: %w", err)
 	}
+	nodeIfaces, bootMAC := s.componentInterfaces(interfaces)
 
-	var bootMAC string
-	for _, iface := range interfaces {
-		if iface.ComponentID == comp.ID {
-			bootMAC = iface.MACAddress
-			break
-		}
+	// Group membership lets group-targeted configurations match before the node is synced
+	memberships, err := s.getMemberships(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a temporary node representation (not persisted)
-	return &node.Node{
+	n := &node.Node{
 		Spec: node.NodeSpec{
-			XName:   comp.ID,
-			NID:     comp.NID,
-			BootMAC: bootMAC,
-			Role:    comp.Role,
-			SubRole: comp.SubRole,
+			XName:      comp.ID,
+			NID:        comp.NID,
+			BootMAC:    bootMAC,
+			Interfaces: nodeIfaces,
+			Role:       comp.Role,
+			SubRole:    comp.SubRole,
+			Groups:     memberships[comp.ID],
 		},
-	}, nil
+	}
+	setComponentState(&n.Status, *comp)
+	return n, nil
 }
 
 // HealthCheck performs health checks on HSM connectivity
@@ -291,6 +720,10 @@ func (s *IntegrationService) StartSyncWorker(ctx context.Context) {
 	ticker := time.NewTicker(s.syncInterval)
 	defer ticker.Stop()
 
+	// Subscribe before the initial sync so no change falls between them
+	s.subscribe(ctx)
+	defer s.unsubscribe()
+
 	// Do initial sync (HSM is now ready)
 	if err := s.SyncNodesFromHSM(ctx); err != nil {
 		s.logger.Printf("Initial HSM sync failed: %v", err)
@@ -302,7 +735,14 @@ func (s *IntegrationService) StartSyncWorker(ctx context.Context) {
 			s.logger.Printf("HSM sync worker stopped")
 			return
 
+		case scn := <-s.scnQueue:
+			if err := s.HandleSCN(ctx, scn); err != nil {
+				s.logger.Printf("Failed to apply HSM state change, waiting for the next full sync: %v", err)
+			}
+
 		case <-ticker.C:
+			// Full syncs catch missed notifications and removals, and retry a failed subscription
+			s.subscribe(ctx)
 			if err := s.SyncNodesFromHSM(ctx); err != nil {
 				s.logger.Printf("HSM sync failed: %v", err)
 			}
@@ -375,7 +815,27 @@ func (s *IntegrationService) GetStats(ctx context.Context) map[string]interface{
 		"hsm_client_stats":        hsmStats,
 		"sync_enabled":            s.syncEnabled,
 		"sync_interval":           s.syncInterval.String(),
+		"sync_filter":             s.filter.Query().Encode(),
+		"boot_mac_rule":           s.bootMACRule,
+		"scn":                     s.scnStatsMap(),
+	}
+
+	lastSuccess, lastErr := s.LastSync()
+	if !lastSuccess.IsZero() {
+		stats["last_sync_success"] = lastSuccess.Format(time.RFC3339)
+	}
+	if lastErr != nil {
+		stats["last_sync_error"] = lastErr.Error()
 	}
 
 	return stats
 }
+
+// normalizeMAC returns a MAC address in lowercase colon-separated form,
+// or unchanged if it cannot be parsed
+func normalizeMAC(mac string) string {
+	if normalized, err := validation.NormalizeMAC(mac); err == nil {
+		return normalized
+	}
+	return mac
+}
@@ -0,0 +1,891 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// fakeHSM serves a mutable HSM inventory
+type fakeHSM struct {
+	mu         sync.Mutex
+	components []HSMComponent
+	interfaces []HSMEthernetInterface
+	groups     []HSMGroup
+	partitions []HSMPartition
+	queries    []url.Values
+	reads      map[string]int // GET requests by path
+
+	subscriptions map[int64]SCNSubscription
+	nextID        int64
+}
+
+func (f *fakeHSM) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				f.mu.Lock()
+				if f.reads == nil {
+					f.reads = make(map[string]int)
+				}
+				f.reads[r.URL.Path]++
+				f.mu.Unlock()
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/hsm/v2/service/ready", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Get("/hsm/v2/State/Components", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		query := r.URL.Query()
+		f.queries = append(f.queries, query)
+		var components []HSMComponent
+		for _, comp := range f.components {
+			if matchesQuery(comp, query) {
+				components = append(components, comp)
+			}
+		}
+		json.NewEncoder(w).Encode(HSMResponse{Components: components}) //nolint:errcheck
+	})
+	r.Get("/hsm/v2/Inventory/EthernetInterfaces", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		interfaces := []HSMEthernetInterface{}
+		for _, iface := range f.interfaces {
+			if ids := r.URL.Query()["ComponentID"]; len(ids) == 0 || slices.Contains(ids, iface.ComponentID) {
+				interfaces = append(interfaces, iface)
+			}
+		}
+		json.NewEncoder(w).Encode(interfaces) //nolint:errcheck
+	})
+	r.Get("/hsm/v2/groups", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(append([]HSMGroup{}, f.groups...)) //nolint:errcheck
+	})
+	r.Get("/hsm/v2/partitions", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(append([]HSMPartition{}, f.partitions...)) //nolint:errcheck
+	})
+	r.Post("/hsm/v2/Subscriptions/SCN", func(w http.ResponseWriter, r *http.Request) {
+		var subscription SCNSubscription
+		if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil || subscription.URL == "" {
+			http.Error(w, "invalid subscription", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.subscriptions == nil {
+			f.subscriptions = make(map[int64]SCNSubscription)
+		}
+		f.nextID++
+		subscription.ID = f.nextID
+		f.subscriptions[subscription.ID] = subscription
+		json.NewEncoder(w).Encode(subscription) //nolint:errcheck
+	})
+	r.Delete("/hsm/v2/Subscriptions/SCN/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if _, found := f.subscriptions[id]; !found {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.subscriptions, id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return r
+}
+
+// notify posts a state change notification to every subscriber like HSM
+func (f *fakeHSM) notify(t *testing.T, scn StateChangeNotification) {
+	t.Helper()
+	f.mu.Lock()
+	var urls []string
+	for _, subscription := range f.subscriptions {
+		urls = append(urls, subscription.URL)
+	}
+	f.mu.Unlock()
+
+	body, _ := json.Marshal(scn)
+	for _, u := range urls {
+		resp, err := http.Post(u, "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatalf("Failed to notify %s: %v", u, err)
+		}
+		resp.Body.Close() //nolint:errcheck
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Notification to %s returned %d", u, resp.StatusCode)
+		}
+	}
+}
+
+// waitFor polls a condition until it holds or the test times out
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// setNodes replaces the inventory with compute nodes numbered from 0
+func (f *fakeHSM) setNodes(ids ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.components, f.interfaces = nil, nil
+	for _, id := range ids {
+		xname := fmt.Sprintf("x1000c0s0b0n%d", id)
+		enabled := true
+		f.components = append(f.components, HSMComponent{
+			ID: xname, Type: "Node", State: "Ready", Enabled: &enabled, Role: "Compute", NID: int32(id + 1),
+		})
+		f.interfaces = append(f.interfaces, HSMEthernetInterface{
+			ComponentID: xname, Type: "Node", MACAddress: fmt.Sprintf("aa:bb:cc:dd:ee:%02x", id),
+		})
+	}
+}
+
+// matchesQuery filters like HSM: values of a parameter are alternatives and
+// negated values are only honored when the parameter has no other values
+func matchesQuery(comp HSMComponent, query url.Values) bool {
+	attributes := map[string]string{
+		"type": comp.Type, "role": comp.Role, "subrole": comp.SubRole,
+		"state": comp.State, "arch": comp.Arch, "class": comp.Class,
+		"enabled": formatEnabled(comp.Enabled), "id": comp.ID,
+	}
+	for param, values := range query {
+		value := attributes[param]
+		if strings.HasPrefix(values[0], "!") {
+			if slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v[1:], value) }) {
+				return false
+			}
+		} else if !slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// fakeNodeStore serves the node API from memory
+type fakeNodeStore struct {
+	mu    sync.Mutex
+	next  int
+	nodes map[string]*node.Node
+	lists int // GET /nodes requests
+}
+
+func newFakeNodeStore() *fakeNodeStore {
+	return &fakeNodeStore{nodes: make(map[string]*node.Node)}
+}
+
+func (s *fakeNodeStore) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/nodes", func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		s.mu.Lock()
+		s.lists++
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(s.list()) //nolint:errcheck
+	})
+	r.Get("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		n, found := s.nodes[chi.URLParam(r, "uid")]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Post("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateNodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.next++
+		n := &node.Node{Spec: req.NodeSpec}
+		n.Metadata.UID = fmt.Sprintf("nod-%04d", s.next)
+		n.Metadata.Name = req.Name
+		for k, v := range req.Labels {
+			n.SetLabel(k, v)
+		}
+		s.nodes[n.Metadata.UID] = n
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Put("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		var req client.UpdateNodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.update(w, r, func(n *node.Node) {
+			n.Spec = req.NodeSpec
+			for k, v := range req.Labels {
+				n.SetLabel(k, v)
+			}
+		})
+	})
+	r.Put("/nodes/{uid}/status", func(w http.ResponseWriter, r *http.Request) {
+		var status node.NodeStatus
+		if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.update(w, r, func(n *node.Node) { n.Status = status })
+	})
+	r.Delete("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		uid := chi.URLParam(r, "uid")
+		if _, found := s.nodes[uid]; !found {
+			http.NotFound(w, r)
+			return
+		}
+		delete(s.nodes, uid)
+		json.NewEncoder(w).Encode(client.DeleteResponse{UID: uid}) //nolint:errcheck
+	})
+	return r
+}
+
+func (s *fakeNodeStore) update(w http.ResponseWriter, r *http.Request, apply func(n *node.Node)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, found := s.nodes[chi.URLParam(r, "uid")]
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	apply(n)
+	json.NewEncoder(w).Encode(n) //nolint:errcheck
+}
+
+// list returns the stored nodes ordered by xname
+func (s *fakeNodeStore) list() []node.Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodes := make([]node.Node, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, *n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Spec.XName < nodes[j].Spec.XName })
+	return nodes
+}
+
+// byXName returns the stored node with an xname, or nil
+func (s *fakeNodeStore) byXName(xname string) *node.Node {
+	for _, n := range s.list() {
+		if n.Spec.XName == xname {
+			return &n
+		}
+	}
+	return nil
+}
+
+// newTestIntegration creates an integration service backed by a fake HSM and node store
+func newTestIntegration(t *testing.T, config IntegrationConfig) (*IntegrationService, *fakeHSM, *fakeNodeStore) {
+	t.Helper()
+	inventory := &fakeHSM{}
+	hsmServer := httptest.NewServer(inventory.routes())
+	t.Cleanup(hsmServer.Close)
+	store := newFakeNodeStore()
+	bootServer := httptest.NewServer(store.routes())
+	t.Cleanup(bootServer.Close)
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	config.HSMConfig.BaseURL = hsmServer.URL
+	config.HSMConfig.CacheExpiry = 0 // Every sync reads the current inventory
+	service := NewIntegrationService(config, *bootClient, log.New(io.Discard, "", 0))
+	return service, inventory, store
+}
+
+func TestSyncRecordsNodeStatus(t *testing.T) {
+	service, inventory, store := newTestIntegration(t, DefaultIntegrationConfig())
+	inventory.setNodes(0, 1)
+
+	if err := service.SyncNodesFromHSM(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for _, n := range store.list() {
+		if n.Status.LastHSMSync == "" || n.Status.Error != "" {
+			t.Errorf("Expected node %s to record a successful sync, got %+v", n.Spec.XName, n.Status)
+		}
+	}
+	if lastSuccess, err := service.LastSync(); lastSuccess.IsZero() || err != nil {
+		t.Errorf("Expected the sync to be recorded, got %v, %v", lastSuccess, err)
+	}
+}
+
+func TestSyncOrphanPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		check  func(n *node.Node) bool
+	}{
+		{OrphanPolicyDelete, func(n *node.Node) bool { return n == nil }},
+		{OrphanPolicyTombstone, func(n *node.Node) bool { return n != nil && n.Status.State == node.StateRemoved }},
+		{OrphanPolicyLabel, func(n *node.Node) bool {
+			if n == nil {
+				return false
+			}
+			label, _ := n.GetLabel(LabelOrphaned)
+			return label == "true" && !n.IsRemoved()
+		}},
+		{OrphanPolicyIgnore, func(n *node.Node) bool { return n != nil && n.Status.State == "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			config := DefaultIntegrationConfig()
+			config.OrphanPolicy = tt.policy
+			config.MaxRemovalPercent = 50
+			service, inventory, store := newTestIntegration(t, config)
+			ctx := context.Background()
+
+			inventory.setNodes(0, 1, 2, 3)
+			if err := service.SyncNodesFromHSM(ctx); err != nil {
+				t.Fatalf("Initial sync failed: %v", err)
+			}
+
+			// Node 3 is decommissioned in HSM
+			inventory.setNodes(0, 1, 2)
+			if err := service.SyncNodesFromHSM(ctx); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+			if orphan := store.byXName("x1000c0s0b0n3"); !tt.check(orphan) {
+				t.Errorf("Orphaned node not handled by %s policy: %+v", tt.policy, orphan)
+			}
+			if kept := store.byXName("x1000c0s0b0n0"); kept == nil || kept.IsRemoved() {
+				t.Errorf("Expected nodes still in HSM to be kept, got %+v", kept)
+			}
+
+			// A node that comes back is restored
+			inventory.setNodes(0, 1, 2, 3)
+			if err := service.SyncNodesFromHSM(ctx); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+			restored := store.byXName("x1000c0s0b0n3")
+			if restored == nil {
+				t.Fatalf("Expected a returning node to be recreated")
+			}
+			if label, _ := restored.GetLabel(LabelOrphaned); restored.IsRemoved() || label == "true" {
+				t.Errorf("Expected a returning node to be restored, got %+v", restored)
+			}
+		})
+	}
+}
+
+func TestSyncRemovalThreshold(t *testing.T) {
+	config := DefaultIntegrationConfig()
+	config.OrphanPolicy = OrphanPolicyDelete
+	config.MaxRemovalPercent = 25
+	service, inventory, store := newTestIntegration(t, config)
+	ctx := context.Background()
+
+	inventory.setNodes(0, 1, 2, 3)
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Initial sync failed: %v", err)
+	}
+
+	// HSM returning half of the inventory must not delete the other half
+	inventory.setNodes(0, 1)
+	err := service.SyncNodesFromHSM(ctx)
+	if !errors.Is(err, ErrRemovalThresholdExceeded) {
+		t.Fatalf("Expected the removal threshold to abort the sync, got %v", err)
+	}
+	if nodes := store.list(); len(nodes) != 4 {
+		t.Errorf("Expected no nodes to be removed, got %d nodes", len(nodes))
+	}
+	if _, lastErr := service.LastSync(); !errors.Is(lastErr, ErrRemovalThresholdExceeded) {
+		t.Errorf("Expected the aborted sync to be recorded, got %v", lastErr)
+	}
+
+	// Removing one of four nodes is within the threshold
+	inventory.setNodes(0, 1, 2)
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if nodes := store.list(); len(nodes) != 3 {
+		t.Errorf("Expected one node to be removed, got %d nodes", len(nodes))
+	}
+}
+
+func TestSyncKeepsUnsyncedNodes(t *testing.T) {
+	service, inventory, store := newTestIntegration(t, DefaultIntegrationConfig())
+	inventory.setNodes(0)
+	if err := service.SyncNodesFromHSM(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// A node created outside HSM sync has no recorded sync and is never orphaned
+	store.mu.Lock()
+	manual := &node.Node{Spec: node.NodeSpec{XName: "x2000c0s0b0n0"}}
+	manual.Metadata.UID = "nod-manual"
+	store.nodes[manual.Metadata.UID] = manual
+	store.mu.Unlock()
+
+	if err := service.SyncNodesFromHSM(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if n := store.byXName("x2000c0s0b0n0"); n == nil || n.IsRemoved() {
+		t.Errorf("Expected the manually created node to be left alone, got %+v", n)
+	}
+}
+
+func TestComponentFilter(t *testing.T) {
+	enabled, disabled := true, false
+	filter := ComponentFilter{
+		Include: ComponentMatch{Type: []string{"Node"}, Role: []string{"Compute", "Application"}, Enabled: &enabled},
+		Exclude: ComponentMatch{Role: []string{"Application"}, SubRole: []string{"UAN"}},
+	}
+
+	// The role exclusion can't be expressed next to included roles, so only the subrole is negated
+	if query := filter.Query().Encode(); query != "enabled=true&role=Compute&role=Application&subrole=%21UAN&type=Node" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	tests := []struct {
+		comp HSMComponent
+		want bool
+	}{
+		{HSMComponent{Type: "Node", Role: "Compute", Enabled: &enabled}, true},
+		{HSMComponent{Type: "node", Role: "compute", Enabled: &enabled}, true},
+		{HSMComponent{Type: "Node", Role: "Compute"}, true}, // Enabled omitted by HSM
+		{HSMComponent{Type: "Node", Role: "Compute", Enabled: &disabled}, false},
+		{HSMComponent{Type: "Node", Role: "Application", Enabled: &enabled}, false},
+		{HSMComponent{Type: "Node", Role: "Compute", SubRole: "UAN", Enabled: &enabled}, false},
+		{HSMComponent{Type: "Node", Role: "Management", Enabled: &enabled}, false},
+		{HSMComponent{Type: "NodeBMC", Enabled: &enabled}, false},
+	}
+	for _, tt := range tests {
+		if got := filter.Matches(tt.comp); got != tt.want {
+			t.Errorf("Matches(%+v) = %v, want %v", tt.comp, got, tt.want)
+		}
+	}
+
+	if err := (ComponentFilter{Include: ComponentMatch{Role: []string{""}}}).Validate(); err == nil {
+		t.Errorf("Expected an empty filter value to be rejected")
+	}
+}
+
+func TestSyncFilter(t *testing.T) {
+	config := DefaultIntegrationConfig()
+	config.Filter.Exclude.SubRole = []string{"Worker"}
+	config.MaxRemovalPercent = 50
+	service, inventory, store := newTestIntegration(t, config)
+
+	inventory.setNodes(0, 1, 2)
+	inventory.mu.Lock()
+	inventory.components[1].SubRole = "Worker"
+	inventory.components[2].Role = "Management"
+	inventory.components = append(inventory.components, HSMComponent{ID: "x1000c0s0b0", Type: "NodeBMC", Role: "Compute"})
+	inventory.mu.Unlock()
+
+	if err := service.SyncNodesFromHSM(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if nodes := store.list(); len(nodes) != 1 || nodes[0].Spec.XName != "x1000c0s0b0n0" {
+		t.Errorf("Expected only the unfiltered compute node to be synced, got %+v", nodes)
+	}
+
+	// Filtering is done by HSM rather than by fetching every component
+	inventory.mu.Lock()
+	query := inventory.queries[0]
+	inventory.mu.Unlock()
+	if query.Get("type") != "Node" || len(query["role"]) != 2 || query.Get("subrole") != "!Worker" {
+		t.Errorf("Expected the filter to be sent to HSM, got %v", query)
+	}
+
+	// Listed nodes are the synced ones
+	nodes, err := service.ListNodes(context.Background())
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Spec.XName != "x1000c0s0b0n0" {
+		t.Errorf("Expected only the unfiltered compute node to be listed, got %+v", nodes)
+	}
+	if status := nodes[0].Status; status.State != "" || status.HSMState != "Ready" {
+		t.Errorf("Expected the HSM state to be recorded as HSM state only, got %+v", status)
+	}
+}
+
+func TestSyncGroups(t *testing.T) {
+	service, inventory, store := newTestIntegration(t, DefaultIntegrationConfig())
+	var changed []string
+	service.OnNodeChange(func(xname string) { changed = append(changed, xname) })
+	ctx := context.Background()
+
+	inventory.setNodes(0, 1)
+	inventory.groups = []HSMGroup{
+		{Label: "compute", Members: HSMMembers{IDs: []string{"x1000c0s0b0n0", "x1000c0s0b0n1"}}},
+		{Label: "gpu", Members: HSMMembers{IDs: []string{"x1000c0s0b0n1"}}},
+	}
+	inventory.partitions = []HSMPartition{{Name: "p1", Members: HSMMembers{IDs: []string{"x1000c0s0b0n0"}}}}
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if groups := store.byXName("x1000c0s0b0n0").Spec.Groups; !slices.Equal(groups, []string{"compute", "p1"}) {
+		t.Errorf("Expected group and partition membership, got %v", groups)
+	}
+	if groups := store.byXName("x1000c0s0b0n1").Spec.Groups; !slices.Equal(groups, []string{"compute", "gpu"}) {
+		t.Errorf("Expected both groups, got %v", groups)
+	}
+	if len(changed) != 0 {
+		t.Errorf("Expected no change notifications for new nodes, got %v", changed)
+	}
+
+	// Node 1 leaves the gpu group
+	inventory.mu.Lock()
+	inventory.groups[1].Members.IDs = nil
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if groups := store.byXName("x1000c0s0b0n1").Spec.Groups; !slices.Equal(groups, []string{"compute"}) {
+		t.Errorf("Expected the removed membership to be synced, got %v", groups)
+	}
+	if !slices.Equal(changed, []string{"x1000c0s0b0n1"}) {
+		t.Errorf("Expected only the changed node to be reported, got %v", changed)
+	}
+
+	// An enabled setting HSM omits is recorded as unknown rather than disabled
+	inventory.mu.Lock()
+	inventory.components[0].Enabled = nil
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if status := store.byXName("x1000c0s0b0n0").Status; status.HSMState != "Ready" || status.HSMEnabled != nil {
+		t.Errorf("Expected an omitted enabled setting to be left unset, got %+v", status)
+	}
+}
+
+func TestSyncInterfaces(t *testing.T) {
+	config := DefaultIntegrationConfig()
+	config.BootMACRule = BootMACDescription
+	config.BootMACDescription = "boot"
+	service, inventory, store := newTestIntegration(t, config)
+	ctx := context.Background()
+
+	inventory.setNodes(0)
+	inventory.mu.Lock()
+	inventory.interfaces = []HSMEthernetInterface{
+		{ComponentID: "x1000c0s0b0n0", MACAddress: "AA-BB-CC-DD-EE-10", Description: "HSN",
+			IPAddresses: []HSMIPAddress{{IPAddress: "10.1.0.10", Network: "HSN"}, {IPAddress: "10.2.0.10", Network: "CAN"}}},
+		{ComponentID: "x1000c0s0b0n0", MACAddress: "aa:bb:cc:dd:ee:01", Description: "Boot NIC",
+			IPAddresses: []HSMIPAddress{{IPAddress: "10.0.0.10", Network: "NMN"}}},
+		{ComponentID: "x1000c0s0b0b0", MACAddress: "aa:bb:cc:dd:ee:ff", Type: "NodeBMC"},
+	}
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	n := store.byXName("x1000c0s0b0n0")
+	expected := []node.Interface{
+		{MAC: "aa:bb:cc:dd:ee:10", IP: "10.1.0.10", Description: "HSN"},
+		{MAC: "aa:bb:cc:dd:ee:01", IP: "10.0.0.10", Description: "Boot NIC"},
+	}
+	if !slices.Equal(n.Spec.Interfaces, expected) {
+		t.Errorf("Expected every interface of the node, got %+v", n.Spec.Interfaces)
+	}
+	if n.Spec.BootMAC != "aa:bb:cc:dd:ee:01" {
+		t.Errorf("Expected the interface matching the description to be the boot MAC, got %s", n.Spec.BootMAC)
+	}
+
+	// A changed IP address updates the node
+	inventory.mu.Lock()
+	inventory.interfaces[0].IPAddresses[0].IPAddress = "10.1.0.11"
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if ip := store.byXName("x1000c0s0b0n0").Spec.Interfaces[0].IP; ip != "10.1.0.11" {
+		t.Errorf("Expected the new IP address to be synced, got %s", ip)
+	}
+
+	// On-demand resolution sees the same interfaces
+	resolved, err := service.convertHSMComponentToNode(ctx, &HSMComponent{ID: "x1000c0s0b0n0"})
+	if err != nil {
+		t.Fatalf("Failed to convert component: %v", err)
+	}
+	if resolved.Spec.BootMAC != "aa:bb:cc:dd:ee:01" || len(resolved.Spec.Interfaces) != 2 {
+		t.Errorf("Expected the resolved node to match the synced one, got %+v", resolved.Spec)
+	}
+}
+
+func TestChooseBootMAC(t *testing.T) {
+	interfaces := []node.Interface{
+		{MAC: "aa:bb:cc:dd:ee:10", Description: "HSN"},
+		{MAC: "aa:bb:cc:dd:ee:01", Description: "Management"},
+	}
+	tests := []struct {
+		rule, description, expected string
+	}{
+		{BootMACFirst, "", "aa:bb:cc:dd:ee:10"},
+		{BootMACLowest, "", "aa:bb:cc:dd:ee:01"},
+		{BootMACDescription, "management", "aa:bb:cc:dd:ee:01"},
+		{BootMACDescription, "none", "aa:bb:cc:dd:ee:10"},
+		{"", "", "aa:bb:cc:dd:ee:10"},
+	}
+	for _, tt := range tests {
+		if mac := chooseBootMAC(interfaces, tt.rule, tt.description); mac != tt.expected {
+			t.Errorf("chooseBootMAC(%q, %q) = %s, expected %s", tt.rule, tt.description, mac, tt.expected)
+		}
+	}
+	if mac := chooseBootMAC(nil, BootMACFirst, ""); mac != "" {
+		t.Errorf("Expected no boot MAC without interfaces, got %s", mac)
+	}
+}
+
+func TestSyncComponentState(t *testing.T) {
+	service, inventory, store := newTestIntegration(t, DefaultIntegrationConfig())
+	var changed []string
+	service.OnNodeChange(func(xname string) { changed = append(changed, xname) })
+	ctx := context.Background()
+
+	inventory.setNodes(0, 1)
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	status := store.byXName("x1000c0s0b0n0").Status
+	if status.HSMState != "Ready" || status.HSMEnabled == nil || !*status.HSMEnabled {
+		t.Errorf("Expected the HSM component state in the node status, got %+v", status)
+	}
+
+	// Node 1 is disabled in HSM
+	disabled := false
+	inventory.mu.Lock()
+	inventory.components[1].Enabled = &disabled
+	inventory.components[1].Flag = "Alert"
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	status = store.byXName("x1000c0s0b0n1").Status
+	if status.HSMFlag != "Alert" || status.HSMEnabled == nil || *status.HSMEnabled {
+		t.Errorf("Expected the disabled state to be synced, got %+v", status)
+	}
+	if !slices.Equal(changed, []string{"x1000c0s0b0n1"}) {
+		t.Errorf("Expected only the changed node to be reported, got %v", changed)
+	}
+
+	// An enabled setting HSM omits is recorded as unknown rather than disabled
+	inventory.mu.Lock()
+	inventory.components[0].Enabled = nil
+	inventory.mu.Unlock()
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if status := store.byXName("x1000c0s0b0n0").Status; status.HSMState != "Ready" || status.HSMEnabled != nil {
+		t.Errorf("Expected an omitted enabled setting to be left unset, got %+v", status)
+	}
+}
+
+func TestSCN(t *testing.T) {
+	callbacks := http.NewServeMux()
+	callbackServer := httptest.NewServer(callbacks)
+	defer callbackServer.Close()
+
+	config := DefaultIntegrationConfig()
+	config.SyncInterval = time.Hour // Only the initial full sync runs
+	config.SCNCallbackURL = callbackServer.URL + SCNPath
+	service, inventory, store := newTestIntegration(t, config)
+	callbacks.Handle(SCNPath, service.SCNHandler())
+	var changed []string
+	var changedMu sync.Mutex
+	service.OnNodeChange(func(xname string) {
+		changedMu.Lock()
+		defer changedMu.Unlock()
+		changed = append(changed, xname)
+	})
+
+	inventory.setNodes(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.StartSyncWorker(ctx)
+		close(done)
+	}()
+
+	waitFor(t, "the subscription and initial sync", func() bool {
+		inventory.mu.Lock()
+		defer inventory.mu.Unlock()
+		return len(inventory.subscriptions) == 1 && store.byXName("x1000c0s0b0n0") != nil
+	})
+	inventory.mu.Lock()
+	subscription := inventory.subscriptions[1]
+	inventory.mu.Unlock()
+	if subscription.URL != config.SCNCallbackURL || len(subscription.States) == 0 || subscription.Enabled == nil {
+		t.Errorf("Unexpected subscription: %+v", subscription)
+	}
+
+	// New hardware appears and node 0 is disabled
+	inventory.setNodes(0, 1)
+	disabled := false
+	inventory.mu.Lock()
+	inventory.components[0].Enabled = &disabled
+	inventory.mu.Unlock()
+	inventory.notify(t, StateChangeNotification{Components: []string{"x1000c0s0b0n1"}, State: "Ready"})
+	inventory.notify(t, StateChangeNotification{Components: []string{"x1000c0s0b0n0"}, Enabled: &disabled})
+
+	waitFor(t, "the notifications to be applied", func() bool {
+		n0, n1 := store.byXName("x1000c0s0b0n0"), store.byXName("x1000c0s0b0n1")
+		return n1 != nil && n1.Spec.BootMAC == "aa:bb:cc:dd:ee:01" &&
+			n0.Status.HSMEnabled != nil && !*n0.Status.HSMEnabled
+	})
+	changedMu.Lock()
+	if !slices.Equal(changed, []string{"x1000c0s0b0n0"}) {
+		t.Errorf("Expected the disabled node to be reported as changed, got %v", changed)
+	}
+	changedMu.Unlock()
+
+	stats := service.GetStats(context.Background())["scn"].(map[string]interface{})
+	if stats["received"] != uint64(2) || stats["subscription_id"] != int64(1) {
+		t.Errorf("Unexpected SCN stats: %v", stats)
+	}
+
+	// Stopping the worker removes the subscription
+	cancel()
+	<-done
+	inventory.mu.Lock()
+	defer inventory.mu.Unlock()
+	if len(inventory.subscriptions) != 0 {
+		t.Errorf("Expected the subscription to be deleted, got %v", inventory.subscriptions)
+	}
+}
+
+func TestSCNHandler(t *testing.T) {
+	service, _, _ := newTestIntegration(t, DefaultIntegrationConfig())
+	handler := service.SCNHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, SCNPath, strings.NewReader("not json")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid notification, got %d", w.Code)
+	}
+
+	// Without a running sync worker the queue fills up and later notifications are refused
+	for i := 0; i < scnQueueSize; i++ {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, SCNPath, strings.NewReader(`{"Components":["x1000c0s0b0n0"]}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected notification %d to be queued, got %d", i, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, SCNPath, strings.NewReader(`{"Components":["x1000c0s0b0n0"]}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 when the queue is full, got %d", w.Code)
+	}
+}
+
+func TestSCNKeepsCache(t *testing.T) {
+	service, inventory, store := newTestIntegration(t, DefaultIntegrationConfig())
+	service.hsmClient.cache.expiry = time.Hour
+	ctx := context.Background()
+	reads := func(path string) int {
+		inventory.mu.Lock()
+		defer inventory.mu.Unlock()
+		return inventory.reads[path]
+	}
+	lists := func() int {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.lists
+	}
+
+	inventory.setNodes(0, 1)
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	groupReads, nodeLists := reads("/hsm/v2/groups"), lists()
+
+	// A state change of a known node reads only that node and keeps cached memberships
+	disabled := false
+	inventory.mu.Lock()
+	inventory.components[0].Enabled = &disabled
+	inventory.mu.Unlock()
+	if err := service.HandleSCN(ctx, StateChangeNotification{Components: []string{"x1000c0s0b0n0"}, Enabled: &disabled}); err != nil {
+		t.Fatalf("HandleSCN failed: %v", err)
+	}
+	if status := store.byXName("x1000c0s0b0n0").Status; status.HSMEnabled == nil || *status.HSMEnabled {
+		t.Errorf("Expected the node to be disabled, got %+v", status)
+	}
+	if reads("/hsm/v2/groups") != groupReads || lists() != nodeLists {
+		t.Errorf("Expected cached memberships and no node list to be used, got %d group reads and %d node lists",
+			reads("/hsm/v2/groups")-groupReads, lists()-nodeLists)
+	}
+
+	// The next full sync reads the changed component rather than a cached list
+	if err := service.SyncNodesFromHSM(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if status := store.byXName("x1000c0s0b0n0").Status; status.HSMEnabled == nil || *status.HSMEnabled {
+		t.Errorf("Expected the full sync to keep the node disabled, got %+v", status)
+	}
+
+	// New hardware lists the nodes and its interfaces are added to the cached list
+	enabled := true
+	inventory.mu.Lock()
+	inventory.components = append(inventory.components, HSMComponent{
+		ID: "x1000c0s0b0n2", Type: "Node", State: "Ready", Enabled: &enabled, Role: "Compute", NID: 3,
+	})
+	inventory.interfaces = append(inventory.interfaces, HSMEthernetInterface{
+		ComponentID: "x1000c0s0b0n2", Type: "Node", MACAddress: "aa:bb:cc:dd:ee:02",
+	})
+	inventory.mu.Unlock()
+	nodeLists = lists()
+	if err := service.HandleSCN(ctx, StateChangeNotification{Components: []string{"x1000c0s0b0n2"}, State: "Ready"}); err != nil {
+		t.Fatalf("HandleSCN failed: %v", err)
+	}
+	if store.byXName("x1000c0s0b0n2") == nil || lists() != nodeLists+1 {
+		t.Errorf("Expected the new node to be created after listing the nodes once, got %d lists", lists()-nodeLists)
+	}
+
+	ethernetReads := reads("/hsm/v2/Inventory/EthernetInterfaces")
+	interfaces, err := service.hsmClient.interfaceList(ctx)
+	if err != nil {
+		t.Fatalf("interfaceList failed: %v", err)
+	}
+	if iface := interfaces.byMAC[macKey("aa:bb:cc:dd:ee:02")]; iface == nil || iface.ComponentID != "x1000c0s0b0n2" {
+		t.Errorf("Expected the new interface in the cached list, got %+v", iface)
+	}
+	if _, found := interfaces.byMAC[macKey("aa:bb:cc:dd:ee:00")]; !found || reads("/hsm/v2/Inventory/EthernetInterfaces") != ethernetReads {
+		t.Errorf("Expected the cached interface list to be kept")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// Boot MAC rules choose which of a component's ethernet interfaces it boots from
+const (
+	BootMACFirst       = "first"       // The first interface HSM lists for the component
+	BootMACLowest      = "lowest"      // The interface with the numerically lowest MAC address
+	BootMACDescription = "description" // The first interface whose description contains BootMACDescription
+)
+
+// nodeInterfaces maps a component's HSM ethernet interfaces to node interfaces,
+// skipping interfaces without a MAC address. A node interface holds one
+// address, the first one HSM lists for the interface.
+func nodeInterfaces(interfaces []HSMEthernetInterface) []node.Interface {
+	result := []node.Interface{}
+	for _, iface := range interfaces {
+		if strings.TrimSpace(iface.MACAddress) == "" {
+			continue
+		}
+		result = append(result, node.Interface{
+			MAC:         normalizeMAC(iface.MACAddress),
+			IP:          iface.primaryIP(),
+			Description: iface.Description,
+		})
+	}
+	return result
+}
+
+// chooseBootMAC picks the boot MAC from a node's interfaces by rule. The
+// description rule falls back to the first interface when none matches.
+func chooseBootMAC(interfaces []node.Interface, rule, description string) string {
+	if len(interfaces) == 0 {
+		return ""
+	}
+
+	switch rule {
+	case BootMACLowest:
+		lowest := interfaces[0].MAC
+		for _, iface := range interfaces[1:] {
+			if compareMAC(iface.MAC, lowest) < 0 {
+				lowest = iface.MAC
+			}
+		}
+		return lowest
+	case BootMACDescription:
+		for _, iface := range interfaces {
+			if description != "" && strings.Contains(strings.ToLower(iface.Description), strings.ToLower(description)) {
+				return iface.MAC
+			}
+		}
+	}
+	return interfaces[0].MAC
+}
+
+// compareMAC orders MAC addresses numerically, placing unparsable ones last
+func compareMAC(a, b string) int {
+	hwA, errA := net.ParseMAC(a)
+	hwB, errB := net.ParseMAC(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+	return bytes.Compare(hwA, hwB)
+}
@@ -0,0 +1,268 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package hsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// SCNPath is where the boot service receives HSM state change notifications
+const SCNPath = "/hsm/scn"
+
+// scnStates and scnRoles are the HSM component states and roles whose changes
+// the boot service subscribes to
+var (
+	scnStates = []string{"Unknown", "Empty", "Populated", "Off", "On", "Standby", "Halt", "Ready"}
+	scnRoles  = []string{"Compute", "Service", "System", "Application", "Storage", "Management"}
+)
+
+const (
+	// scnQueueSize is how many notifications wait for the sync worker before new ones are refused
+	scnQueueSize = 100
+	// scnBatchSize caps the component IDs of one HSM query
+	scnBatchSize = 100
+	// scnMaxBodySize caps the size of a notification
+	scnMaxBodySize = 1 << 20
+)
+
+// SCNSubscription is an HSM state change notification subscription
+type SCNSubscription struct {
+	ID             int64    `json:"ID,omitempty"`
+	Subscriber     string   `json:"Subscriber"`
+	Enabled        *bool    `json:"Enabled,omitempty"` // Notify of enabled changes
+	Roles          []string `json:"Roles,omitempty"`
+	SubRoles       []string `json:"SubRoles,omitempty"`
+	SoftwareStatus []string `json:"SoftwareStatus,omitempty"`
+	States         []string `json:"States,omitempty"`
+	URL            string   `json:"Url"`
+}
+
+// StateChangeNotification is sent by HSM when components change. It carries
+// only the attributes that changed.
+type StateChangeNotification struct {
+	Components     []string `json:"Components"`
+	Enabled        *bool    `json:"Enabled,omitempty"`
+	Flag           string   `json:"Flag,omitempty"`
+	Role           string   `json:"Role,omitempty"`
+	SubRole        string   `json:"SubRole,omitempty"`
+	SoftwareStatus string   `json:"SoftwareStatus,omitempty"`
+	State          string   `json:"State,omitempty"`
+	Timestamp      string   `json:"Timestamp,omitempty"`
+}
+
+// SubscribeSCN registers a state change notification subscription with HSM
+func (c *HSMClient) SubscribeSCN(ctx context.Context, subscription SCNSubscription) (*SCNSubscription, error) {
+	body, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SCN subscription: %w", err)
+	}
+
+	var created SCNSubscription
+	if err := c.do(ctx, http.MethodPost, "/hsm/v2/Subscriptions/SCN", body, &created); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to HSM state changes: %w", err)
+	}
+	return &created, nil
+}
+
+// UnsubscribeSCN deletes a state change notification subscription from HSM
+func (c *HSMClient) UnsubscribeSCN(ctx context.Context, id int64) error {
+	path := "/hsm/v2/Subscriptions/SCN/" + strconv.FormatInt(id, 10)
+	if err := c.do(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to unsubscribe from HSM state changes: %w", err)
+	}
+	return nil
+}
+
+// GetCurrentComponents retrieves components by ID from HSM, bypassing the
+// cache, e.g., after HSM reported that they changed
+func (c *HSMClient) GetCurrentComponents(ctx context.Context, ids []string) ([]HSMComponent, error) {
+	var components []HSMComponent
+	for batch := range slices.Chunk(ids, scnBatchSize) {
+		var hsmResp HSMResponse
+		if err := c.get(ctx, "/hsm/v2/State/Components?"+url.Values{"id": batch}.Encode(), &hsmResp); err != nil {
+			return nil, fmt.Errorf("failed to get components from HSM: %w", err)
+		}
+		components = append(components, hsmResp.Components...)
+	}
+	return components, nil
+}
+
+// GetCurrentInterfaces retrieves the ethernet interfaces of components from
+// HSM, bypassing the cache
+func (c *HSMClient) GetCurrentInterfaces(ctx context.Context, componentIDs []string) ([]HSMEthernetInterface, error) {
+	var interfaces []HSMEthernetInterface
+	for batch := range slices.Chunk(componentIDs, scnBatchSize) {
+		var list ethernetInterfaceList
+		if err := c.get(ctx, "/hsm/v2/Inventory/EthernetInterfaces?"+url.Values{"ComponentID": batch}.Encode(), &list); err != nil {
+			return nil, fmt.Errorf("failed to get ethernet interfaces from HSM: %w", err)
+		}
+		interfaces = append(interfaces, list...)
+	}
+	return interfaces, nil
+}
+
+// scnStats counts state change notifications
+type scnStats struct {
+	received  atomic.Uint64
+	processed atomic.Uint64
+	failed    atomic.Uint64
+	refused   atomic.Uint64
+}
+
+// HandleSCN applies a state change notification to the nodes it lists. The
+// components are read from HSM again, since a notification only carries the
+// changed attributes, and only cached data about them is dropped. Components
+// that no longer match the sync filter are left to the next full sync, which
+// applies the orphan policy and its removal threshold.
+func (s *IntegrationService) HandleSCN(ctx context.Context, scn StateChangeNotification) (err error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	defer func() {
+		if err != nil {
+			s.scnStats.failed.Add(1)
+		} else {
+			s.scnStats.processed.Add(1)
+		}
+	}()
+
+	if len(scn.Components) == 0 {
+		return nil
+	}
+
+	components, err := s.hsmClient.GetCurrentComponents(ctx, scn.Components)
+	if err != nil {
+		return err
+	}
+	// Cached data about the components predates the change and would undo it on the next full sync
+	s.hsmClient.invalidateComponents(scn.Components)
+
+	var matching []HSMComponent
+	var ids []string
+	for _, comp := range components {
+		if s.filter.Matches(comp) {
+			matching = append(matching, comp)
+			ids = append(ids, comp.ID)
+		}
+	}
+	if len(matching) == 0 {
+		s.logger.Printf("State change of %d components matches no synced nodes", len(scn.Components))
+		return nil
+	}
+
+	interfaces, err := s.hsmClient.GetCurrentInterfaces(ctx, ids)
+	if err != nil {
+		return err
+	}
+	s.hsmClient.replaceInterfaces(ids, interfaces)
+	// Notifications don't report membership changes, so cached memberships are used
+	groupMap, err := s.getMemberships(ctx)
+	if err != nil {
+		return err
+	}
+	existingNodes, err := s.affectedNodes(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	counts := s.syncComponents(ctx, matching, newInterfaceList(interfaces), groupMap, existingNodes)
+	s.logger.Printf("HSM state change applied: %d created, %d updated, %d skipped, %d failed",
+		counts.created, counts.updated, counts.skipped, counts.failed)
+	return nil
+}
+
+// SCNHandler returns the HTTP handler HSM posts state change notifications
+// to. Notifications are queued for the sync worker, so they are applied in
+// order and never alongside a full sync.
+func (s *IntegrationService) SCNHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var scn StateChangeNotification
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, scnMaxBodySize)).Decode(&scn); err != nil {
+			http.Error(w, "invalid state change notification: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.scnStats.received.Add(1)
+
+		select {
+		case s.scnQueue <- scn:
+			w.WriteHeader(http.StatusOK)
+		default:
+			// The next full sync picks up the change
+			s.scnStats.refused.Add(1)
+			s.logger.Printf("Refusing state change of %d components: notification queue is full", len(scn.Components))
+			http.Error(w, "notification queue is full", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// subscribe registers the SCN subscription unless it already exists
+func (s *IntegrationService) subscribe(ctx context.Context) {
+	if s.scnCallbackURL == "" || s.scnSubscriptionID.Load() != 0 {
+		return
+	}
+
+	subscriber := "boot-service"
+	if hostname, err := os.Hostname(); err == nil {
+		subscriber += "@" + hostname
+	}
+	enabled := true
+	subscription, err := s.hsmClient.SubscribeSCN(ctx, SCNSubscription{
+		Subscriber: subscriber,
+		Enabled:    &enabled,
+		Roles:      scnRoles,
+		States:     scnStates,
+		URL:        s.scnCallbackURL,
+	})
+	if err != nil {
+		s.logger.Printf("Warning: %v, relying on periodic sync", err)
+		return
+	}
+	s.scnSubscriptionID.Store(subscription.ID)
+	s.logger.Printf("Subscribed to HSM state changes (subscription %d, callback %s)", subscription.ID, s.scnCallbackURL)
+}
+
+// unsubscribe deletes the SCN subscription so HSM stops notifying a stopped instance
+func (s *IntegrationService) unsubscribe() {
+	id := s.scnSubscriptionID.Swap(0)
+	if id == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.hsmClient.UnsubscribeSCN(ctx, id); err != nil {
+		s.logger.Printf("Warning: %v", err)
+		return
+	}
+	s.logger.Printf("Unsubscribed from HSM state changes (subscription %d)", id)
+}
+
+// scnStatsMap reports the SCN subscription and notification counts
+func (s *IntegrationService) scnStatsMap() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":         s.scnCallbackURL != "",
+		"callback_url":    s.scnCallbackURL,
+		"subscription_id": s.scnSubscriptionID.Load(),
+		"received":        s.scnStats.received.Load(),
+		"processed":       s.scnStats.processed.Load(),
+		"failed":          s.scnStats.failed.Load(),
+		"refused":         s.scnStats.refused.Load(),
+		"queued":          len(s.scnQueue),
+	}
+}
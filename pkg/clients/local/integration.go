@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"reflect"
 	"time"
 
 	"github.com/openchami/boot-service/pkg/client"
@@ -69,23 +70,57 @@ func (s *IntegrationService) ResolveNodeByIdentifier(ctx context.Context, identi
 
 	// Create and return node resource
 	nodeResource := &node.Node{
-		Spec: node.NodeSpec{
-			XName:   yamlNode.XName,
-			Role:    yamlNode.Role,
-			SubRole: yamlNode.SubRole,
-			BootMAC: yamlNode.BootMAC,
-		},
+		Spec: yamlNodeSpec(*yamlNode),
 		Status: node.NodeStatus{
 			State: yamlNode.State,
 		},
 	}
 
+	return nodeResource, nil
+}
+
+// ListNodes lists the nodes in the YAML file (not persisted)
+func (s *IntegrationService) ListNodes(ctx context.Context) ([]node.Node, error) {
+	yamlNodes, err := s.yamlProvider.GetAllNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing YAML nodes: %w", err)
+	}
+
+	nodes := make([]node.Node, 0, len(yamlNodes))
+	for _, yamlNode := range yamlNodes {
+		nodes = append(nodes, node.Node{
+			Spec: yamlNodeSpec(yamlNode),
+			Status: node.NodeStatus{
+				State: yamlNode.State,
+			},
+		})
+	}
+	return nodes, nil
+}
+
+// yamlNodeSpec converts a YAML node to a node spec, normalizing MAC addresses
+func yamlNodeSpec(yamlNode YAMLNode) node.NodeSpec {
+	spec := node.NodeSpec{
+		XName:    yamlNode.XName,
+		Role:     yamlNode.Role,
+		SubRole:  yamlNode.SubRole,
+		Hostname: yamlNode.Hostname,
+		BootMAC:  macKey(yamlNode.BootMAC),
+	}
+
 	// Add NID if present
 	if yamlNode.NID > 0 {
-		nodeResource.Spec.NID = int32(yamlNode.NID)
+		spec.NID = int32(yamlNode.NID)
 	}
 
-	return nodeResource, nil
+	for _, iface := range yamlNode.EthernetInterfaces {
+		spec.Interfaces = append(spec.Interfaces, node.Interface{
+			MAC: macKey(iface.MACAddress),
+			IP:  iface.IPAddress,
+		})
+	}
+
+	return spec
 }
 
 // SyncNodesFromYAML synchronizes all nodes from YAML to the boot service
@@ -109,14 +144,8 @@ func (s *IntegrationService) SyncNodesFromYAML(ctx context.Context) error {
 		if err != nil {
 			// Node doesn't exist, create it
 			createReq := client.CreateNodeRequest{
-				NodeSpec: node.NodeSpec{
-					XName:   yamlNode.XName,
-					Role:    yamlNode.Role,
-					SubRole: yamlNode.SubRole,
-					BootMAC: yamlNode.BootMAC,
-					NID:     int32(yamlNode.NID),
-				},
-				Name: yamlNode.XName,
+				NodeSpec: yamlNodeSpec(yamlNode),
+				Name:     yamlNode.XName,
 			}
 
 			_, err = s.bootClient.CreateNode(ctx, createReq)
@@ -130,14 +159,8 @@ func (s *IntegrationService) SyncNodesFromYAML(ctx context.Context) error {
 			// Node exists, update if different
 			if s.shouldUpdateNode(existingNode, yamlNode) {
 				updateReq := client.UpdateNodeRequest{
-					NodeSpec: node.NodeSpec{
-						XName:   yamlNode.XName,
-						Role:    yamlNode.Role,
-						SubRole: yamlNode.SubRole,
-						BootMAC: yamlNode.BootMAC,
-						NID:     int32(yamlNode.NID),
-					},
-					Name: yamlNode.XName,
+					NodeSpec: yamlNodeSpec(yamlNode),
+					Name:     yamlNode.XName,
 				}
 
 				_, err = s.bootClient.UpdateNode(ctx, yamlNode.XName, updateReq)
@@ -203,21 +226,24 @@ func (s *IntegrationService) GetStats(ctx context.Context) map[string]interface{
 
 // shouldUpdateNode determines if a node needs to be updated
 func (s *IntegrationService) shouldUpdateNode(existing *node.Node, yamlNode YAMLNode) bool {
+	spec := yamlNodeSpec(yamlNode)
+
 	// Compare key fields
-	if existing.Spec.Role != yamlNode.Role ||
-		existing.Spec.SubRole != yamlNode.SubRole ||
-		existing.Spec.BootMAC != yamlNode.BootMAC ||
+	if existing.Spec.Role != spec.Role ||
+		existing.Spec.SubRole != spec.SubRole ||
+		existing.Spec.Hostname != spec.Hostname ||
+		existing.Spec.BootMAC != spec.BootMAC ||
 		existing.Status.State != yamlNode.State {
 		return true
 	}
 
-	// Compare NID
-	existingNID := int32(0)
-	if existing.Spec.NID != 0 {
-		existingNID = existing.Spec.NID
+	// Compare interfaces
+	if !reflect.DeepEqual(existing.Spec.Interfaces, spec.Interfaces) {
+		return true
 	}
-	yamlNID := int32(yamlNode.NID)
-	return existingNID != yamlNID
+
+	// Compare NID
+	return existing.Spec.NID != spec.NID
 }
 
 // ReloadYAML forces a reload of the YAML file
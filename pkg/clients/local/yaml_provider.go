@@ -9,11 +9,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/openchami/boot-service/pkg/validation"
 	"gopkg.in/yaml.v3"
 )
 
@@ -37,6 +40,7 @@ type YAMLNode struct {
 	State              string              `yaml:"state"`
 	Enabled            bool                `yaml:"enabled"`
 	NID                int                 `yaml:"nid,omitempty"`
+	Hostname           string              `yaml:"hostname,omitempty"`
 	BootMAC            string              `yaml:"boot_mac,omitempty"`
 	EthernetInterfaces []EthernetInterface `yaml:"ethernet_interfaces,omitempty"`
 	Metadata           map[string]string   `yaml:"metadata,omitempty"`
@@ -116,14 +120,23 @@ func (p *YAMLNodeProvider) loadNodes() error {
 			p.nodes[node.XName] = node
 		}
 		if node.BootMAC != "" {
-			p.nodes[strings.ToLower(node.BootMAC)] = node
+			p.nodes[macKey(node.BootMAC)] = node
 		}
 
 		// Index by all ethernet interface MAC addresses
 		for _, iface := range node.EthernetInterfaces {
 			if iface.MACAddress != "" {
-				p.nodes[strings.ToLower(iface.MACAddress)] = node
+				p.nodes[macKey(iface.MACAddress)] = node
 			}
+			if iface.IPAddress != "" {
+				p.nodes[ipKey(iface.IPAddress)] = node
+			}
+		}
+
+		// Index by full and short hostname
+		if node.Hostname != "" {
+			p.nodes[hostnameKey(node.Hostname)] = node
+			p.nodes[hostnameKey(validation.ShortHostname(node.Hostname))] = node
 		}
 
 		// Index by NID if present
@@ -143,7 +156,7 @@ func (p *YAMLNodeProvider) loadNodes() error {
 	return nil
 }
 
-// GetNodeByIdentifier retrieves a node by any identifier (ID, XName, MAC, NID)
+// GetNodeByIdentifier retrieves a node by any identifier (ID, XName, MAC, NID, hostname, IP)
 func (p *YAMLNodeProvider) GetNodeByIdentifier(ctx context.Context, identifier string) (*YAMLNode, error) { //nolint:revive
 	// Reload if auto-reload is enabled
 	if p.autoReload {
@@ -161,14 +174,58 @@ func (p *YAMLNodeProvider) GetNodeByIdentifier(ctx context.Context, identifier s
 		return &node, nil
 	}
 
-	// Try lowercase MAC address lookup
-	if node, found := p.nodes[strings.ToLower(identifier)]; found {
+	// Try MAC address lookup in any notation
+	if node, found := p.nodes[macKey(identifier)]; found {
+		return &node, nil
+	}
+
+	// Try interface IP address lookup
+	if net.ParseIP(identifier) != nil {
+		if node, found := p.nodes[ipKey(identifier)]; found {
+			p.logger.Printf("Matched IP %s to YAML node %s ethernet interface", identifier, node.XName)
+			return &node, nil
+		}
+	}
+
+	// Try hostname lookup (full, then short name)
+	if node, found := p.nodes[hostnameKey(identifier)]; found {
+		return &node, nil
+	}
+	if node, found := p.nodes[hostnameKey(validation.ShortHostname(identifier))]; found {
 		return &node, nil
 	}
 
+	// Legacy BSS host names encode the NID (e.g., "nid001234")
+	if nid, ok := validation.ParseLegacyNIDHostname(identifier); ok {
+		if node, found := p.nodes[strconv.Itoa(int(nid))]; found {
+			return &node, nil
+		}
+	}
+
 	return nil, fmt.Errorf("node not found for identifier: %s", identifier)
 }
 
+// macKey returns the index key for a MAC address, normalized across notations
+func macKey(mac string) string {
+	if normalized, err := validation.NormalizeMAC(mac); err == nil {
+		return normalized
+	}
+	return strings.ToLower(mac)
+}
+
+// ipKey returns the index key for an interface IP address
+func ipKey(ip string) string {
+	if parsed := net.ParseIP(strings.TrimSpace(ip)); parsed != nil {
+		return "ip:" + parsed.String()
+	}
+	return "ip:" + ip
+}
+
+// hostnameKey returns the index key for a hostname
+func hostnameKey(hostname string) string {
+	return "host:" + strings.ToLower(hostname)
+}
+
 // GetAllNodes returns all nodes from the YAML file
 func (p *YAMLNodeProvider) GetAllNodes(ctx context.Context) ([]YAMLNode, error) { //nolint:revive
 	// Reload if auto-reload is enabled
@@ -0,0 +1,307 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// AdmissionConfig holds boot storm admission control settings.
+// A node admitted to boot holds a lease for LeaseDuration, during which it
+// counts against every budget it belongs to. Nodes over budget are told to
+// sleep and ask again instead of all fetching kernels and initrds at once.
+type AdmissionConfig struct {
+	// Enabled turns on admission control
+	Enabled bool `yaml:"enabled"`
+
+	// GlobalLimit is the maximum number of nodes booting at once (0 means unlimited)
+	GlobalLimit int `yaml:"global_limit"`
+
+	// CabinetLimit is the maximum number of nodes booting at once per cabinet,
+	// derived from the xname prefix (e.g., "x1000") (0 means unlimited)
+	CabinetLimit int `yaml:"cabinet_limit"`
+
+	// GroupLimits is the maximum number of nodes booting at once per node group
+	GroupLimits map[string]int `yaml:"group_limits"`
+
+	// LeaseDuration is how long an admitted node counts as booting
+	LeaseDuration time.Duration `yaml:"lease_duration"`
+
+	// RetryDelay is the base delay before an over-budget node asks again
+	RetryDelay time.Duration `yaml:"retry_delay"`
+
+	// RetryJitter is the maximum random delay added to RetryDelay
+	RetryJitter time.Duration `yaml:"retry_jitter"`
+
+	// StaggerWindow spreads nodes' kernel and initrd downloads over this window
+	// using a deterministic per-node delay derived from the NID (0 disables)
+	StaggerWindow time.Duration `yaml:"stagger_window"`
+}
+
+// DefaultAdmissionConfig returns the default admission configuration (disabled)
+func DefaultAdmissionConfig() AdmissionConfig {
+	return AdmissionConfig{
+		Enabled:       false,
+		LeaseDuration: 2 * time.Minute,
+		RetryDelay:    30 * time.Second,
+		RetryJitter:   30 * time.Second,
+	}
+}
+
+// AdmissionStats provides admission control metrics
+type AdmissionStats struct {
+	Enabled  bool           `json:"enabled"`
+	Booting  int            `json:"booting"`
+	Cabinets map[string]int `json:"cabinets"`
+	Groups   map[string]int `json:"groups"`
+	Admitted uint64         `json:"admitted"`
+	Deferred uint64         `json:"deferred"`
+}
+
+// cabinetPattern extracts the cabinet from an xname
+var cabinetPattern = regexp.MustCompile(`^x\d+`)
+
+// admissionProfile holds the budget memberships of a node
+type admissionProfile struct {
+	cabinet string
+	groups  []string
+}
+
+// admissionLease records a node that was admitted to boot
+type admissionLease struct {
+	profile   admissionProfile
+	expiresAt time.Time
+}
+
+// leaseExpiry queues a lease for expiry. Leases share one duration, so the
+// queue is ordered by expiry time.
+type leaseExpiry struct {
+	nodeKey   string
+	expiresAt time.Time
+}
+
+// admissionController tracks booting nodes against the configured budgets.
+// Active leases are counted per budget as they are granted and expire, so
+// admission does not scan every lease.
+type admissionController struct {
+	mu       sync.Mutex
+	config   AdmissionConfig
+	profiles map[string]admissionProfile // node cache key -> budget memberships
+	leases   map[string]admissionLease   // node cache key -> active lease
+	expiries []leaseExpiry               // active leases in expiry order
+	cabinets map[string]int              // cabinet -> active leases
+	groups   map[string]int              // group -> active leases
+	admitted uint64
+	deferred uint64
+}
+
+// newAdmissionController creates an admission controller, filling in unset durations
+func newAdmissionController(config AdmissionConfig) *admissionController {
+	defaults := DefaultAdmissionConfig()
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = defaults.LeaseDuration
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+
+	return &admissionController{
+		config:   config,
+		profiles: make(map[string]admissionProfile),
+		leases:   make(map[string]admissionLease),
+		cabinets: make(map[string]int),
+		groups:   make(map[string]int),
+	}
+}
+
+// remember records the budget memberships of a resolved node
+func (a *admissionController) remember(nodeKey string, n *node.Node) {
+	if !a.config.Enabled {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.profiles[nodeKey] = admissionProfile{
+		cabinet: cabinetPattern.FindString(n.Spec.XName),
+		groups:  n.Spec.Groups,
+	}
+}
+
+// admit decides whether a node may boot now. A node that already holds a
+// lease is always admitted so retries and chained requests are not deferred.
+// When the node is over budget, the exceeded budget is returned.
+func (a *admissionController) admit(nodeKey string) (bool, string) {
+	if !a.config.Enabled {
+		return true, ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	a.expire(now)
+
+	if _, booting := a.leases[nodeKey]; booting {
+		return true, ""
+	}
+
+	profile := a.profiles[nodeKey]
+	if budget := a.exceededBudget(profile); budget != "" {
+		a.deferred++
+		return false, budget
+	}
+
+	a.acquire(nodeKey, profile, now.Add(a.config.LeaseDuration))
+	a.admitted++
+	return true, ""
+}
+
+// acquire grants a lease and counts it against the node's budgets; callers must hold a.mu
+func (a *admissionController) acquire(nodeKey string, profile admissionProfile, expiresAt time.Time) {
+	a.leases[nodeKey] = admissionLease{profile: profile, expiresAt: expiresAt}
+	a.expiries = append(a.expiries, leaseExpiry{nodeKey: nodeKey, expiresAt: expiresAt})
+	if profile.cabinet != "" {
+		a.cabinets[profile.cabinet]++
+	}
+	for _, group := range profile.groups {
+		a.groups[group]++
+	}
+}
+
+// release ends a lease and removes it from the node's budgets; callers must hold a.mu
+func (a *admissionController) release(nodeKey string) {
+	lease, exists := a.leases[nodeKey]
+	if !exists {
+		return
+	}
+	delete(a.leases, nodeKey)
+	if lease.profile.cabinet != "" {
+		decrement(a.cabinets, lease.profile.cabinet)
+	}
+	for _, group := range lease.profile.groups {
+		decrement(a.groups, group)
+	}
+}
+
+// expire releases the leases that expired by now; callers must hold a.mu
+func (a *admissionController) expire(now time.Time) {
+	for len(a.expiries) > 0 && now.After(a.expiries[0].expiresAt) {
+		expiry := a.expiries[0]
+		a.expiries = a.expiries[1:]
+		if lease, exists := a.leases[expiry.nodeKey]; exists && lease.expiresAt.Equal(expiry.expiresAt) {
+			a.release(expiry.nodeKey)
+		}
+	}
+}
+
+// decrement lowers a budget count, dropping budgets with no active leases
+func decrement(counts map[string]int, key string) {
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
+
+// exceededBudget returns the first budget the node would exceed; callers must hold a.mu
+func (a *admissionController) exceededBudget(profile admissionProfile) string {
+	if a.config.GlobalLimit > 0 && len(a.leases) >= a.config.GlobalLimit {
+		return "global"
+	}
+
+	if a.config.CabinetLimit > 0 && profile.cabinet != "" && a.cabinets[profile.cabinet] >= a.config.CabinetLimit {
+		return "cabinet " + profile.cabinet
+	}
+
+	for _, group := range profile.groups {
+		if limit, exists := a.config.GroupLimits[group]; exists && limit > 0 && a.groups[group] >= limit {
+			return "group " + group
+		}
+	}
+
+	return ""
+}
+
+// stats returns admission control metrics
+func (a *admissionController) stats() AdmissionStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expire(time.Now())
+
+	return AdmissionStats{
+		Enabled:  a.config.Enabled,
+		Booting:  len(a.leases),
+		Cabinets: maps.Clone(a.cabinets),
+		Groups:   maps.Clone(a.groups),
+		Admitted: a.admitted,
+		Deferred: a.deferred,
+	}
+}
+
+// retryDelay returns the jittered delay before a deferred node asks again
+func (a *admissionController) retryDelay() time.Duration {
+	delay := a.config.RetryDelay
+	if a.config.RetryJitter > 0 {
+		delay += rand.N(a.config.RetryJitter) //nolint:gosec
+	}
+	return delay
+}
+
+// admitScript returns script if the node is within budget, or a deferral script otherwise
+func (c *BootScriptController) admitScript(identifier NodeIdentifier, nodeKey, script string) string {
+	admitted, budget := c.admission.admit(nodeKey)
+	if admitted {
+		return script
+	}
+
+	c.logger.Printf("Deferring boot of node %s: %s budget exhausted", nodeKey, budget)
+	return c.generateDeferScript(identifier, nodeKey, budget)
+}
+
+// generateDeferScript creates an iPXE script that waits and requests the boot script again
+func (c *BootScriptController) generateDeferScript(identifier NodeIdentifier, nodeKey, budget string) string {
+	// Relative URLs resolve against the current script's URI
+	param := "host"
+	switch identifier.Type {
+	case IdentifierMAC:
+		param = "mac"
+	case IdentifierNID:
+		param = "nid"
+	}
+	chainURL := fmt.Sprintf("bootscript?%s=%s", param, url.QueryEscape(identifier.Value))
+
+	delay := int(c.admission.retryDelay().Round(time.Second) / time.Second)
+
+	// Use a simple string replacement for the defer template
+	script := DeferIPXETemplate
+	script = strings.ReplaceAll(script, "{{.Identifier}}", nodeKey)
+	script = strings.ReplaceAll(script, "{{.Budget}}", budget)
+	script = strings.ReplaceAll(script, "{{.Delay}}", strconv.Itoa(delay))
+	script = strings.ReplaceAll(script, "{{.ChainURL}}", chainURL)
+
+	return script
+}
+
+// staggerDelay returns a deterministic delay in [0, window) seconds for a NID,
+// spreading consecutive NIDs evenly across the window
+func staggerDelay(nid int32, window int) int {
+	if window <= 0 || nid < 0 {
+		return 0
+	}
+	return int(nid) % window
+}
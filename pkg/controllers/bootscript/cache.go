@@ -5,10 +5,38 @@
 package bootscript
 
 import (
+	"container/list"
+	"context"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/openchami/boot-service/pkg/resources/node"
 )
 
+// CacheConfig holds configuration for the boot script cache
+type CacheConfig struct {
+	// TTL is how long a generated script stays valid
+	TTL time.Duration `yaml:"ttl"`
+
+	// MaxEntries bounds the number of cached scripts; the least recently used
+	// entry is evicted when the cache is full (0 means unbounded)
+	MaxEntries int `yaml:"max_entries"`
+
+	// RenderTimeout bounds the resolution and rendering shared by concurrent
+	// requests for the same node, which outlives any single caller
+	RenderTimeout time.Duration `yaml:"render_timeout"`
+}
+
+// DefaultCacheConfig returns the default cache configuration
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		TTL:           5 * time.Minute,
+		MaxEntries:    10000,
+		RenderTimeout: 30 * time.Second,
+	}
+}
+
 // CacheEntry represents a cached boot script
 type CacheEntry struct {
 	Script      string
@@ -16,48 +44,156 @@ type CacheEntry struct {
 	ExpiresAt   time.Time
 	NodeID      string
 	ConfigID    string
+
+	key string
 }
 
-// ScriptCache manages caching of generated boot scripts
+// ScriptCache manages caching of generated boot scripts.
+// Entries are kept in least-recently-used order so that a full cache evicts
+// the coldest node first. Expired entries are dropped lazily on access and
+// by a cleanup worker that runs between Start and Stop.
 type ScriptCache struct {
-	mu      sync.RWMutex
-	entries map[string]*CacheEntry
-	ttl     time.Duration
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List                     // front is most recently used
+	aliases    map[string]cacheAlias          // raw identifier -> canonical node cache key
+	keyAliases map[string]map[string]struct{} // canonical node cache key -> raw identifiers
+	ttl        time.Duration
+	maxEntries int
+
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// cacheAlias is the node cache key an identifier resolved to and when
+type cacheAlias struct {
+	key   string
+	added time.Time
 }
 
 // NewScriptCache creates a new script cache with the specified TTL
 func NewScriptCache(ttl time.Duration) *ScriptCache {
-	cache := &ScriptCache{
-		entries: make(map[string]*CacheEntry),
-		ttl:     ttl,
+	config := DefaultCacheConfig()
+	config.TTL = ttl
+	return NewScriptCacheWithConfig(config)
+}
+
+// NewScriptCacheWithConfig creates a new script cache with the given configuration.
+// Call Start to run periodic cleanup of expired entries.
+func NewScriptCacheWithConfig(config CacheConfig) *ScriptCache {
+	if config.TTL <= 0 {
+		config.TTL = DefaultCacheConfig().TTL
+	}
+
+	return &ScriptCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		aliases:    make(map[string]cacheAlias),
+		keyAliases: make(map[string]map[string]struct{}),
+		ttl:        config.TTL,
+		maxEntries: config.MaxEntries,
+	}
+}
+
+// Start runs the cleanup worker until ctx is done or Stop is called.
+// Calling Start on a running cache has no effect.
+func (c *ScriptCache) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return
 	}
 
-	// Start cleanup routine
-	go cache.cleanup()
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.cleanup(ctx, c.done)
+}
 
-	return cache
+// Stop stops the cleanup worker and waits for it to exit
+func (c *ScriptCache) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
 }
 
 // Get retrieves a cached script if it exists and is not expired
 func (c *ScriptCache) Get(cacheKey string) (string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	entry, exists := c.entries[cacheKey]
+	return c.get(cacheKey)
+}
+
+// get looks up an entry and records the hit or miss; callers must hold c.mu
+func (c *ScriptCache) get(cacheKey string) (string, bool) {
+	elem, exists := c.entries[cacheKey]
 	if !exists {
+		c.misses++
 		return "", false
 	}
 
 	// Check if entry has expired
+	entry := elem.Value.(*CacheEntry)
 	if time.Now().After(entry.ExpiresAt) {
 		// Entry expired, remove it
-		delete(c.entries, cacheKey)
+		c.removeElement(elem)
+		c.expirations++
+		c.misses++
 		return "", false
 	}
 
+	c.lru.MoveToFront(elem)
+	c.hits++
 	return entry.Script, true
 }
 
+// GetByAlias retrieves the cached script for the node an identifier last
+// resolved to, along with that node's cache key
+func (c *ScriptCache) GetByAlias(identifier string) (string, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// An unknown alias is not counted as a miss; the caller resolves the node
+	// and looks it up by its cache key, which records the outcome
+	alias, exists := c.aliases[identifier]
+	if !exists {
+		return "", "", false
+	}
+	script, found := c.get(alias.key)
+	return script, alias.key, found
+}
+
+// SetAlias records that an identifier (xname, MAC, NID, ...) resolves to the
+// node cached under cacheKey, so every identifier of a node shares one entry.
+// Aliases are dropped with their node's entry when it is evicted, and by
+// cleanup once their node has had no entry for a TTL.
+func (c *ScriptCache) SetAlias(identifier, cacheKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if previous, exists := c.aliases[identifier]; exists && previous.key != cacheKey {
+		c.unlinkAlias(identifier, previous.key)
+	}
+	c.aliases[identifier] = cacheAlias{key: cacheKey, added: time.Now()}
+	if c.keyAliases[cacheKey] == nil {
+		c.keyAliases[cacheKey] = make(map[string]struct{})
+	}
+	c.keyAliases[cacheKey][identifier] = struct{}{}
+}
+
 // Set stores a script in the cache
 func (c *ScriptCache) Set(cacheKey, script, nodeID, configID string) {
 	c.mu.Lock()
@@ -70,9 +206,24 @@ func (c *ScriptCache) Set(cacheKey, script, nodeID, configID string) {
 		ExpiresAt:   now.Add(c.ttl),
 		NodeID:      nodeID,
 		ConfigID:    configID,
+		key:         cacheKey,
+	}
+
+	if elem, exists := c.entries[cacheKey]; exists {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
 	}
 
-	c.entries[cacheKey] = entry
+	c.entries[cacheKey] = c.lru.PushFront(entry)
+
+	// Evict least recently used entries, and the aliases of their nodes, once over capacity
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		evicted := c.lru.Back().Value.(*CacheEntry).key
+		c.removeElement(c.lru.Back())
+		c.dropAliases(evicted)
+		c.evictions++
+	}
 }
 
 // Invalidate removes a specific entry from the cache
@@ -80,31 +231,34 @@ func (c *ScriptCache) Invalidate(cacheKey string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.entries, cacheKey)
+	if elem, exists := c.entries[cacheKey]; exists {
+		c.removeElement(elem)
+	}
 }
 
-// InvalidateByNodeID removes all cache entries for a specific node
-func (c *ScriptCache) InvalidateByNodeID(nodeID string) {
+// InvalidateByNodeID removes all cache entries for a specific node and returns how many were removed.
+// The node may be given by its cache key or by any identifier it was requested with.
+func (c *ScriptCache) InvalidateByNodeID(nodeID string) int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for key, entry := range c.entries {
-		if entry.NodeID == nodeID {
-			delete(c.entries, key)
-		}
+	if alias, exists := c.aliases[nodeID]; exists {
+		nodeID = alias.key
 	}
+
+	return c.removeWhere(func(entry *CacheEntry) bool {
+		return entry.NodeID == nodeID
+	})
 }
 
-// InvalidateByConfigID removes all cache entries using a specific configuration
-func (c *ScriptCache) InvalidateByConfigID(configID string) {
+// InvalidateByConfigID removes all cache entries using a specific configuration and returns how many were removed
+func (c *ScriptCache) InvalidateByConfigID(configID string) int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for key, entry := range c.entries {
-		if entry.ConfigID == configID {
-			delete(c.entries, key)
-		}
-	}
+	return c.removeWhere(func(entry *CacheEntry) bool {
+		return entry.ConfigID == configID
+	})
 }
 
 // Clear removes all entries from the cache
@@ -112,19 +266,22 @@ func (c *ScriptCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries = make(map[string]*CacheEntry)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.aliases = make(map[string]cacheAlias)
+	c.keyAliases = make(map[string]map[string]struct{})
 }
 
 // Stats returns cache statistics
 func (c *ScriptCache) Stats() CacheStats {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	now := time.Now()
 	expired := 0
 
-	for _, entry := range c.entries {
-		if now.After(entry.ExpiresAt) {
+	for _, elem := range c.entries {
+		if now.After(elem.Value.(*CacheEntry).ExpiresAt) {
 			expired++
 		}
 	}
@@ -133,23 +290,48 @@ func (c *ScriptCache) Stats() CacheStats {
 		TotalEntries:   len(c.entries),
 		ExpiredEntries: expired,
 		ValidEntries:   len(c.entries) - expired,
+		Aliases:        len(c.aliases),
+		MaxEntries:     c.maxEntries,
+		TTL:            c.ttl.String(),
+		Hits:           c.hits,
+		Misses:         c.misses,
+		Evictions:      c.evictions,
+		Expirations:    c.expirations,
 	}
 }
 
 // CacheStats provides cache performance metrics
 type CacheStats struct {
-	TotalEntries   int
-	ExpiredEntries int
-	ValidEntries   int
+	TotalEntries   int    `json:"totalEntries"`
+	ExpiredEntries int    `json:"expiredEntries"`
+	ValidEntries   int    `json:"validEntries"`
+	Aliases        int    `json:"aliases"`
+	MaxEntries     int    `json:"maxEntries"`
+	TTL            string `json:"ttl"`
+	Hits           uint64 `json:"hits"`
+	Misses         uint64 `json:"misses"`
+	Evictions      uint64 `json:"evictions"`
+	Expirations    uint64 `json:"expirations"`
 }
 
-// cleanup periodically removes expired entries
-func (c *ScriptCache) cleanup() {
-	ticker := time.NewTicker(c.ttl / 2) // Clean up twice per TTL period
+// cleanup periodically removes expired entries until ctx is done
+func (c *ScriptCache) cleanup(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	interval := c.ttl / 2 // Clean up twice per TTL period
+	if interval <= 0 {
+		interval = c.ttl
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.cleanupExpired()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.cleanupExpired()
+		}
 	}
 }
 
@@ -159,17 +341,67 @@ func (c *ScriptCache) cleanupExpired() {
 	defer c.mu.Unlock()
 
 	now := time.Now()
-	for key, entry := range c.entries {
-		if now.After(entry.ExpiresAt) {
-			delete(c.entries, key)
+	c.expirations += uint64(c.removeWhere(func(entry *CacheEntry) bool {
+		return now.After(entry.ExpiresAt)
+	}))
+
+	c.pruneAliases(now.Add(-c.ttl))
+}
+
+// removeWhere removes entries matching the predicate; callers must hold c.mu
+func (c *ScriptCache) removeWhere(match func(*CacheEntry) bool) int {
+	removed := 0
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if match(elem.Value.(*CacheEntry)) {
+			c.removeElement(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// removeElement unlinks an entry; callers must hold c.mu
+func (c *ScriptCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*CacheEntry).key)
+}
+
+// pruneAliases drops aliases added before a time whose node has no entry.
+// Newer aliases are kept, as their node's script may still be rendering.
+// Callers must hold c.mu.
+func (c *ScriptCache) pruneAliases(before time.Time) {
+	for identifier, alias := range c.aliases {
+		if _, exists := c.entries[alias.key]; !exists && alias.added.Before(before) {
+			c.unlinkAlias(identifier, alias.key)
 		}
 	}
 }
 
-// generateCacheKey creates a cache key from node identifier and configuration
-func (c *BootScriptController) generateCacheKey(identifier string, configName string) string {
-	if configName == "" {
-		configName = "default"
+// dropAliases drops every alias of a node; callers must hold c.mu
+func (c *ScriptCache) dropAliases(cacheKey string) {
+	for identifier := range c.keyAliases[cacheKey] {
+		delete(c.aliases, identifier)
+	}
+	delete(c.keyAliases, cacheKey)
+}
+
+// unlinkAlias drops one alias of a node; callers must hold c.mu
+func (c *ScriptCache) unlinkAlias(identifier, cacheKey string) {
+	delete(c.aliases, identifier)
+	delete(c.keyAliases[cacheKey], identifier)
+	if len(c.keyAliases[cacheKey]) == 0 {
+		delete(c.keyAliases, cacheKey)
+	}
+}
+
+// generateCacheKey creates a cache key for a resolved node.
+// Keying by the node rather than the requested identifier lets the xname,
+// MAC and NID of a node share a single cache entry.
+func (c *BootScriptController) generateCacheKey(node *node.Node) string {
+	if node.Spec.XName != "" {
+		return node.Spec.XName
 	}
-	return identifier + ":" + configName
+	return "mac:" + strings.ToLower(node.Spec.BootMAC)
 }
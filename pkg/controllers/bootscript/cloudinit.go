@@ -0,0 +1,355 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+	"gopkg.in/yaml.v3"
+)
+
+// CloudInitConfig controls the NoCloud datasource served at /cloud-init
+type CloudInitConfig struct {
+	// BaseURL is the absolute URL of the boot service as seen by booting nodes
+	// (e.g., "http://boot.example.com:8080"). When set, rendered kernel params
+	// point cloud-init at the node's datasource with "ds=nocloud-net;s=...".
+	BaseURL string `yaml:"base_url"`
+}
+
+// DefaultCloudInitConfig returns the default cloud-init configuration (no kernel param injection)
+func DefaultCloudInitConfig() CloudInitConfig {
+	return CloudInitConfig{}
+}
+
+// ErrNodeNotFound is returned when a node cannot be resolved from its identifier
+var ErrNodeNotFound = errors.New("node not found")
+
+// cloudConfigHeader starts cloud-config user-data and vendor-data documents
+const cloudConfigHeader = "#cloud-config"
+
+// NodeCloudInit holds the rendered NoCloud documents for a node.
+// VendorData and NetworkConfig are nil when no configuration provides them.
+type NodeCloudInit struct {
+	Node          *node.Node
+	MetaData      []byte
+	UserData      []byte
+	VendorData    []byte
+	NetworkConfig []byte
+}
+
+// nodeFacts are the node facts available to cloud-init and Ignition templates
+type nodeFacts struct {
+	XName      string
+	NID        int32
+	Hostname   string
+	BootMAC    string
+	IP         string // First interface IP address
+	Role       string
+	SubRole    string
+	Groups     []string
+	Interfaces []node.Interface
+}
+
+// RenderCloudInit renders the NoCloud documents for a node. Cloud-init data from
+// every boot configuration matching the node is merged, least specific first,
+// so node-level settings override group-level ones. String values of sources
+// marked as templates are executed over the node's facts (e.g., "{{.XName}}").
+func (c *BootScriptController) RenderCloudInit(ctx context.Context, identifier string) (*NodeCloudInit, error) {
+	n, err := c.resolveRequester(ctx, identifier, EndpointCloudInit)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := c.cloudInitSources(ctx, n, c.stateDecision(n))
+	if err != nil {
+		return nil, err
+	}
+
+	facts := newNodeFacts(n)
+	metaData := map[string]interface{}{
+		"instance-id":    nodeIdentifierOf(n),
+		"local-hostname": facts.Hostname,
+	}
+
+	var userData, vendorData, networkConfig interface{}
+	for _, source := range sources {
+		documents := []struct {
+			name   string
+			raw    json.RawMessage
+			target *interface{}
+		}{
+			{"user-data", source.UserData, &userData},
+			{"vendor-data", source.VendorData, &vendorData},
+			{"network-config", source.NetworkData, &networkConfig},
+		}
+		for _, document := range documents {
+			value, err := decodeCloudInitSource(source, document.raw, facts)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s: %w", document.name, err)
+			}
+			*document.target = mergeCloudInit(*document.target, value)
+		}
+
+		value, err := decodeCloudInitSource(source, source.MetaData, facts)
+		if err != nil {
+			return nil, fmt.Errorf("decoding meta-data: %w", err)
+		}
+		if merged, ok := mergeCloudInit(metaData, value).(map[string]interface{}); ok {
+			metaData = merged
+		}
+
+		// Report to the phone home URL unless user-data already configures it
+		if source.PhoneHomeURL != "" {
+			if config, ok := userData.(map[string]interface{}); ok || userData == nil {
+				if config == nil {
+					config = make(map[string]interface{})
+				}
+				if _, exists := config["phone_home"]; !exists {
+					config["phone_home"] = map[string]interface{}{"url": source.PhoneHomeURL, "post": "all"}
+				}
+				userData = config
+			}
+		}
+	}
+
+	result := &NodeCloudInit{Node: n}
+	if result.MetaData, err = renderCloudInitDocument(metaData, ""); err != nil {
+		return nil, fmt.Errorf("rendering meta-data: %w", err)
+	}
+	if result.UserData, err = renderCloudInitDocument(userData, cloudConfigHeader); err != nil {
+		return nil, fmt.Errorf("rendering user-data: %w", err)
+	}
+	if result.UserData == nil {
+		result.UserData = []byte(cloudConfigHeader + "\n")
+	}
+	if result.VendorData, err = renderCloudInitDocument(vendorData, cloudConfigHeader); err != nil {
+		return nil, fmt.Errorf("rendering vendor-data: %w", err)
+	}
+	if result.NetworkConfig, err = renderCloudInitDocument(networkConfig, ""); err != nil {
+		return nil, fmt.Errorf("rendering network-config: %w", err)
+	}
+
+	return result, nil
+}
+
+// cloudInitSources returns the cloud-init data of the configurations matching a
+// node, ordered from least to most specific. A node the state policy boots into
+// a named configuration gets only its data, and a halted node gets none.
+func (c *BootScriptController) cloudInitSources(ctx context.Context, n *node.Node, decision StateDecision) ([]*bootconfiguration.CloudInit, error) {
+	// The state policy decides which configuration, if any, the node boots
+	switch decision.Action {
+	case StateActionHalt, StateActionLocal, StateActionConfig:
+		config, err := c.nodeBootConfiguration(ctx, n, decision)
+		if err != nil {
+			return nil, err
+		}
+		if config.Spec.CloudInit.IsEmpty() {
+			return nil, nil
+		}
+		return []*bootconfiguration.CloudInit{config.Spec.CloudInit}, nil
+	}
+
+	configs, err := c.client.GetBootConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting boot configurations: %w", err)
+	}
+
+	type candidate struct {
+		cloudInit *bootconfiguration.CloudInit
+		score     int
+		priority  int
+	}
+
+	var candidates []candidate
+	for i := range configs {
+		if configs[i].Spec.CloudInit.IsEmpty() {
+			continue
+		}
+		if score := c.calculateConfigScore(&configs[i], n); score > 0 {
+			candidates = append(candidates, candidate{configs[i].Spec.CloudInit, score, configs[i].Spec.Priority})
+		}
+	}
+
+	// Sort by score (ascending) and priority (ascending) so later sources win
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].priority < candidates[j].priority
+	})
+
+	sources := make([]*bootconfiguration.CloudInit, len(candidates))
+	for i, candidate := range candidates {
+		sources[i] = candidate.cloudInit
+	}
+	return sources, nil
+}
+
+// newNodeFacts collects the template facts for a node
+func newNodeFacts(n *node.Node) nodeFacts {
+	facts := nodeFacts{
+		XName:      n.Spec.XName,
+		NID:        n.Spec.NID,
+		Hostname:   n.Spec.Hostname,
+		BootMAC:    n.Spec.BootMAC,
+		Role:       n.Spec.Role,
+		SubRole:    n.Spec.SubRole,
+		Groups:     n.Spec.Groups,
+		Interfaces: n.Spec.Interfaces,
+	}
+	if facts.Hostname == "" {
+		facts.Hostname = nodeIdentifierOf(n)
+	}
+	for _, iface := range n.Spec.Interfaces {
+		if iface.IP != "" {
+			facts.IP = iface.IP
+			break
+		}
+	}
+	return facts
+}
+
+// decodeCloudInitDocument decodes a stored document. Cloud-config strings are
+// parsed so they can be merged with documents stored as objects.
+func decodeCloudInitDocument(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	if text, ok := value.(string); ok && strings.HasPrefix(text, cloudConfigHeader) {
+		var config map[string]interface{}
+		if err := yaml.Unmarshal([]byte(text), &config); err == nil {
+			if config == nil {
+				config = make(map[string]interface{})
+			}
+			return config, nil
+		}
+	}
+	return value, nil
+}
+
+// decodeCloudInitSource decodes a document of a source, executing its
+// templates when the source is marked as a template
+func decodeCloudInitSource(source *bootconfiguration.CloudInit, raw json.RawMessage, facts nodeFacts) (interface{}, error) {
+	value, err := decodeCloudInitDocument(raw)
+	if err != nil || !source.Template {
+		return value, err
+	}
+	return templateCloudInit(value, facts)
+}
+
+// mergeCloudInit merges override into base. Objects are merged key by key;
+// anything else in override (lists, strings, scripts) replaces base.
+func mergeCloudInit(base, override interface{}) interface{} {
+	if override == nil {
+		return base
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overrideMap, overrideIsMap := override.(map[string]interface{})
+	if !baseIsMap || !overrideIsMap {
+		return override
+	}
+
+	merged := make(map[string]interface{}, len(baseMap)+len(overrideMap))
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+	for key, value := range overrideMap {
+		merged[key] = mergeCloudInit(merged[key], value)
+	}
+	return merged
+}
+
+// renderCloudInitDocument serializes a merged document as YAML. Objects are
+// prefixed with header, if any; strings are served as written.
+func renderCloudInitDocument(value interface{}, header string) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if text, ok := value.(string); ok {
+		return []byte(text), nil
+	}
+
+	body, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if header != "" {
+		body = append([]byte(header+"\n"), body...)
+	}
+	return body, nil
+}
+
+// templateCloudInit executes every string in a document as a template over the node's facts
+func templateCloudInit(value interface{}, facts nodeFacts) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		if !strings.Contains(typed, "{{") {
+			return typed, nil
+		}
+		tmpl, err := template.New("cloud-init").Option("missingkey=error").Parse(typed)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %q: %w", typed, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, facts); err != nil {
+			return nil, fmt.Errorf("executing template %q: %w", typed, err)
+		}
+		return buf.String(), nil
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			result, err := templateCloudInit(item, facts)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = result
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(typed))
+		for i, item := range typed {
+			result, err := templateCloudInit(item, facts)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = result
+		}
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
+
+// withCloudInitParam adds the NoCloud datasource kernel parameter for a node
+// unless the params already choose a datasource or no base URL is configured
+func (c *BootScriptController) withCloudInitParam(params string, n *node.Node) string {
+	baseURL := strings.TrimSuffix(c.config.CloudInit.BaseURL, "/")
+	if baseURL == "" || strings.Contains(params, "ds=") {
+		return params
+	}
+
+	param := fmt.Sprintf("ds=nocloud-net;s=%s/cloud-init/%s/", baseURL, nodeIdentifierOf(n))
+	if params == "" {
+		return param
+	}
+	return params + " " + param
+}
@@ -0,0 +1,56 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import "sync"
+
+// flight is an in-progress or completed call shared by concurrent callers
+type flight struct {
+	wg     sync.WaitGroup
+	script string
+	err    error
+	dups   int
+}
+
+// flightGroup coalesces concurrent calls with the same key so that only one
+// of them does the work while the others wait for and share its result.
+// This keeps a rack power-on from resolving and rendering the same node
+// hundreds of times before the first result reaches the cache.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// Do runs fn once for all concurrent callers using the same key.
+// The shared return value reports whether the result came from another caller's call.
+func (g *flightGroup) Do(key string, fn func() (string, error)) (script string, err error, shared bool) { //nolint:revive
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		f.dups++
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.script, f.err, true
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	// Release waiters even if fn panics
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		shared = f.dups > 0
+		g.mu.Unlock()
+		f.wg.Done()
+	}()
+
+	f.script, f.err = fn()
+	return f.script, f.err, false
+}
@@ -7,8 +7,10 @@ package bootscript
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,20 +24,99 @@ import (
 
 // BootScriptController handles iPXE boot script generation
 type BootScriptController struct { //nolint:revive
-	client client.Client
-	logger *log.Logger
-	cache  *ScriptCache
+	client       client.Client
+	logger       *log.Logger
+	cache        *ScriptCache
+	flights      flightGroup // Coalesces concurrent requests for the same node
+	admission    *admissionController
+	spoofCheck   *spoofChecker
+	history      *requestHistory
+	config       ControllerConfig
+	nodeProvider NodeProvider // Optional - consulted when a node is not found locally
+}
+
+// ControllerConfig holds optional behavior settings for the boot script controller
+type ControllerConfig struct {
+	Admission   AdmissionConfig   `yaml:"admission"`
+	Cache       CacheConfig       `yaml:"cache"`
+	CloudInit   CloudInitConfig   `yaml:"cloud_init"`
+	Discovery   DiscoveryConfig   `yaml:"discovery"`
+	Ignition    IgnitionConfig    `yaml:"ignition"`
+	SpoofCheck  SpoofCheckConfig  `yaml:"spoof_check"`
+	StatePolicy StatePolicyConfig `yaml:"state_policy"`
+}
+
+// DefaultControllerConfig returns the default controller configuration
+func DefaultControllerConfig() ControllerConfig {
+	return ControllerConfig{
+		Admission:   DefaultAdmissionConfig(),
+		Cache:       DefaultCacheConfig(),
+		CloudInit:   DefaultCloudInitConfig(),
+		Discovery:   DefaultDiscoveryConfig(),
+		Ignition:    DefaultIgnitionConfig(),
+		SpoofCheck:  DefaultSpoofCheckConfig(),
+		StatePolicy: DefaultStatePolicyConfig(),
+	}
 }
 
 // NewBootScriptController creates a new controller instance
 func NewBootScriptController(client client.Client, logger *log.Logger) *BootScriptController {
+	return NewBootScriptControllerWithConfig(client, DefaultControllerConfig(), logger)
+}
+
+// NewBootScriptControllerWithConfig creates a new controller instance with the given configuration
+func NewBootScriptControllerWithConfig(client client.Client, config ControllerConfig, logger *log.Logger) *BootScriptController {
 	return &BootScriptController{
-		client: client,
-		logger: logger,
-		cache:  NewScriptCache(5 * time.Minute), // 5 minute cache
+		client:     client,
+		logger:     logger,
+		cache:      NewScriptCacheWithConfig(config.Cache),
+		admission:  newAdmissionController(config.Admission),
+		spoofCheck: newSpoofChecker(config.SpoofCheck, logger),
+		history:    newRequestHistory(),
+		config:     config,
 	}
 }
 
+// Start runs the controller's background workers until ctx is done
+func (c *BootScriptController) Start(ctx context.Context) {
+	c.cache.Start(ctx)
+}
+
+// Stop stops the controller's background workers
+func (c *BootScriptController) Stop() {
+	c.cache.Stop()
+}
+
+// CacheStats returns boot script cache statistics
+func (c *BootScriptController) CacheStats() CacheStats {
+	return c.cache.Stats()
+}
+
+// AdmissionStats returns boot storm admission control statistics
+func (c *BootScriptController) AdmissionStats() AdmissionStats {
+	return c.admission.stats()
+}
+
+// SpoofCheckStats returns requester verification statistics
+func (c *BootScriptController) SpoofCheckStats() SpoofCheckStats {
+	return c.spoofCheck.statsSnapshot()
+}
+
+// InvalidateNode drops cached scripts for a node, given by xname or any identifier it booted with
+func (c *BootScriptController) InvalidateNode(identifier string) int {
+	return c.cache.InvalidateByNodeID(c.aliasKey(c.parseNodeIdentifier(identifier)))
+}
+
+// InvalidateConfig drops cached scripts rendered from the named boot configuration
+func (c *BootScriptController) InvalidateConfig(name string) int {
+	return c.cache.InvalidateByConfigID(name)
+}
+
+// ClearCache drops all cached scripts
+func (c *BootScriptController) ClearCache() {
+	c.cache.Clear()
+}
+
 // NodeIdentifier represents different ways to identify a node
 type NodeIdentifier struct {
 	Value string
@@ -50,29 +131,135 @@ const (
 	IdentifierXName IdentifierType = iota
 	IdentifierNID
 	IdentifierMAC
+	IdentifierHostname
 	IdentifierUnknown
 )
 
-// GenerateBootScript generates an iPXE boot script for a node
+// GenerateBootScript generates an iPXE boot script for a node. When spoof
+// checking rejects the requester recorded with WithRequesterIP, the returned
+// error wraps ErrRequesterMismatch.
 func (c *BootScriptController) GenerateBootScript(ctx context.Context, identifier string) (string, error) {
 	c.logger.Printf("Generating boot script for identifier: %s", identifier)
 
-	// Check cache first
-	cacheKey := c.generateCacheKey(identifier, "")
-	if cached, found := c.cache.Get(cacheKey); found {
+	nodeID := c.parseNodeIdentifier(identifier)
+
+	// Check cache first, using the node this identifier last resolved to
+	alias := c.aliasKey(nodeID)
+	if cached, cacheKey, found := c.cache.GetByAlias(alias); found {
 		c.logger.Printf("Cache hit for identifier: %s", identifier)
-		return cached, nil
+		if err := c.verifyRequester(ctx, cacheKey); err != nil {
+			return "", err
+		}
+		c.recordAccess(cacheKey, EndpointBootScript)
+		return c.admitScript(nodeID, cacheKey, cached), nil
+	}
+
+	// Concurrent requests for the same identifier share one resolution. The
+	// shared work must not be cancelled because the first caller went away,
+	// so it is bounded by its own timeout instead.
+	script, err, shared := c.flights.Do(c.flightKey(ctx, alias), func() (string, error) {
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.renderTimeout())
+		defer cancel()
+		return c.resolveAndRender(sharedCtx, identifier, nodeID, alias)
+	})
+	if shared {
+		c.logger.Printf("Coalesced boot script request for identifier: %s", identifier)
 	}
 
+	return script, err
+}
+
+// renderTimeout returns the time limit of a shared resolution
+func (c *BootScriptController) renderTimeout() time.Duration {
+	if c.config.Cache.RenderTimeout > 0 {
+		return c.config.Cache.RenderTimeout
+	}
+	return DefaultCacheConfig().RenderTimeout
+}
+
+var (
+	errPendingDiscovery = errors.New("node is pending discovery approval")
+	errNodeRemoved      = errors.New("node was removed from the inventory")
+)
+
+// servable reports why a resolved node may not be given boot data. Every boot
+// endpoint applies it, so a node refused a boot script is refused its
+// cloud-init data and Ignition config too.
+func servable(n *node.Node) error {
+	switch {
+	case n.IsPendingDiscovery():
+		return errPendingDiscovery
+	case n.IsRemoved():
+		return errNodeRemoved
+	}
+	return nil
+}
+
+// resolveAndRender resolves a node and renders its boot script, coalescing
+// rendering per canonical node so the xname, MAC and NID of one node share the work
+func (c *BootScriptController) resolveAndRender(ctx context.Context, identifier string, nodeID NodeIdentifier, alias string) (string, error) {
 	// Parse and resolve node identifier
-	nodeID := c.parseNodeIdentifier(identifier)
 	node, err := c.resolveNode(ctx, nodeID)
 	if err != nil {
+		if c.discoveryEnabled() && nodeID.Type == IdentifierMAC {
+			c.logger.Printf("Unknown MAC %s, sending discovery script", identifier)
+			return c.generateDiscoveryScript(identifier), nil
+		}
 		return c.generateErrorScript(fmt.Sprintf("Node resolution failed: %v", err)), nil
 	}
 
-	// Find best matching configuration
-	config, err := c.findBootConfiguration(ctx, node)
+	// Pending and tombstoned nodes match no configuration; discovered nodes
+	// keep re-registering until an operator approves them
+	if err := servable(node); err != nil {
+		c.logger.Printf("Node %s: %v", identifier, err)
+		if errors.Is(err, errPendingDiscovery) && c.discoveryEnabled() {
+			return c.generateDiscoveryScript(node.Spec.BootMAC), nil
+		}
+		return c.generateMinimalScript(identifier), nil
+	}
+
+	// Nodes disabled or unhealthy in HSM may be kept from booting their configuration
+	decision := c.stateDecision(node)
+	if decision.Action != StateActionBoot {
+		c.logger.Printf("State policy for node %s: %s (%s)", node.Spec.XName, decision.Action, decision.Reason)
+	}
+	if decision.Action == StateActionHalt || decision.Action == StateActionLocal {
+		return c.generateStateScript(identifier, decision), nil
+	}
+
+	cacheKey := c.generateCacheKey(node)
+	c.cache.SetAlias(alias, cacheKey)
+	c.admission.remember(cacheKey, node)
+	c.spoofCheck.remember(cacheKey, node)
+
+	// Requesters must be the node they ask for before any budget is spent on them
+	if err := c.verifyRequester(ctx, cacheKey); err != nil {
+		return "", err
+	}
+	c.recordAccess(cacheKey, EndpointBootScript)
+
+	// Over-budget nodes are deferred before any rendering work is done
+	if admitted, budget := c.admission.admit(cacheKey); !admitted {
+		c.logger.Printf("Deferring boot of node %s: %s budget exhausted", cacheKey, budget)
+		return c.generateDeferScript(nodeID, cacheKey, budget), nil
+	}
+
+	// Another identifier of the same node may already have been rendered
+	if cached, found := c.cache.Get(cacheKey); found {
+		c.logger.Printf("Cache hit for node %s via identifier: %s", cacheKey, identifier)
+		return cached, nil
+	}
+
+	script, err, _ := c.flights.Do("node:"+cacheKey, func() (string, error) {
+		return c.renderNodeScript(ctx, identifier, node, cacheKey, decision)
+	})
+	return script, err
+}
+
+// renderNodeScript finds the configuration for a resolved node and renders and caches its script
+func (c *BootScriptController) renderNodeScript(ctx context.Context, identifier string, node *node.Node, cacheKey string, decision StateDecision) (string, error) {
+	// Find best matching configuration, or the one chosen by the state policy
+	config, err := c.nodeBootConfiguration(ctx, node, decision)
 	if err != nil {
 		c.logger.Printf("No configuration found for node %s: %v", node.Spec.XName, err)
 		// Return minimal script for nodes without configuration
@@ -85,18 +272,26 @@ func (c *BootScriptController) GenerateBootScript(ctx context.Context, identifie
 		return c.generateErrorScript(fmt.Sprintf("Script generation failed: %v", err)), nil
 	}
 
-	// Cache the result
+	// Cache the result under the canonical node key
 	configName := ""
 	if config != nil {
 		configName = config.GetName()
 	}
-	cacheKey = c.generateCacheKey(identifier, configName)
-	c.cache.Set(cacheKey, script, node.Spec.XName, configName)
+	c.cache.Set(cacheKey, script, cacheKey, configName)
 
 	c.logger.Printf("Generated boot script for node %s using config %s", node.Spec.XName, configName)
 	return script, nil
 }
 
+// aliasKey normalizes an identifier so that equivalent spellings share a cache alias.
+// MAC identifiers are already normalized by parseNodeIdentifier.
+func (c *BootScriptController) aliasKey(identifier NodeIdentifier) string {
+	if identifier.Type == IdentifierHostname {
+		return strings.ToLower(identifier.Value)
+	}
+	return identifier.Value
+}
+
 // parseNodeIdentifier determines what type of identifier we're dealing with
 func (c *BootScriptController) parseNodeIdentifier(identifier string) NodeIdentifier {
 	// Check if it's an XName (format: x<cabinet>c<chassis>s<slot>b<blade>n<node>)
@@ -109,16 +304,39 @@ func (c *BootScriptController) parseNodeIdentifier(identifier string) NodeIdenti
 		return NodeIdentifier{Value: identifier, Type: IdentifierNID}
 	}
 
-	// Check if it's a MAC address
-	if validation.ValidateMAC(identifier) {
-		return NodeIdentifier{Value: identifier, Type: IdentifierMAC}
+	// Check if it's a MAC address, in any common notation. This comes before
+	// hostnames, so a hostname of 12 hex digits (e.g., "deadbeefcafe") is
+	// looked up as a MAC address.
+	if mac, err := validation.NormalizeMAC(identifier); err == nil {
+		return NodeIdentifier{Value: mac, Type: IdentifierMAC}
+	}
+
+	// Check if it's a hostname (short name, FQDN, or legacy "nid001234")
+	if validation.ValidateHostname(identifier) {
+		return NodeIdentifier{Value: identifier, Type: IdentifierHostname}
 	}
 
 	return NodeIdentifier{Value: identifier, Type: IdentifierUnknown}
 }
 
-// resolveNode finds a node based on the identifier
+// resolveNode finds a node based on the identifier, consulting the node provider if one is configured
 func (c *BootScriptController) resolveNode(ctx context.Context, identifier NodeIdentifier) (*node.Node, error) {
+	nodeItem, err := c.resolveLocalNode(ctx, identifier)
+	if err == nil || c.nodeProvider == nil {
+		return nodeItem, err
+	}
+
+	c.logger.Printf("Local resolution failed for %s, trying node provider: %v", identifier.Value, err)
+	providerNode, providerErr := c.nodeProvider.ResolveNodeByIdentifier(ctx, identifier.Value)
+	if providerErr != nil {
+		return nil, fmt.Errorf("%w; provider: %v", err, providerErr)
+	}
+
+	return providerNode, nil
+}
+
+// resolveLocalNode finds a node in the boot service's own storage
+func (c *BootScriptController) resolveLocalNode(ctx context.Context, identifier NodeIdentifier) (*node.Node, error) {
 	// Get all nodes
 	nodes, err := c.client.GetNodes(ctx)
 	if err != nil {
@@ -138,15 +356,144 @@ func (c *BootScriptController) resolveNode(ctx context.Context, identifier NodeI
 				return &nodeItem, nil
 			}
 		case IdentifierMAC:
-			if strings.EqualFold(nodeItem.Spec.BootMAC, identifier.Value) {
+			if hasMAC(&nodeItem, identifier.Value) {
+				return &nodeItem, nil
+			}
+		case IdentifierHostname:
+			if matchesHostname(nodeItem.Spec.Hostname, identifier.Value) {
 				return &nodeItem, nil
 			}
 		}
 	}
 
+	// Legacy BSS host names encode the NID (e.g., "nid001234")
+	if identifier.Type == IdentifierHostname {
+		if nid, ok := validation.ParseLegacyNIDHostname(identifier.Value); ok {
+			for _, nodeItem := range nodes {
+				if nodeItem.Spec.NID == nid {
+					return &nodeItem, nil
+				}
+			}
+		}
+	}
+
 	return nil, fmt.Errorf("node not found for identifier %s", identifier.Value)
 }
 
+// ResolveNodeByIP identifies the node that owns an interface address, for boot
+// script requests that carry no identifier. It returns an identifier that
+// GenerateBootScript resolves to the same node (the xname, or the boot MAC of
+// nodes without one).
+func (c *BootScriptController) ResolveNodeByIP(ctx context.Context, ip string) (string, error) {
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	nodes, err := c.client.GetNodes(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting nodes: %w", err)
+	}
+
+	for i := range nodes {
+		if hasIP(&nodes[i], ip) {
+			identifier := nodeIdentifierOf(&nodes[i])
+			c.logger.Printf("Identified requester %s as node %s from boot service node interfaces", ip, identifier)
+			return identifier, nil
+		}
+	}
+
+	if c.nodeProvider != nil {
+		providerNode, providerErr := c.nodeProvider.ResolveNodeByIdentifier(ctx, ip)
+		if providerErr == nil {
+			identifier := nodeIdentifierOf(providerNode)
+			c.logger.Printf("Identified requester %s as node %s from node provider", ip, identifier)
+			return identifier, nil
+		}
+		return "", fmt.Errorf("no node has interface address %s; provider: %v", ip, providerErr)
+	}
+
+	return "", fmt.Errorf("no node has interface address %s", ip)
+}
+
+// ListNodes lists the nodes known to the boot service and its node provider.
+// Boot service nodes take precedence over provider nodes with the same identifier.
+func (c *BootScriptController) ListNodes(ctx context.Context) ([]node.Node, error) {
+	nodes, err := c.client.GetNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting nodes: %w", err)
+	}
+
+	lister, ok := c.nodeProvider.(NodeLister)
+	if !ok {
+		return nodes, nil
+	}
+	providerNodes, err := lister.ListNodes(ctx)
+	if err != nil {
+		c.logger.Printf("Warning: failed to list nodes from node provider: %v", err)
+		return nodes, nil
+	}
+
+	known := make(map[string]bool, len(nodes))
+	for i := range nodes {
+		known[nodeIdentifierOf(&nodes[i])] = true
+	}
+	for i := range providerNodes {
+		if !known[nodeIdentifierOf(&providerNodes[i])] {
+			nodes = append(nodes, providerNodes[i])
+		}
+	}
+	return nodes, nil
+}
+
+// nodeIdentifierOf returns the identifier a resolved node is best looked up by
+func nodeIdentifierOf(n *node.Node) string {
+	if n.Spec.XName != "" {
+		return n.Spec.XName
+	}
+	return n.Spec.BootMAC
+}
+
+// hasIP reports whether any of a node's interfaces has the given IP address
+func hasIP(n *node.Node, ip string) bool {
+	for _, iface := range n.Spec.Interfaces {
+		if validation.EqualIP(iface.IP, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMAC reports whether a node's boot MAC or any of its interfaces has the given MAC
+func hasMAC(n *node.Node, mac string) bool {
+	if validation.EqualMAC(n.Spec.BootMAC, mac) {
+		return true
+	}
+	for _, iface := range n.Spec.Interfaces {
+		if validation.EqualMAC(iface.MAC, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHostname reports whether a requested hostname refers to a node's hostname.
+// A short name matches an FQDN with the same first label and vice versa.
+func matchesHostname(nodeHostname, requested string) bool {
+	if nodeHostname == "" {
+		return false
+	}
+	if strings.EqualFold(nodeHostname, requested) {
+		return true
+	}
+	if !strings.Contains(nodeHostname, ".") || !strings.Contains(requested, ".") {
+		return strings.EqualFold(validation.ShortHostname(nodeHostname), validation.ShortHostname(requested))
+	}
+	return false
+}
+
+// errNoMatchingConfiguration is returned when no boot configuration matches a node
+var errNoMatchingConfiguration = errors.New("no matching configurations found")
+
 // findBootConfiguration finds the best matching configuration for a node
 func (c *BootScriptController) findBootConfiguration(ctx context.Context, node *node.Node) (*bootconfiguration.BootConfiguration, error) {
 	// Get all boot configurations
@@ -156,7 +503,7 @@ func (c *BootScriptController) findBootConfiguration(ctx context.Context, node *
 	}
 
 	if len(configs) == 0 {
-		return nil, fmt.Errorf("no boot configurations found")
+		return nil, fmt.Errorf("%w: no boot configurations found", errNoMatchingConfiguration)
 	}
 
 	// Score each configuration against the node
@@ -174,7 +521,7 @@ func (c *BootScriptController) findBootConfiguration(ctx context.Context, node *
 	}
 
 	if len(candidates) == 0 {
-		return nil, fmt.Errorf("no matching configurations found for node %s", node.Spec.XName)
+		return nil, fmt.Errorf("%w for node %s", errNoMatchingConfiguration, node.Spec.XName)
 	}
 
 	// Sort by score (descending) and priority (descending)
@@ -203,7 +550,7 @@ func (c *BootScriptController) calculateConfigScore(config *bootconfiguration.Bo
 
 	// MAC address matching
 	for _, mac := range config.Spec.MACs {
-		if strings.EqualFold(mac, node.Spec.BootMAC) {
+		if validation.EqualMAC(mac, node.Spec.BootMAC) {
 			score += 100 // Exact MAC match is highest priority
 		}
 	}
@@ -224,9 +571,16 @@ func (c *BootScriptController) calculateConfigScore(config *bootconfiguration.Bo
 		}
 	}
 
+	// Role matching
+	for _, role := range config.Spec.Roles {
+		if node.Spec.Role != "" && strings.EqualFold(role, node.Spec.Role) {
+			score += 10
+		}
+	}
+
 	// Base score for any configuration (fallback)
-	if score == 0 && len(config.Spec.Hosts) == 0 && len(config.Spec.MACs) == 0 &&
-		len(config.Spec.NIDs) == 0 && len(config.Spec.Groups) == 0 {
+	if score == 0 && (config.Spec.Default || (len(config.Spec.Hosts) == 0 && len(config.Spec.MACs) == 0 &&
+		len(config.Spec.NIDs) == 0 && len(config.Spec.Groups) == 0 && len(config.Spec.Roles) == 0)) {
 		score = 1 // Default/catch-all configuration
 	}
 
@@ -5,10 +5,20 @@
 package bootscript
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/openchami/boot-service/pkg/client"
 	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
 	"github.com/openchami/boot-service/pkg/resources/node"
 )
@@ -45,6 +55,102 @@ func TestScriptCache(t *testing.T) {
 	}
 }
 
+// TestScriptCacheLRU tests that a full cache evicts the least recently used entry
+func TestScriptCacheLRU(t *testing.T) {
+	cache := NewScriptCacheWithConfig(CacheConfig{TTL: time.Minute, MaxEntries: 2})
+
+	cache.Set("node1", "script1", "node1", "config1")
+	cache.Set("node2", "script2", "node2", "config1")
+	cache.Get("node1") // node2 is now the coldest entry
+	cache.Set("node3", "script3", "node3", "config2")
+
+	if _, found := cache.Get("node2"); found {
+		t.Errorf("Expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"node1", "node3"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("Expected %s to remain cached", key)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.TotalEntries != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected 2 entries and 1 eviction, got %+v", stats)
+	}
+	if stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("Expected 3 hits and 1 miss, got %+v", stats)
+	}
+
+	if removed := cache.InvalidateByConfigID("config1"); removed != 1 {
+		t.Errorf("Expected 1 entry removed for config1, got %d", removed)
+	}
+}
+
+// TestScriptCacheAliases tests that aliases go with their node's evicted entry
+// and that cleanup never prunes an alias recorded before its node's script
+func TestScriptCacheAliases(t *testing.T) {
+	cache := NewScriptCacheWithConfig(CacheConfig{TTL: time.Minute, MaxEntries: 1})
+
+	// The controller records the alias before the script is rendered and cached
+	cache.SetAlias("aa:bb:cc:dd:ee:01", "node1")
+	cache.cleanupExpired()
+	cache.Set("node1", "script1", "node1", "config1")
+	if script, key, found := cache.GetByAlias("aa:bb:cc:dd:ee:01"); !found || key != "node1" || script != "script1" {
+		t.Fatalf("Expected alias to survive cleanup before Set, got %q %q %v", script, key, found)
+	}
+	cache.SetAlias("1", "node1")
+
+	// Evicting node1 drops its aliases but keeps the one just added for node2
+	cache.SetAlias("aa:bb:cc:dd:ee:02", "node2")
+	cache.Set("node2", "script2", "node2", "config1")
+	for _, identifier := range []string{"aa:bb:cc:dd:ee:01", "1"} {
+		if _, _, found := cache.GetByAlias(identifier); found {
+			t.Errorf("Expected alias %s of the evicted node to be dropped", identifier)
+		}
+	}
+	if _, key, found := cache.GetByAlias("aa:bb:cc:dd:ee:02"); !found || key != "node2" {
+		t.Errorf("Expected alias of node2 to remain, got %q %v", key, found)
+	}
+	if stats := cache.Stats(); stats.Aliases != 1 {
+		t.Errorf("Expected 1 alias after eviction, got %+v", stats)
+	}
+
+	// An identifier moved to another node no longer belongs to the old one
+	cache.SetAlias("aa:bb:cc:dd:ee:02", "node3")
+	cache.Set("node3", "script3", "node3", "config1")
+	if _, key, found := cache.GetByAlias("aa:bb:cc:dd:ee:02"); !found || key != "node3" {
+		t.Errorf("Expected moved alias to follow node3, got %q %v", key, found)
+	}
+}
+
+// TestScriptCacheLifecycle tests that the cleanup worker runs between Start and Stop
+func TestScriptCacheLifecycle(t *testing.T) {
+	cache := NewScriptCache(20 * time.Millisecond)
+	cache.Set("node1", "script1", "node1", "config1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx)
+	cache.Start(ctx) // Starting twice is a no-op
+
+	time.Sleep(60 * time.Millisecond)
+	if stats := cache.Stats(); stats.TotalEntries != 0 || stats.Expirations != 1 {
+		t.Errorf("Expected cleanup to expire the entry, got %+v", stats)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cache.Stop()
+		cache.Stop() // Stopping twice is a no-op
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
 // TestIPXETemplates tests the iPXE script generation templates
 func TestIPXETemplates(t *testing.T) {
 	controller := createTestController(t)
@@ -100,23 +206,47 @@ func TestCacheKeyGeneration(t *testing.T) {
 	controller := createTestController(t)
 
 	tests := []struct {
-		identifier string
-		config     string
-		expected   string
+		node     node.NodeSpec
+		expected string
 	}{
-		{"x0c0s0b0n0", "compute-config", "x0c0s0b0n0:compute-config"},
-		{"node1", "", "node1:default"},
-		{"", "config1", ":config1"},
+		{node.NodeSpec{XName: "x0c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:ff"}, "x0c0s0b0n0"},
+		{node.NodeSpec{BootMAC: "AA:BB:CC:DD:EE:FF"}, "mac:aa:bb:cc:dd:ee:ff"},
 	}
 
 	for _, tt := range tests {
-		result := controller.generateCacheKey(tt.identifier, tt.config)
+		result := controller.generateCacheKey(&node.Node{Spec: tt.node})
 		if result != tt.expected {
 			t.Errorf("Expected cache key %s, got %s", tt.expected, result)
 		}
 	}
 }
 
+// TestRoleAndDefaultTargeting tests that host targets beat role targets, which beat the catch-all default
+func TestRoleAndDefaultTargeting(t *testing.T) {
+	controller := createTestController(t)
+
+	compute := &node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, Role: "Compute"}}
+	service := &node.Node{Spec: node.NodeSpec{XName: "x3000c0s1b0n0", NID: 2, Role: "Service"}}
+
+	host := &bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{Hosts: []string{"x1000c0s0b0n0"}}}
+	role := &bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{Roles: []string{"compute"}}}
+	fallback := &bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{Default: true}}
+
+	hostScore := controller.calculateConfigScore(host, compute)
+	roleScore := controller.calculateConfigScore(role, compute)
+	defaultScore := controller.calculateConfigScore(fallback, compute)
+	if !(hostScore > roleScore && roleScore > defaultScore && defaultScore > 0) {
+		t.Errorf("Expected host > role > default > 0, got %d, %d, %d", hostScore, roleScore, defaultScore)
+	}
+
+	if score := controller.calculateConfigScore(role, service); score != 0 {
+		t.Errorf("Expected role configuration not to match other roles, got score %d", score)
+	}
+	if score := controller.calculateConfigScore(fallback, service); score != 1 {
+		t.Errorf("Expected default configuration to match any node, got score %d", score)
+	}
+}
+
 // TestTemplateVariablePreparation tests the template variable preparation
 func TestTemplateVariablePreparation(t *testing.T) {
 	controller := createTestController(t)
@@ -200,3 +330,877 @@ func TestFilenameExtraction(t *testing.T) {
 		}
 	}
 }
+
+// TestDiscoveryScript tests that unknown MACs receive a registration script only when discovery is enabled
+func TestDiscoveryScript(t *testing.T) {
+	bootServer := createMockBootService(t)
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	ctx := context.Background()
+
+	// Discovery disabled: unknown nodes get the error script
+	controller := NewBootScriptController(*bootClient, logger)
+	script, _ := controller.GenerateBootScript(ctx, "aa:bb:cc:dd:ee:ff")
+	if strings.Contains(script, "Discovery") || !strings.Contains(script, "halt") {
+		t.Errorf("Expected error script with discovery disabled, got:\n%s", script)
+	}
+
+	config := DefaultControllerConfig()
+	config.Discovery.Enabled = true
+	config.Discovery.RegistrationURL = "http://boot.example.com/discovery/register"
+	controller = NewBootScriptControllerWithConfig(*bootClient, config, logger)
+
+	script, _ = controller.GenerateBootScript(ctx, "aa:bb:cc:dd:ee:ff")
+	expectedContents := []string{
+		"param mac ${mac}",
+		"param serial ${serial}",
+		"chain --autofree http://boot.example.com/discovery/register##params",
+		"sleep 30",
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Discovery script missing expected content: %s", expected)
+		}
+	}
+
+	// Only MAC identifiers can be registered
+	script, _ = controller.GenerateBootScript(ctx, "x0c0s0b0n0")
+	if strings.Contains(script, "discovery/register") {
+		t.Errorf("Expected no discovery script for an xname")
+	}
+}
+
+// TestRequestCoalescing tests that concurrent requests for one node, by any identifier, render once
+func TestRequestCoalescing(t *testing.T) {
+	var nodeRequests, configRequests atomic.Int32
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			nodeRequests.Add(1)
+			time.Sleep(50 * time.Millisecond) // Keep requests in flight long enough to overlap
+			testNode := node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 42, BootMAC: "aa:bb:cc:dd:ee:01"}}
+			json.NewEncoder(w).Encode([]node.Node{testNode}) //nolint:errcheck
+		case "/bootconfigurations":
+			configRequests.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			config := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0"},
+				Kernel: "http://files.example.com/vmlinuz",
+			}}
+			config.Metadata.Name = "compute"
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{config}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	controller := NewBootScriptController(*bootClient, log.New(io.Discard, "", 0))
+
+	identifiers := []string{"x1000c0s0b0n0", "AA:BB:CC:DD:EE:01", "aa:bb:cc:dd:ee:01", "42"}
+	scripts := make([]string, 40)
+
+	var wg sync.WaitGroup
+	for i := range scripts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scripts[i], _ = controller.GenerateBootScript(context.Background(), identifiers[i%len(identifiers)])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, script := range scripts {
+		if script != scripts[0] || !strings.Contains(script, "vmlinuz") {
+			t.Fatalf("Request %d got a different script:\n%s", i, script)
+		}
+	}
+
+	// One resolution per distinct identifier (the two MAC spellings share one) and one render per node
+	if n := nodeRequests.Load(); n > 3 {
+		t.Errorf("Expected at most 3 node lookups, got %d", n)
+	}
+	if n := configRequests.Load(); n != 1 {
+		t.Errorf("Expected 1 configuration lookup, got %d", n)
+	}
+
+	// Every identifier now hits the shared cache entry without further lookups
+	before := nodeRequests.Load()
+	for _, identifier := range identifiers {
+		controller.GenerateBootScript(context.Background(), identifier) //nolint:errcheck
+	}
+	if n := nodeRequests.Load(); n != before {
+		t.Errorf("Expected cached identifiers to skip node lookups, got %d more", n-before)
+	}
+	if stats := controller.cache.Stats(); stats.TotalEntries != 1 {
+		t.Errorf("Expected 1 cache entry for the node, got %d", stats.TotalEntries)
+	}
+}
+
+// TestSharedRenderTimeout tests that a shared resolution is bounded although
+// it is not cancelled with its caller
+func TestSharedRenderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer bootServer.Close()
+	defer close(release)
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	config := DefaultControllerConfig()
+	config.Cache.RenderTimeout = 50 * time.Millisecond
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+
+	start := time.Now()
+	script, err := controller.GenerateBootScript(context.Background(), "x1000c0s0b0n0")
+	if err != nil {
+		t.Fatalf("GenerateBootScript failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the render timeout to end the shared resolution, took %v", elapsed)
+	}
+	if !strings.Contains(script, "Node resolution failed") {
+		t.Errorf("Expected an error script, got:\n%s", script)
+	}
+}
+
+// TestAdmissionBudgets tests global, cabinet and group boot budgets
+func TestAdmissionBudgets(t *testing.T) {
+	admission := newAdmissionController(AdmissionConfig{
+		Enabled:      true,
+		GlobalLimit:  3,
+		CabinetLimit: 2,
+		GroupLimits:  map[string]int{"gpu": 1},
+	})
+
+	nodes := map[string]node.NodeSpec{
+		"x1000c0s0b0n0": {XName: "x1000c0s0b0n0", Groups: []string{"gpu"}},
+		"x1000c0s0b0n1": {XName: "x1000c0s0b0n1", Groups: []string{"gpu"}},
+		"x1000c0s1b0n0": {XName: "x1000c0s1b0n0"},
+		"x1000c0s2b0n0": {XName: "x1000c0s2b0n0"},
+		"x2000c0s0b0n0": {XName: "x2000c0s0b0n0"},
+		"x3000c0s0b0n0": {XName: "x3000c0s0b0n0"},
+	}
+	for key, spec := range nodes {
+		admission.remember(key, &node.Node{Spec: spec})
+	}
+
+	tests := []struct {
+		node     string
+		admitted bool
+		budget   string
+	}{
+		{"x1000c0s0b0n0", true, ""},
+		{"x1000c0s0b0n1", false, "group gpu"},
+		{"x1000c0s1b0n0", true, ""},
+		{"x1000c0s2b0n0", false, "cabinet x1000"},
+		{"x2000c0s0b0n0", true, ""},
+		{"x3000c0s0b0n0", false, "global"},
+		{"x1000c0s0b0n0", true, ""}, // Already booting nodes keep their lease
+	}
+
+	for _, tt := range tests {
+		admitted, budget := admission.admit(tt.node)
+		if admitted != tt.admitted || budget != tt.budget {
+			t.Errorf("admit(%s) = %v, %q; expected %v, %q", tt.node, admitted, budget, tt.admitted, tt.budget)
+		}
+	}
+
+	stats := admission.stats()
+	if stats.Booting != 3 || stats.Admitted != 3 || stats.Deferred != 3 || stats.Cabinets["x1000"] != 2 {
+		t.Errorf("Unexpected admission stats: %+v", stats)
+	}
+}
+
+// TestAdmissionLeaseExpiry tests that expired leases are released from every budget
+func TestAdmissionLeaseExpiry(t *testing.T) {
+	admission := newAdmissionController(AdmissionConfig{
+		Enabled:       true,
+		CabinetLimit:  1,
+		GroupLimits:   map[string]int{"gpu": 1},
+		LeaseDuration: 20 * time.Millisecond,
+	})
+	admission.remember("x1000c0s0b0n0", &node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", Groups: []string{"gpu"}}})
+	admission.remember("x1000c0s1b0n0", &node.Node{Spec: node.NodeSpec{XName: "x1000c0s1b0n0", Groups: []string{"gpu"}}})
+
+	if admitted, _ := admission.admit("x1000c0s0b0n0"); !admitted {
+		t.Fatal("Expected the first node to be admitted")
+	}
+	if admitted, budget := admission.admit("x1000c0s1b0n0"); admitted || budget != "cabinet x1000" {
+		t.Fatalf("Expected the second node to be deferred by its cabinet, got %v, %q", admitted, budget)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if stats := admission.stats(); stats.Booting != 0 || len(stats.Cabinets) != 0 || len(stats.Groups) != 0 {
+		t.Errorf("Expected expired leases to leave no counts, got %+v", stats)
+	}
+	if admitted, budget := admission.admit("x1000c0s1b0n0"); !admitted {
+		t.Errorf("Expected the second node to be admitted after the lease expired, deferred by %q", budget)
+	}
+	if stats := admission.stats(); stats.Cabinets["x1000"] != 1 || stats.Groups["gpu"] != 1 {
+		t.Errorf("Unexpected admission stats: %+v", stats)
+	}
+}
+
+// TestAdmissionDeferScript tests that over-budget nodes are told to wait and chain back
+func TestAdmissionDeferScript(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01"}},
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 2, BootMAC: "aa:bb:cc:dd:ee:02"}},
+			})
+		case "/bootconfigurations":
+			config := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0", "x1000c0s0b0n1"},
+				Kernel: "http://files.example.com/vmlinuz",
+			}}
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{config}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	config := DefaultControllerConfig()
+	config.Admission.Enabled = true
+	config.Admission.GlobalLimit = 1
+	config.Admission.RetryDelay = 10 * time.Second
+	config.Admission.RetryJitter = 5 * time.Second
+	config.Admission.StaggerWindow = time.Minute
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	script, _ := controller.GenerateBootScript(ctx, "x1000c0s0b0n0")
+	if !strings.Contains(script, "vmlinuz") || !strings.Contains(script, "sleep 1\n") {
+		t.Errorf("Expected admitted boot script staggered by NID, got:\n%s", script)
+	}
+
+	script, _ = controller.GenerateBootScript(ctx, "aa:bb:cc:dd:ee:02")
+	if strings.Contains(script, "vmlinuz") {
+		t.Fatalf("Expected over-budget node to be deferred, got:\n%s", script)
+	}
+	if !strings.Contains(script, "global boot budget exhausted") ||
+		!strings.Contains(script, "chain --autofree bootscript?mac=aa%3Abb%3Acc%3Add%3Aee%3A02") {
+		t.Errorf("Deferred script missing expected content:\n%s", script)
+	}
+
+	// The admitted node is still served from the cache while it holds its lease
+	script, _ = controller.GenerateBootScript(ctx, "1")
+	if !strings.Contains(script, "vmlinuz") {
+		t.Errorf("Expected admitted node to keep booting, got:\n%s", script)
+	}
+}
+
+// TestSpoofCheck tests that requesters must be one of the node's interfaces or a relay
+func TestSpoofCheck(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01",
+					Interfaces: []node.Interface{{MAC: "aa:bb:cc:dd:ee:01", IP: "10.1.0.1"}}}},
+			})
+		case "/bootconfigurations":
+			config := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0"},
+				Kernel: "http://files.example.com/vmlinuz",
+			}}
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{config}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	config := DefaultControllerConfig()
+	config.SpoofCheck.Mode = SpoofCheckReject
+	config.SpoofCheck.RelaySubnets = []string{"10.100.0.0/16"}
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	from := func(ip string) context.Context {
+		return WithRequesterIP(context.Background(), ip)
+	}
+
+	// A spoofed request is refused before the node's script is ever rendered
+	if _, err := controller.GenerateBootScript(from("10.1.0.2"), "aa:bb:cc:dd:ee:01"); !errors.Is(err, ErrRequesterMismatch) {
+		t.Errorf("Expected spoofed request to be rejected, got %v", err)
+	}
+
+	script, err := controller.GenerateBootScript(from("10.1.0.1"), "aa:bb:cc:dd:ee:01")
+	if err != nil || !strings.Contains(script, "vmlinuz") {
+		t.Errorf("Expected node's own request to be served, got %v:\n%s", err, script)
+	}
+
+	// Cached scripts are checked too
+	if _, err := controller.GenerateBootScript(from("10.1.0.2"), "aa:bb:cc:dd:ee:01"); !errors.Is(err, ErrRequesterMismatch) {
+		t.Errorf("Expected spoofed request for cached script to be rejected, got %v", err)
+	}
+	if _, err := controller.GenerateBootScript(from("10.100.3.4"), "x1000c0s0b0n0"); err != nil {
+		t.Errorf("Expected relayed request to be served, got %v", err)
+	}
+	if _, err := controller.GenerateBootScript(context.Background(), "x1000c0s0b0n0"); err != nil {
+		t.Errorf("Expected request without requester to be served, got %v", err)
+	}
+
+	stats := controller.SpoofCheckStats()
+	if stats.Checked != 4 || stats.Relayed != 1 || stats.Mismatches != 2 || stats.Rejected != 2 {
+		t.Errorf("Unexpected spoof check stats: %+v", stats)
+	}
+
+	// In count mode mismatches are served and only counted
+	config.SpoofCheck.Mode = SpoofCheckCount
+	controller = NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	script, err = controller.GenerateBootScript(from("10.1.0.2"), "x1000c0s0b0n0")
+	if err != nil || !strings.Contains(script, "vmlinuz") {
+		t.Errorf("Expected mismatch to be served in count mode, got %v", err)
+	}
+	if stats := controller.SpoofCheckStats(); stats.Mismatches != 1 || stats.Rejected != 0 {
+		t.Errorf("Unexpected spoof check stats in count mode: %+v", stats)
+	}
+}
+
+// TestRenderCloudInit tests merging and templating of NoCloud documents
+func TestRenderCloudInit(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01",
+					Hostname: "nid000001", Groups: []string{"compute"},
+					Interfaces: []node.Interface{{MAC: "aa:bb:cc:dd:ee:01", IP: "10.1.0.1"}}}},
+			})
+		case "/bootconfigurations":
+			group := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Groups: []string{"compute"},
+				Kernel: "http://files.example.com/vmlinuz",
+				CloudInit: &bootconfiguration.CloudInit{
+					MetaData: json.RawMessage(`{"cluster": "test", "motd": "{{ served literally }}"}`),
+					UserData: json.RawMessage(`{"packages": ["vim"], "runcmd": ["echo group"]}`),
+				},
+			}}
+			nodeLevel := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0"},
+				Kernel: "http://files.example.com/vmlinuz",
+				Params: "console=ttyS0",
+				CloudInit: &bootconfiguration.CloudInit{
+					MetaData:     json.RawMessage(`{"local-hostname": "{{.Hostname}}.cluster"}`),
+					UserData:     json.RawMessage(`"#cloud-config\nruncmd:\n  - echo {{.XName}}\n"`),
+					NetworkData:  json.RawMessage(`{"version": 2, "ethernets": {"eth0": {"addresses": ["{{.IP}}/16"]}}}`),
+					PhoneHomeURL: "http://boot.example.com/phone-home",
+					Template:     true,
+				},
+			}}
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{nodeLevel, group}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	config := DefaultControllerConfig()
+	config.CloudInit.BaseURL = "http://boot.example.com/"
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	cloudInit, err := controller.RenderCloudInit(ctx, "aa-bb-cc-dd-ee-01")
+	if err != nil {
+		t.Fatalf("RenderCloudInit failed: %v", err)
+	}
+
+	expectations := []struct {
+		document string
+		content  []byte
+		contains []string
+	}{
+		{"meta-data", cloudInit.MetaData, []string{"instance-id: x1000c0s0b0n0", "cluster: test", "local-hostname: nid000001.cluster",
+			"{{ served literally }}"}},
+		{"user-data", cloudInit.UserData, []string{"#cloud-config\n", "- vim", "- echo x1000c0s0b0n0", "url: http://boot.example.com/phone-home"}},
+		{"network-config", cloudInit.NetworkConfig, []string{"version: 2", "- 10.1.0.1/16"}},
+	}
+	for _, tt := range expectations {
+		for _, expected := range tt.contains {
+			if !strings.Contains(string(tt.content), expected) {
+				t.Errorf("%s missing %q:\n%s", tt.document, expected, tt.content)
+			}
+		}
+	}
+	if strings.Contains(string(cloudInit.UserData), "echo group") {
+		t.Errorf("Expected node-level runcmd to replace group-level runcmd:\n%s", cloudInit.UserData)
+	}
+	if cloudInit.VendorData != nil {
+		t.Errorf("Expected no vendor-data, got:\n%s", cloudInit.VendorData)
+	}
+
+	if _, err := controller.RenderCloudInit(ctx, "x9999c0s0b0n0"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for unknown node, got %v", err)
+	}
+
+	// Boot scripts point cloud-init at the node's datasource
+	script, _ := controller.GenerateBootScript(ctx, "x1000c0s0b0n0")
+	if !strings.Contains(script, "set params console=ttyS0 ds=nocloud-net;s=http://boot.example.com/cloud-init/x1000c0s0b0n0/\n") {
+		t.Errorf("Expected NoCloud datasource kernel param, got:\n%s", script)
+	}
+}
+
+// TestRenderIgnition tests templated Ignition configs with merge references
+func TestRenderIgnition(t *testing.T) {
+	template := `{"ignition": {"version": "3.4.0"}, "storage": {"files": [{"path": "/etc/hostname", ` +
+		`"contents": {"source": "data:,{{.XName}}"}}]}}`
+	disabled := false
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01"}},
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 2, BootMAC: "aa:bb:cc:dd:ee:02"}},
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n2", NID: 3, BootMAC: "aa:bb:cc:dd:ee:03"}},
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n3", NID: 4, BootMAC: "aa:bb:cc:dd:ee:04"},
+					Status: node.NodeStatus{HSMEnabled: &disabled}},
+			})
+		case "/bootconfigurations":
+			worker := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n0", "x1000c0s0b0n3"},
+				Kernel: "http://files.example.com/flatcar_production_pxe.vmlinuz",
+				Params: "flatcar.first_boot=1",
+				Ignition: &bootconfiguration.Ignition{
+					Template: template,
+					Merge: []bootconfiguration.IgnitionReference{
+						{Source: "http://files.example.com/k8s-worker.ign", Hash: "sha512-abc123"},
+					},
+				},
+			}}
+			plain := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts:  []string{"x1000c0s0b0n1"},
+				Kernel: "http://files.example.com/vmlinuz",
+			}}
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{worker, plain}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	config := DefaultControllerConfig()
+	config.Ignition.BaseURL = "http://boot.example.com"
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	rendered, err := controller.RenderIgnition(ctx, "aa:bb:cc:dd:ee:01")
+	if err != nil {
+		t.Fatalf("RenderIgnition failed: %v", err)
+	}
+
+	var ignition struct {
+		Ignition struct {
+			Version string `json:"version"`
+			Config  struct {
+				Merge []struct {
+					Source       string `json:"source"`
+					Verification struct {
+						Hash string `json:"hash"`
+					} `json:"verification"`
+				} `json:"merge"`
+			} `json:"config"`
+		} `json:"ignition"`
+		Storage struct {
+			Files []struct {
+				Contents struct {
+					Source string `json:"source"`
+				} `json:"contents"`
+			} `json:"files"`
+		} `json:"storage"`
+	}
+	if err := json.Unmarshal(rendered, &ignition); err != nil {
+		t.Fatalf("Rendered config is not JSON: %v\n%s", err, rendered)
+	}
+	if ignition.Ignition.Version != "3.4.0" || len(ignition.Ignition.Config.Merge) != 1 ||
+		ignition.Ignition.Config.Merge[0].Verification.Hash != "sha512-abc123" {
+		t.Errorf("Unexpected ignition section: %s", rendered)
+	}
+	if len(ignition.Storage.Files) != 1 || ignition.Storage.Files[0].Contents.Source != "data:,x1000c0s0b0n0" {
+		t.Errorf("Template not rendered with node facts: %s", rendered)
+	}
+
+	if _, err := controller.RenderIgnition(ctx, "x1000c0s0b0n1"); !errors.Is(err, ErrNoIgnitionConfig) {
+		t.Errorf("Expected ErrNoIgnitionConfig for configuration without Ignition, got %v", err)
+	}
+	if _, err := controller.RenderIgnition(ctx, "x1000c0s0b0n2"); !errors.Is(err, ErrNoIgnitionConfig) {
+		t.Errorf("Expected ErrNoIgnitionConfig for node without configuration, got %v", err)
+	}
+	// A node the state policy keeps from booting has an Ignition config it must not get
+	if _, err := controller.RenderIgnition(ctx, "x1000c0s0b0n3"); !errors.Is(err, ErrNotBooting) {
+		t.Errorf("Expected ErrNotBooting for halted node, got %v", err)
+	}
+	if history := controller.EndpointHistory("x1000c0s0b0n0", EndpointIgnition); len(history) != 1 {
+		t.Errorf("Expected Ignition access to be recorded, got %+v", history)
+	}
+
+	// Only configurations with an Ignition config point the kernel at it
+	script, _ := controller.GenerateBootScript(ctx, "x1000c0s0b0n0")
+	if !strings.Contains(script, "set params flatcar.first_boot=1 ignition.config.url=http://boot.example.com/ignition/x1000c0s0b0n0\n") {
+		t.Errorf("Expected ignition.config.url kernel param, got:\n%s", script)
+	}
+	script, _ = controller.GenerateBootScript(ctx, "x1000c0s0b0n1")
+	if strings.Contains(script, "ignition.config.url") {
+		t.Errorf("Unexpected ignition.config.url for configuration without Ignition:\n%s", script)
+	}
+}
+
+// TestEndpointHistory tests that boot script and cloud-init fetches are recorded per node
+func TestEndpointHistory(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1, BootMAC: "aa:bb:cc:dd:ee:01"}},
+				{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 2, BootMAC: "aa:bb:cc:dd:ee:02"}},
+			})
+		case "/bootconfigurations":
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	controller := NewBootScriptController(*bootClient, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	before := time.Now().Unix()
+	controller.GenerateBootScript(ctx, "aa:bb:cc:dd:ee:01") //nolint:errcheck
+	controller.GenerateBootScript(ctx, "1")                 //nolint:errcheck // Cache hit for the same node
+	controller.RenderCloudInit(ctx, "x1000c0s0b0n1")        //nolint:errcheck
+
+	history := controller.EndpointHistory("", "")
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %+v", history)
+	}
+	if history[0].Name != "x1000c0s0b0n0" || history[0].Endpoint != EndpointBootScript || history[0].LastEpoch < before {
+		t.Errorf("Unexpected boot script entry: %+v", history[0])
+	}
+	if history[1].Name != "x1000c0s0b0n1" || history[1].Endpoint != EndpointCloudInit {
+		t.Errorf("Unexpected cloud-init entry: %+v", history[1])
+	}
+
+	if filtered := controller.EndpointHistory("x1000c0s0b0n1", EndpointBootScript); len(filtered) != 0 {
+		t.Errorf("Expected no boot script fetches for x1000c0s0b0n1, got %+v", filtered)
+	}
+	if filtered := controller.EndpointHistory("", EndpointCloudInit); len(filtered) != 1 {
+		t.Errorf("Expected one cloud-init fetch, got %+v", filtered)
+	}
+}
+
+// TestStaggerDelay tests deterministic NID-based stagger delays
+func TestStaggerDelay(t *testing.T) {
+	tests := []struct {
+		nid      int32
+		window   int
+		expected int
+	}{
+		{0, 60, 0},
+		{59, 60, 59},
+		{61, 60, 1},
+		{42, 0, 0},
+		{-1, 60, 0},
+	}
+
+	for _, tt := range tests {
+		if result := staggerDelay(tt.nid, tt.window); result != tt.expected {
+			t.Errorf("staggerDelay(%d, %d) = %d, expected %d", tt.nid, tt.window, result, tt.expected)
+		}
+	}
+}
+
+// TestNodeResolution tests resolution by xname, NID, interface MAC and hostname
+func TestNodeResolution(t *testing.T) {
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:revive
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]node.Node{ //nolint:errcheck
+			{Spec: node.NodeSpec{
+				XName:    "x1000c0s0b0n0",
+				NID:      1234,
+				BootMAC:  "aa:bb:cc:dd:ee:01",
+				Hostname: "compute-01.cluster.local",
+				Interfaces: []node.Interface{
+					{MAC: "aa:bb:cc:dd:ee:01"},
+					{MAC: "aa:bb:cc:dd:ee:99", IP: "10.0.0.99"},
+				},
+			}},
+			{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 7, Hostname: "login01"}},
+		})
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	controller := NewBootScriptController(*bootClient, log.New(io.Discard, "", 0))
+
+	tests := []struct {
+		identifier   string
+		expectedType IdentifierType
+		expected     string
+	}{
+		{"x1000c0s0b0n0", IdentifierXName, "x1000c0s0b0n0"},
+		{"1234", IdentifierNID, "x1000c0s0b0n0"},
+		{"AA:BB:CC:DD:EE:01", IdentifierMAC, "x1000c0s0b0n0"},
+		{"aa-bb-cc-dd-ee-99", IdentifierMAC, "x1000c0s0b0n0"}, // Secondary interface
+		{"aabb.ccdd.ee99", IdentifierMAC, "x1000c0s0b0n0"},
+		{"aabbccddee99", IdentifierMAC, "x1000c0s0b0n0"},
+		{"compute-01.cluster.local", IdentifierHostname, "x1000c0s0b0n0"},
+		{"compute-01", IdentifierHostname, "x1000c0s0b0n0"},
+		{"login01.cluster.local", IdentifierHostname, "x1000c0s0b0n1"},
+		{"nid001234", IdentifierHostname, "x1000c0s0b0n0"}, // Legacy BSS host form
+		{"nid000007", IdentifierHostname, "x1000c0s0b0n1"},
+	}
+
+	// Twelve hex digits are read as a MAC address before a hostname
+	if nodeID := controller.parseNodeIdentifier("deadbeefcafe"); nodeID.Type != IdentifierMAC || nodeID.Value != "de:ad:be:ef:ca:fe" {
+		t.Errorf("Expected a hex hostname to be parsed as a MAC address, got %+v", nodeID)
+	}
+
+	for _, tt := range tests {
+		nodeID := controller.parseNodeIdentifier(tt.identifier)
+		if nodeID.Type != tt.expectedType {
+			t.Errorf("parseNodeIdentifier(%s) type = %v, expected %v", tt.identifier, nodeID.Type, tt.expectedType)
+			continue
+		}
+
+		resolved, err := controller.resolveNode(context.Background(), nodeID)
+		if err != nil {
+			t.Errorf("Failed to resolve %s: %v", tt.identifier, err)
+			continue
+		}
+		if resolved.Spec.XName != tt.expected {
+			t.Errorf("Resolved %s to %s, expected %s", tt.identifier, resolved.Spec.XName, tt.expected)
+		}
+	}
+
+	if _, err := controller.resolveNode(context.Background(), controller.parseNodeIdentifier("nid000999")); err == nil {
+		t.Errorf("Expected unknown legacy NID host to fail resolution")
+	}
+
+	// Requests without an identifier are matched by interface IP
+	identifier, err := controller.ResolveNodeByIP(context.Background(), "10.0.0.99")
+	if err != nil || identifier != "x1000c0s0b0n0" {
+		t.Errorf("ResolveNodeByIP(10.0.0.99) = %q, %v; expected x1000c0s0b0n0", identifier, err)
+	}
+	if _, err := controller.ResolveNodeByIP(context.Background(), "10.0.0.100"); err == nil {
+		t.Errorf("Expected unknown IP to fail resolution")
+	}
+}
+
+func TestRemovedNodeScript(t *testing.T) {
+	removed := node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1}, Status: node.NodeStatus{State: node.StateRemoved}}
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode([]node.Node{removed}) //nolint:errcheck
+		default:
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{ //nolint:errcheck
+				{Spec: bootconfiguration.BootConfigurationSpec{
+					Default: true, Kernel: "http://files.example.com/vmlinuz",
+					CloudInit: &bootconfiguration.CloudInit{UserData: json.RawMessage(`{"runcmd": ["echo secret"]}`)},
+					Ignition:  &bootconfiguration.Ignition{Config: json.RawMessage(`{"ignition": {"version": "3.4.0"}}`)},
+				}},
+			})
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	controller := NewBootScriptController(*bootClient, log.New(io.Discard, "", 0))
+
+	// Tombstoned nodes no longer boot the configurations they used to match
+	script, err := controller.GenerateBootScript(context.Background(), "x1000c0s0b0n0")
+	if err != nil {
+		t.Fatalf("Failed to generate script: %v", err)
+	}
+	if strings.Contains(script, "vmlinuz") {
+		t.Errorf("Expected a removed node not to receive a kernel, got:\n%s", script)
+	}
+
+	// Nor their cloud-init data or Ignition config
+	if _, err := controller.RenderCloudInit(context.Background(), "x1000c0s0b0n0"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for cloud-init of a removed node, got %v", err)
+	}
+	if _, err := controller.RenderIgnition(context.Background(), "x1000c0s0b0n0"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for Ignition of a removed node, got %v", err)
+	}
+	if explanation, err := controller.Explain(context.Background(), "x1000c0s0b0n0"); err != nil || explanation.Configuration != "" {
+		t.Errorf("Expected no configuration explained for a removed node, got %+v, %v", explanation, err)
+	}
+}
+
+func TestStatePolicy(t *testing.T) {
+	enabled, disabled := true, false
+	nodes := []node.Node{
+		{Spec: node.NodeSpec{XName: "x1000c0s0b0n0", NID: 1}, Status: node.NodeStatus{HSMState: "Ready", HSMFlag: "OK", HSMEnabled: &disabled}},
+		{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 2}, Status: node.NodeStatus{HSMState: "Ready", HSMFlag: "Alert", HSMEnabled: &enabled}},
+		{Spec: node.NodeSpec{XName: "x1000c0s0b0n2", NID: 3}, Status: node.NodeStatus{HSMState: "Standby", HSMFlag: "OK", HSMEnabled: &enabled}},
+		{Spec: node.NodeSpec{XName: "x1000c0s0b0n3", NID: 4}},
+	}
+	bootServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/nodes":
+			json.NewEncoder(w).Encode(nodes) //nolint:errcheck
+		default:
+			production := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Default: true, Kernel: "http://files.example.com/production.vmlinuz",
+				CloudInit: &bootconfiguration.CloudInit{UserData: json.RawMessage(`{"runcmd": ["echo production"]}`)},
+				Ignition:  &bootconfiguration.Ignition{Config: json.RawMessage(`{"ignition": {"version": "3.4.0"}}`)},
+			}}
+			production.Metadata.Name = "production"
+			diagnostics := bootconfiguration.BootConfiguration{Spec: bootconfiguration.BootConfigurationSpec{
+				Hosts: []string{"none"}, Kernel: "http://files.example.com/diagnostics.vmlinuz",
+				CloudInit: &bootconfiguration.CloudInit{UserData: json.RawMessage(`{"runcmd": ["echo diagnostics"]}`)},
+			}}
+			diagnostics.Metadata.Name = "diagnostics"
+			json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{production, diagnostics}) //nolint:errcheck
+		}
+	}))
+	defer bootServer.Close()
+
+	bootClient, err := client.NewClient(bootServer.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	config := DefaultControllerConfig()
+	config.StatePolicy.Rules = append(config.StatePolicy.Rules,
+		StatePolicyRule{Flags: []string{"alert"}, Action: StateActionConfig, Config: "diagnostics"},
+		StatePolicyRule{States: []string{"Standby"}, Action: StateActionBoot},
+	)
+	if err := config.StatePolicy.Validate(); err != nil {
+		t.Fatalf("Invalid state policy: %v", err)
+	}
+	controller := NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	tests := []struct {
+		xname, action, kernel, config string
+	}{
+		{"x1000c0s0b0n0", StateActionHalt, "", ""},
+		{"x1000c0s0b0n1", StateActionConfig, "diagnostics.vmlinuz", "diagnostics"},
+		{"x1000c0s0b0n2", StateActionBoot, "production.vmlinuz", "production"},
+		{"x1000c0s0b0n3", StateActionBoot, "production.vmlinuz", "production"},
+	}
+	for _, tt := range tests {
+		script, err := controller.GenerateBootScript(ctx, tt.xname)
+		if err != nil {
+			t.Fatalf("Failed to generate script for %s: %v", tt.xname, err)
+		}
+		if tt.kernel == "" && (strings.Contains(script, "vmlinuz") || !strings.Contains(script, "halted by state policy")) {
+			t.Errorf("Expected %s to be halted, got:\n%s", tt.xname, script)
+		}
+		if tt.kernel != "" && !strings.Contains(script, tt.kernel) {
+			t.Errorf("Expected %s to boot %s, got:\n%s", tt.xname, tt.kernel, script)
+		}
+
+		explanation, err := controller.Explain(ctx, tt.xname)
+		if err != nil {
+			t.Fatalf("Failed to explain %s: %v", tt.xname, err)
+		}
+		if explanation.Decision.Action != tt.action || explanation.Configuration != tt.config {
+			t.Errorf("Expected %s decision and configuration %q for %s, got %+v", tt.action, tt.config, tt.xname, explanation)
+		}
+
+		// Cloud-init and Ignition follow the same decision as the boot script
+		cloudInit, err := controller.RenderCloudInit(ctx, tt.xname)
+		_, ignitionErr := controller.RenderIgnition(ctx, tt.xname)
+		switch tt.config {
+		case "":
+			if !errors.Is(err, ErrNotBooting) || !errors.Is(ignitionErr, ErrNotBooting) {
+				t.Errorf("Expected ErrNotBooting for halted %s, got %v and %v", tt.xname, err, ignitionErr)
+			}
+		case "diagnostics":
+			if err != nil || !strings.Contains(string(cloudInit.UserData), "echo diagnostics") ||
+				strings.Contains(string(cloudInit.UserData), "echo production") {
+				t.Errorf("Expected only diagnostics user-data for %s, got %v:\n%s", tt.xname, err, cloudInit.UserData)
+			}
+			if !errors.Is(ignitionErr, ErrNoIgnitionConfig) {
+				t.Errorf("Expected ErrNoIgnitionConfig for %s, got %v", tt.xname, ignitionErr)
+			}
+		default:
+			if err != nil || !strings.Contains(string(cloudInit.UserData), "echo production") {
+				t.Errorf("Expected production user-data for %s, got %v", tt.xname, err)
+			}
+			if ignitionErr != nil {
+				t.Errorf("Expected an Ignition config for %s, got %v", tt.xname, ignitionErr)
+			}
+		}
+	}
+
+	explanation, _ := controller.Explain(ctx, "x1000c0s0b0n1")
+	if explanation.Configuration != "diagnostics" || explanation.Decision.Rule != 1 {
+		t.Errorf("Expected the diagnostics configuration from rule 1, got %+v", explanation)
+	}
+	if _, err := controller.Explain(ctx, "x9999c0s0b0n0"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for an unknown node, got %v", err)
+	}
+
+	// Disabled nodes can be sent to their local disk instead
+	config.StatePolicy.Rules[0].Action = StateActionLocal
+	controller = NewBootScriptControllerWithConfig(*bootClient, config, log.New(io.Discard, "", 0))
+	script, _ := controller.GenerateBootScript(ctx, "x1000c0s0b0n0")
+	if !strings.Contains(script, "\nexit\n") {
+		t.Errorf("Expected a local boot script, got:\n%s", script)
+	}
+
+	if err := (StatePolicyConfig{Rules: []StatePolicyRule{{Action: StateActionConfig}}}).Validate(); err == nil {
+		t.Errorf("Expected a config action without a configuration to be invalid")
+	}
+}
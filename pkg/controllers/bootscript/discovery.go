@@ -0,0 +1,52 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiscoveryConfig controls auto-registration of unknown nodes that PXE boot
+type DiscoveryConfig struct {
+	// Enabled turns on discovery mode; unknown MACs receive a registration script
+	Enabled bool `yaml:"enabled"`
+
+	// RegistrationURL is the absolute URL iPXE posts discovered facts to
+	// (e.g., "http://boot.example.com:8080/discovery/register")
+	RegistrationURL string `yaml:"registration_url"`
+
+	// RetryDelay is how long (in seconds) iPXE waits before retrying a failed registration
+	RetryDelay int `yaml:"retry_delay"`
+}
+
+// DefaultDiscoveryConfig returns the default discovery configuration (disabled)
+func DefaultDiscoveryConfig() DiscoveryConfig {
+	return DiscoveryConfig{
+		Enabled:    false,
+		RetryDelay: 30,
+	}
+}
+
+// discoveryEnabled reports whether discovery mode can be used
+func (c *BootScriptController) discoveryEnabled() bool {
+	return c.config.Discovery.Enabled && c.config.Discovery.RegistrationURL != ""
+}
+
+// generateDiscoveryScript creates an iPXE script that registers an unknown node
+func (c *BootScriptController) generateDiscoveryScript(identifier string) string {
+	retryDelay := c.config.Discovery.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = DefaultDiscoveryConfig().RetryDelay
+	}
+
+	// Use a simple string replacement for the discovery template
+	script := DiscoveryIPXETemplate
+	script = strings.ReplaceAll(script, "{{.Identifier}}", identifier)
+	script = strings.ReplaceAll(script, "{{.RegistrationURL}}", c.config.Discovery.RegistrationURL)
+	script = strings.ReplaceAll(script, "{{.RetryDelay}}", strconv.Itoa(retryDelay))
+
+	return script
+}
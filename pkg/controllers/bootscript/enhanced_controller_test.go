@@ -146,6 +146,7 @@ func TestEnhancedController_HSMIntegration(t *testing.T) {
 
 // createMockHSMServer creates a mock HSM server for testing
 func createMockHSMServer(t *testing.T) *httptest.Server {
+	enabled := true
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/hsm/v2/service/ready":
@@ -159,7 +160,7 @@ func createMockHSMServer(t *testing.T) *httptest.Server {
 						ID:      "x1000c0s0b0n0",
 						Type:    "Node",
 						State:   "Ready",
-						Enabled: true,
+						Enabled: &enabled,
 						Role:    "Compute",
 						NID:     123,
 					},
@@ -167,7 +168,7 @@ func createMockHSMServer(t *testing.T) *httptest.Server {
 						ID:      "x2000c0s0b0n0",
 						Type:    "Node",
 						State:   "Ready",
-						Enabled: true,
+						Enabled: &enabled,
 						Role:    "Compute",
 						NID:     456,
 					},
@@ -175,7 +176,7 @@ func createMockHSMServer(t *testing.T) *httptest.Server {
 						ID:      "x1000c0r0e0",
 						Type:    "RouterModule",
 						State:   "Ready",
-						Enabled: true,
+						Enabled: &enabled,
 						Role:    "Service",
 					},
 				},
@@ -188,7 +189,7 @@ func createMockHSMServer(t *testing.T) *httptest.Server {
 				ID:      "x2000c0s0b0n0",
 				Type:    "Node",
 				State:   "Ready",
-				Enabled: true,
+				Enabled: &enabled,
 				Role:    "Compute",
 				NID:     456,
 			}
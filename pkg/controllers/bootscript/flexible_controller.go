@@ -6,7 +6,11 @@ package bootscript
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/openchami/boot-service/pkg/client"
 	"github.com/openchami/boot-service/pkg/clients/hsm"
@@ -27,6 +31,29 @@ type SyncProvider interface {
 	StartSyncWorker(ctx context.Context)
 }
 
+// SyncStatusProvider interface for sync providers that report their last
+// successful sync and the error of their most recent attempt
+type SyncStatusProvider interface {
+	LastSync() (time.Time, error)
+}
+
+// NodeChangeNotifier interface for providers that report nodes whose data
+// changed, so scripts rendered from the old data can be dropped
+type NodeChangeNotifier interface {
+	OnNodeChange(fn func(xname string))
+}
+
+// NotificationReceiver interface for providers that accept pushed inventory
+// change notifications, e.g., HSM state change notifications
+type NotificationReceiver interface {
+	SCNHandler() http.Handler
+}
+
+// NodeLister interface for providers that can list every node they know
+type NodeLister interface {
+	ListNodes(ctx context.Context) ([]node.Node, error)
+}
+
 // FlexibleBootScriptController provides boot script generation with pluggable node providers
 type FlexibleBootScriptController struct {
 	*BootScriptController
@@ -41,12 +68,19 @@ type ProviderConfig struct {
 	Type       string                   `yaml:"type"` // "hsm" or "yaml"
 	HSMConfig  *hsm.IntegrationConfig   `yaml:"hsm_config,omitempty"`
 	YAMLConfig *local.IntegrationConfig `yaml:"yaml_config,omitempty"`
+
+	// Controller holds boot script controller settings (defaults are used when nil)
+	Controller *ControllerConfig `yaml:"controller,omitempty"`
 }
 
 // NewFlexibleBootScriptController creates a controller with the specified provider
 func NewFlexibleBootScriptController(bootClient client.Client, config ProviderConfig, logger *log.Logger) (*FlexibleBootScriptController, error) {
 	// Create base controller
-	baseController := NewBootScriptController(bootClient, logger)
+	if config.Controller == nil {
+		defaultConfig := DefaultControllerConfig()
+		config.Controller = &defaultConfig
+	}
+	baseController := NewBootScriptControllerWithConfig(bootClient, *config.Controller, logger)
 
 	controller := &FlexibleBootScriptController{
 		BootScriptController: baseController,
@@ -89,45 +123,27 @@ func NewFlexibleBootScriptController(bootClient client.Client, config ProviderCo
 		logger.Printf("Unknown provider type: %s, using basic controller only", config.Type)
 	}
 
+	// Let the base controller fall back to the provider during node resolution
+	baseController.nodeProvider = controller.nodeProvider
+
+	if notifier, ok := controller.nodeProvider.(NodeChangeNotifier); ok {
+		notifier.OnNodeChange(func(xname string) {
+			if removed := baseController.InvalidateNode(xname); removed > 0 {
+				logger.Printf("Invalidated %d cached boot scripts for changed node %s", removed, xname)
+			}
+		})
+	}
+
 	return controller, nil
 }
 
-// GenerateBootScriptWithFallback generates a boot script with external provider fallback
+// GenerateBootScriptWithFallback generates a boot script with external provider fallback.
+// Provider lookups happen during node resolution, so unknown nodes reach discovery
+// mode only after both local storage and the provider have been consulted.
 func (c *FlexibleBootScriptController) GenerateBootScriptWithFallback(ctx context.Context, identifier string) (string, error) {
 	c.logger.Printf("Generating boot script for identifier: %s (provider: %s)", identifier, c.providerType)
 
-	// First try the standard resolution
-	script, err := c.GenerateBootScript(ctx, identifier)
-	if err == nil {
-		return script, nil
-	}
-
-	// If no external provider is configured, return minimal script
-	if c.nodeProvider == nil {
-		c.logger.Printf("Standard resolution failed for %s, no external provider configured: %v", identifier, err)
-		return c.generateMinimalScript(identifier), nil
-	}
-
-	c.logger.Printf("Standard resolution failed for %s, trying %s provider: %v", identifier, c.providerType, err)
-
-	// Try external provider resolution
-	node, err := c.nodeProvider.ResolveNodeByIdentifier(ctx, identifier)
-	if err != nil {
-		c.logger.Printf("%s provider fallback also failed for %s: %v", c.providerType, identifier, err)
-		// Return minimal script as final fallback
-		return c.generateMinimalScript(identifier), nil
-	}
-
-	c.logger.Printf("%s provider resolved node %s for identifier %s", c.providerType, node.Spec.XName, identifier)
-
-	// Now try to generate script with the resolved node
-	script, err = c.GenerateBootScript(ctx, node.Spec.XName)
-	if err != nil {
-		c.logger.Printf("Failed to generate script for %s-resolved node %s: %v", c.providerType, node.Spec.XName, err)
-		return c.generateMinimalScript(identifier), nil
-	}
-
-	return script, nil
+	return c.GenerateBootScript(ctx, identifier)
 }
 
 // StartBackgroundSync starts background synchronization if the provider supports it
@@ -167,6 +183,41 @@ func (c *FlexibleBootScriptController) HealthCheck(ctx context.Context) error {
 	return c.nodeProvider.HealthCheck(ctx)
 }
 
+// SyncHealthCheck fails when the provider's last successful sync is older
+// than maxAge, so instances with a stale view of the inventory stop
+// receiving boot requests. Providers without background sync always pass.
+func (c *FlexibleBootScriptController) SyncHealthCheck(ctx context.Context, maxAge time.Duration) error { //nolint:revive
+	status, ok := c.syncProvider.(SyncStatusProvider)
+	if c.syncProvider == nil || !ok {
+		return nil
+	}
+
+	lastSuccess, lastErr := status.LastSync()
+	if lastSuccess.IsZero() {
+		if lastErr != nil {
+			return fmt.Errorf("%s sync has not succeeded: %w", c.providerType, lastErr)
+		}
+		return errors.New(c.providerType + " sync has not completed yet")
+	}
+	if age := time.Since(lastSuccess); age > maxAge {
+		if lastErr != nil {
+			return fmt.Errorf("last successful %s sync was %v ago: %w", c.providerType, age.Round(time.Second), lastErr)
+		}
+		return fmt.Errorf("last successful %s sync was %v ago", c.providerType, age.Round(time.Second))
+	}
+	return nil
+}
+
+// NotificationHandler returns the provider's handler for pushed inventory
+// change notifications, or nil if the provider doesn't accept them
+func (c *FlexibleBootScriptController) NotificationHandler() http.Handler {
+	receiver, ok := c.nodeProvider.(NotificationReceiver)
+	if !ok {
+		return nil
+	}
+	return receiver.SCNHandler()
+}
+
 // GetProviderType returns the configured provider type
 func (c *FlexibleBootScriptController) GetProviderType() string {
 	return c.providerType
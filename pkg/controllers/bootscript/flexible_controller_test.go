@@ -8,6 +8,7 @@ package bootscript
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -34,6 +35,7 @@ nodes:
     state: "Ready"
     enabled: true
     nid: 123
+    hostname: "compute-123.cluster.local"
     boot_mac: "00:1B:63:84:45:E6"
     ethernet_interfaces:
       - mac_address: "00:1B:63:84:45:E6"
@@ -88,6 +90,7 @@ func createMockBootService(t *testing.T) *httptest.Server { //nolint:revive
 
 // createMockHSMService creates a mock HSM service for testing
 func createMockHSMService(t *testing.T) *httptest.Server {
+	enabled := true
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/hsm/v2/service/ready":
@@ -101,7 +104,7 @@ func createMockHSMService(t *testing.T) *httptest.Server {
 						ID:      "x3000c0s0b0n0",
 						Type:    "Node",
 						State:   "Ready",
-						Enabled: true,
+						Enabled: &enabled,
 						Role:    "Compute",
 						NID:     789,
 					},
@@ -115,7 +118,7 @@ func createMockHSMService(t *testing.T) *httptest.Server {
 				ID:      "x3000c0s0b0n0",
 				Type:    "Node",
 				State:   "Ready",
-				Enabled: true,
+				Enabled: &enabled,
 				Role:    "Compute",
 				NID:     789,
 			}
@@ -226,6 +229,32 @@ func TestFlexibleController_YAMLProvider(t *testing.T) {
 
 		t.Logf("✅ Generated boot script by MAC address (%d bytes)", len(script))
 	})
+
+	t.Run("Node Resolution by Hostname and MAC Notation", func(t *testing.T) {
+		tests := []struct {
+			identifier string
+			expected   string
+		}{
+			{"compute-123.cluster.local", "x1000c0s0b0n0"},
+			{"compute-123", "x1000c0s0b0n0"},
+			{"nid000456", "x2000c0s0b0n0"},
+			{"00-1b-63-84-45-f0", "x2000c0s0b0n0"},
+			{"001b.6384.45e6", "x1000c0s0b0n0"},
+			{"001B638445E6", "x1000c0s0b0n0"},
+			{"10.1.1.123", "x1000c0s0b0n0"},
+		}
+
+		for _, tt := range tests {
+			n, err := controller.nodeProvider.ResolveNodeByIdentifier(ctx, tt.identifier)
+			if err != nil {
+				t.Errorf("Failed to resolve %s: %v", tt.identifier, err)
+				continue
+			}
+			if n.Spec.XName != tt.expected {
+				t.Errorf("Resolved %s to %s, expected %s", tt.identifier, n.Spec.XName, tt.expected)
+			}
+		}
+	})
 }
 
 func TestFlexibleController_HSMProvider(t *testing.T) {
@@ -411,3 +440,40 @@ func BenchmarkFlexibleController_YAML(b *testing.B) {
 		}
 	}
 }
+
+// fakeSyncProvider reports a fixed sync status
+type fakeSyncProvider struct {
+	SyncProvider
+	lastSuccess time.Time
+	lastErr     error
+}
+
+func (p *fakeSyncProvider) LastSync() (time.Time, error) {
+	return p.lastSuccess, p.lastErr
+}
+
+func TestSyncHealthCheck(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name        string
+		provider    SyncProvider
+		expectError bool
+	}{
+		{"No Sync Provider", nil, false},
+		{"Recent Sync", &fakeSyncProvider{lastSuccess: time.Now().Add(-time.Minute)}, false},
+		{"Recent Sync After Failure", &fakeSyncProvider{lastSuccess: time.Now().Add(-time.Minute), lastErr: failure}, false},
+		{"Never Synced", &fakeSyncProvider{}, true},
+		{"Never Succeeded", &fakeSyncProvider{lastErr: failure}, true},
+		{"Stale Sync", &fakeSyncProvider{lastSuccess: time.Now().Add(-time.Hour), lastErr: failure}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &FlexibleBootScriptController{syncProvider: tt.provider, providerType: "hsm"}
+			err := controller.SyncHealthCheck(context.Background(), 15*time.Minute)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
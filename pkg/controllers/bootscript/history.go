@@ -0,0 +1,76 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Boot endpoints recorded in the request history, named as in BSS
+const (
+	EndpointBootScript = "bootscript"
+	EndpointCloudInit  = "cloud-init"
+	EndpointIgnition   = "ignition"
+)
+
+// EndpointAccess records when a node last fetched a boot endpoint
+type EndpointAccess struct {
+	Name      string `json:"name"`
+	Endpoint  string `json:"endpoint"`
+	LastEpoch int64  `json:"last_epoch"`
+}
+
+// requestHistory keeps the last access of each node to each boot endpoint.
+// It holds one entry per node and endpoint, so its size is bounded by the inventory.
+type requestHistory struct {
+	mu   sync.RWMutex
+	last map[string]EndpointAccess
+}
+
+func newRequestHistory() *requestHistory {
+	return &requestHistory{last: make(map[string]EndpointAccess)}
+}
+
+// record notes that a node fetched an endpoint now
+func (h *requestHistory) record(name, endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last[name+"|"+endpoint] = EndpointAccess{Name: name, Endpoint: endpoint, LastEpoch: time.Now().Unix()}
+}
+
+// entries returns the recorded accesses matching name and endpoint (either may
+// be empty to match all), ordered by name and endpoint
+func (h *requestHistory) entries(name, endpoint string) []EndpointAccess {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := make([]EndpointAccess, 0, len(h.last))
+	for _, access := range h.last {
+		if (name == "" || strings.EqualFold(access.Name, name)) && (endpoint == "" || access.Endpoint == endpoint) {
+			entries = append(entries, access)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Endpoint < entries[j].Endpoint
+	})
+	return entries
+}
+
+// EndpointHistory returns when nodes last fetched their boot script or
+// cloud-init data, filtered by node name and endpoint when given
+func (c *BootScriptController) EndpointHistory(name, endpoint string) []EndpointAccess {
+	return c.history.entries(name, endpoint)
+}
+
+// recordAccess records a node's fetch of an endpoint, given the node's cache key
+func (c *BootScriptController) recordAccess(nodeKey, endpoint string) {
+	c.history.record(strings.TrimPrefix(nodeKey, "mac:"), endpoint)
+}
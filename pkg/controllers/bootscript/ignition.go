@@ -0,0 +1,140 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+	"github.com/openchami/boot-service/pkg/validation"
+)
+
+// IgnitionConfig controls the Ignition configs served at /ignition
+type IgnitionConfig struct {
+	// BaseURL is the absolute URL of the boot service as seen by booting nodes
+	// (e.g., "http://boot.example.com:8080"). When set, kernel params of
+	// configurations with an Ignition config gain "ignition.config.url=...".
+	BaseURL string `yaml:"base_url"`
+}
+
+// DefaultIgnitionConfig returns the default Ignition configuration (no kernel param injection)
+func DefaultIgnitionConfig() IgnitionConfig {
+	return IgnitionConfig{}
+}
+
+// ErrNoIgnitionConfig is returned when the configuration selected for a node has no Ignition config
+var ErrNoIgnitionConfig = errors.New("no ignition config for node")
+
+// RenderIgnition renders the Ignition config of the boot configuration selected
+// for a node, adding its merge or replace references
+func (c *BootScriptController) RenderIgnition(ctx context.Context, identifier string) ([]byte, error) {
+	n, err := c.resolveRequester(ctx, identifier, EndpointIgnition)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := c.nodeBootConfiguration(ctx, n, c.stateDecision(n))
+	if errors.Is(err, errNoMatchingConfiguration) {
+		return nil, fmt.Errorf("%w %s: %v", ErrNoIgnitionConfig, nodeIdentifierOf(n), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("selecting configuration for node %s: %w", nodeIdentifierOf(n), err)
+	}
+	if config.Spec.Ignition == nil {
+		return nil, fmt.Errorf("%w %s", ErrNoIgnitionConfig, nodeIdentifierOf(n))
+	}
+
+	ignition, err := buildIgnitionConfig(config.Spec.Ignition, newNodeFacts(n))
+	if err != nil {
+		return nil, fmt.Errorf("configuration %s: %w", config.GetName(), err)
+	}
+
+	return json.Marshal(ignition)
+}
+
+// buildIgnitionConfig renders an Ignition option into a config document
+func buildIgnitionConfig(spec *bootconfiguration.Ignition, facts nodeFacts) (map[string]interface{}, error) {
+	raw := []byte(spec.Config)
+	if spec.Template != "" {
+		tmpl, err := template.New("ignition").Option("missingkey=error").Parse(spec.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ignition template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, facts); err != nil {
+			return nil, fmt.Errorf("executing ignition template: %w", err)
+		}
+		raw = buf.Bytes()
+	}
+
+	config := map[string]interface{}{
+		"ignition": map[string]interface{}{"version": bootconfiguration.DefaultIgnitionVersion},
+	}
+	if len(raw) > 0 {
+		config = nil
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, fmt.Errorf("ignition config is not a JSON object: %w", err)
+		}
+	}
+
+	section, _ := config["ignition"].(map[string]interface{})
+	version, _ := section["version"].(string)
+	if !validation.ValidateIgnitionVersion(version) {
+		return nil, fmt.Errorf("unsupported ignition spec version %q", version)
+	}
+
+	if len(spec.Merge) == 0 && spec.Replace == nil {
+		return config, nil
+	}
+
+	references, _ := section["config"].(map[string]interface{})
+	if references == nil {
+		references = make(map[string]interface{})
+	}
+	if spec.Replace != nil {
+		references["replace"] = ignitionReference(*spec.Replace)
+	}
+	merge, _ := references["merge"].([]interface{})
+	for _, reference := range spec.Merge {
+		merge = append(merge, ignitionReference(reference))
+	}
+	if len(merge) > 0 {
+		references["merge"] = merge
+	}
+	section["config"] = references
+
+	return config, nil
+}
+
+// ignitionReference converts a reference to its Ignition config form
+func ignitionReference(reference bootconfiguration.IgnitionReference) map[string]interface{} {
+	resource := map[string]interface{}{"source": reference.Source}
+	if reference.Hash != "" {
+		resource["verification"] = map[string]interface{}{"hash": reference.Hash}
+	}
+	return resource
+}
+
+// withIgnitionParam points Ignition at the node's config when the configuration
+// has one, unless the params already set a config URL or no base URL is configured
+func (c *BootScriptController) withIgnitionParam(params string, config *bootconfiguration.BootConfiguration, n *node.Node) string {
+	baseURL := strings.TrimSuffix(c.config.Ignition.BaseURL, "/")
+	if baseURL == "" || config.Spec.Ignition == nil || strings.Contains(params, "ignition.config.url=") {
+		return params
+	}
+
+	param := fmt.Sprintf("ignition.config.url=%s/ignition/%s", baseURL, nodeIdentifierOf(n))
+	if params == "" {
+		return param
+	}
+	return params + " " + param
+}
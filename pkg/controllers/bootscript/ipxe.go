@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html/template"
 	"strings"
+	"time"
 
 	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
 	"github.com/openchami/boot-service/pkg/resources/node"
@@ -22,8 +23,16 @@ func (c *BootScriptController) buildIPXEScript(config *bootconfiguration.BootCon
 	// Use default template if no custom template is specified
 	tmplContent := DefaultIPXETemplate
 
+	// Template helpers bound to this node
+	funcs := template.FuncMap{
+		// stagger returns a deterministic delay in [0, window) seconds from the node's NID
+		"stagger": func(window int) int {
+			return staggerDelay(node.Spec.NID, window)
+		},
+	}
+
 	// Parse and execute template
-	tmpl, err := template.New("ipxe").Parse(tmplContent)
+	tmpl, err := template.New("ipxe").Funcs(funcs).Parse(tmplContent)
 	if err != nil {
 		return "", fmt.Errorf("parsing iPXE template: %w", err)
 	}
@@ -51,7 +60,7 @@ func (c *BootScriptController) prepareTemplateVars(config *bootconfiguration.Boo
 		// Boot configuration
 		"Kernel":   config.Spec.Kernel,
 		"Initrd":   config.Spec.Initrd,
-		"Params":   config.Spec.Params,
+		"Params":   c.withIgnitionParam(c.withCloudInitParam(config.Spec.Params, node), config, node),
 		"Priority": config.Spec.Priority,
 
 		// Configuration metadata
@@ -61,6 +70,9 @@ func (c *BootScriptController) prepareTemplateVars(config *bootconfiguration.Boo
 		// Additional derived values
 		"KernelFilename": extractFilename(config.Spec.Kernel),
 		"InitrdFilename": extractFilename(config.Spec.Initrd),
+
+		// Boot storm staggering window in seconds (0 disables)
+		"StaggerWindow": int(c.config.Admission.StaggerWindow / time.Second),
 	}
 
 	return vars
@@ -90,6 +102,12 @@ echo Role: {{.Role}}{{if .Groups}}, Groups: {{.Groups}}{{end}}
 
 # Configure network interface
 dhcp
+{{- if .StaggerWindow}}
+
+# Stagger downloads across the boot window
+echo Waiting {{stagger .StaggerWindow}} seconds before downloading...
+sleep {{stagger .StaggerWindow}}
+{{- end}}
 
 # Set boot parameters
 set kernel {{.Kernel}}
@@ -131,6 +149,75 @@ echo Trying default boot methods...
 boot
 `
 
+// DiscoveryIPXETemplate is used for unknown nodes when discovery mode is enabled.
+// It posts the facts iPXE knows about the hardware to the registration endpoint,
+// which answers with a script that waits for operator approval.
+const DiscoveryIPXETemplate = `#!ipxe
+# Discovery iPXE Boot Script
+# Node: {{.Identifier}}
+
+echo Boot service does not know node {{.Identifier}}
+echo Registering node for operator approval...
+
+# Configure network
+dhcp
+
+# Report hardware facts
+params
+param mac ${mac}
+param uuid ${uuid}
+param serial ${serial}
+param manufacturer ${manufacturer}
+param product ${product}
+param platform ${platform}
+param buildarch ${buildarch}
+
+:register
+chain --autofree {{.RegistrationURL}}##params || goto retry
+
+:retry
+echo Registration failed, retrying in {{.RetryDelay}} seconds...
+sleep {{.RetryDelay}}
+goto register
+`
+
+// DeferIPXETemplate is used when a node is over its boot admission budget.
+// It waits for a jittered interval and then asks for its boot script again.
+const DeferIPXETemplate = `#!ipxe
+# Deferred iPXE Boot Script
+# Node: {{.Identifier}}
+
+echo Boot of {{.Identifier}} deferred: {{.Budget}} boot budget exhausted
+echo Retrying in {{.Delay}} seconds...
+sleep {{.Delay}}
+chain --autofree {{.ChainURL}}
+`
+
+// HaltIPXETemplate is used when the state policy halts a node, e.g., one
+// disabled in HSM
+const HaltIPXETemplate = `#!ipxe
+# Halted iPXE Boot Script
+# Node: {{.Identifier}}
+
+echo Boot of {{.Identifier}} halted by state policy
+echo Reason: {{.Reason}}
+
+# Halt system instead of booting
+halt
+`
+
+// LocalBootIPXETemplate is used when the state policy sends a node to its
+// local disk. Exiting iPXE lets the firmware try the next boot device.
+const LocalBootIPXETemplate = `#!ipxe
+# Local Boot iPXE Boot Script
+# Node: {{.Identifier}}
+
+echo Boot of {{.Identifier}} sent to local disk by state policy
+echo Reason: {{.Reason}}
+
+exit
+`
+
 // ErrorIPXETemplate is used when there are errors in script generation
 const ErrorIPXETemplate = `#!ipxe
 # Error iPXE Boot Script
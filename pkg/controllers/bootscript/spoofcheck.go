@@ -0,0 +1,276 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// Spoof check modes
+const (
+	SpoofCheckOff    = "off"    // requesters are not verified
+	SpoofCheckLog    = "log"    // mismatches are counted and logged
+	SpoofCheckCount  = "count"  // mismatches are only counted
+	SpoofCheckReject = "reject" // mismatches are counted, logged and refused
+)
+
+// ErrRequesterMismatch is returned when a boot script request is refused because
+// the requester's address does not belong to the node it asked for
+var ErrRequesterMismatch = errors.New("requester address does not belong to the requested node")
+
+// SpoofCheckConfig holds requester verification settings.
+// When enabled, the address a boot script request came from must be one of the
+// identified node's interface IPs, or fall inside a relay subnet, so a host on
+// the boot network cannot fetch another node's script by asking for its MAC.
+type SpoofCheckConfig struct {
+	// Mode is one of "off", "log", "count" or "reject"
+	Mode string `yaml:"mode"`
+
+	// RelaySubnets are CIDRs (e.g., DHCP relays or proxies) whose requests are
+	// accepted for any node
+	RelaySubnets []string `yaml:"relay_subnets"`
+}
+
+// DefaultSpoofCheckConfig returns the default spoof check configuration (off)
+func DefaultSpoofCheckConfig() SpoofCheckConfig {
+	return SpoofCheckConfig{
+		Mode: SpoofCheckOff,
+	}
+}
+
+// SpoofCheckStats provides requester verification metrics
+type SpoofCheckStats struct {
+	Mode         string `json:"mode"`
+	Checked      uint64 `json:"checked"`
+	Relayed      uint64 `json:"relayed"`
+	Mismatches   uint64 `json:"mismatches"`
+	Rejected     uint64 `json:"rejected"`
+	LastMismatch string `json:"lastMismatch,omitempty"`
+}
+
+// requesterIPKey is the context key for the requester's IP address
+type requesterIPKey struct{}
+
+// WithRequesterIP returns a context carrying the IP address a boot script request came from
+func WithRequesterIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, requesterIPKey{}, ip)
+}
+
+// requesterIP returns the requester's IP address from the context, if known
+func requesterIP(ctx context.Context) string {
+	ip, _ := ctx.Value(requesterIPKey{}).(string)
+	return ip
+}
+
+// RequesterIP returns the IP address a request came from
+func RequesterIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// IPResolver is implemented by controllers that can identify a node from the
+// IP address a request came from
+type IPResolver interface {
+	ResolveNodeByIP(ctx context.Context, ip string) (string, error)
+}
+
+// IdentifyRequester returns the node a request that does not name one is for:
+// the node given by ?mac=, or else the node with the requester's IP address
+func IdentifyRequester(r *http.Request, resolver IPResolver) (string, error) {
+	if mac := r.URL.Query().Get("mac"); mac != "" {
+		return mac, nil
+	}
+
+	ip := RequesterIP(r)
+	identifier, err := resolver.ResolveNodeByIP(r.Context(), ip)
+	if err != nil {
+		return "", fmt.Errorf("requester %s not identified: %w", ip, err)
+	}
+	return identifier, nil
+}
+
+// spoofChecker verifies that requesters are the nodes they ask for
+type spoofChecker struct {
+	mu        sync.Mutex
+	mode      string
+	relays    []*net.IPNet
+	addresses map[string][]net.IP // node cache key -> interface addresses
+	stats     SpoofCheckStats
+}
+
+// newSpoofChecker creates a spoof checker, skipping relay subnets that do not parse
+func newSpoofChecker(config SpoofCheckConfig, logger *log.Logger) *spoofChecker {
+	mode := strings.ToLower(config.Mode)
+	switch mode {
+	case SpoofCheckLog, SpoofCheckCount, SpoofCheckReject:
+	default:
+		mode = SpoofCheckOff
+	}
+
+	var relays []*net.IPNet
+	for _, subnet := range config.RelaySubnets {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(subnet))
+		if err != nil {
+			logger.Printf("Warning: ignoring invalid relay subnet %q: %v", subnet, err)
+			continue
+		}
+		relays = append(relays, network)
+	}
+
+	return &spoofChecker{
+		mode:      mode,
+		relays:    relays,
+		addresses: make(map[string][]net.IP),
+		stats:     SpoofCheckStats{Mode: mode},
+	}
+}
+
+// enabled reports whether requesters are verified
+func (s *spoofChecker) enabled() bool {
+	return s.mode != SpoofCheckOff
+}
+
+// remember records the interface addresses of a resolved node
+func (s *spoofChecker) remember(nodeKey string, n *node.Node) {
+	if !s.enabled() {
+		return
+	}
+
+	var addresses []net.IP
+	for _, iface := range n.Spec.Interfaces {
+		if ip := net.ParseIP(iface.IP); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addresses[nodeKey] = addresses
+}
+
+// check verifies a requester against a node's known addresses. It returns a
+// description of the mismatch, or "" when the requester is accepted.
+func (s *spoofChecker) check(nodeKey, requester string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Checked++
+	ip := net.ParseIP(requester)
+	addresses := s.addresses[nodeKey]
+
+	var mismatch string
+	switch {
+	case ip == nil:
+		mismatch = fmt.Sprintf("requester address %q is not an IP address", requester)
+	case s.relayed(ip):
+		s.stats.Relayed++
+		return ""
+	case containsIP(addresses, ip):
+		return ""
+	case len(addresses) == 0:
+		mismatch = fmt.Sprintf("requester %s cannot be verified: node %s has no known interface addresses", requester, nodeKey)
+	default:
+		mismatch = fmt.Sprintf("requester %s is not an interface of node %s", requester, nodeKey)
+	}
+
+	s.stats.Mismatches++
+	s.stats.LastMismatch = mismatch
+	if s.mode == SpoofCheckReject {
+		s.stats.Rejected++
+	}
+	return mismatch
+}
+
+// relayed reports whether an address is inside a relay subnet
+func (s *spoofChecker) relayed(ip net.IP) bool {
+	for _, relay := range s.relays {
+		if relay.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsIP reports whether ip is one of addresses
+func containsIP(addresses []net.IP, ip net.IP) bool {
+	for _, address := range addresses {
+		if address.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// statsSnapshot returns requester verification metrics
+func (s *spoofChecker) statsSnapshot() SpoofCheckStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// verifyRequester checks the requester recorded in ctx against the node's
+// interfaces. Requests without a known requester are not checked.
+func (c *BootScriptController) verifyRequester(ctx context.Context, nodeKey string) error {
+	requester := requesterIP(ctx)
+	if !c.spoofCheck.enabled() || requester == "" {
+		return nil
+	}
+
+	mismatch := c.spoofCheck.check(nodeKey, requester)
+	if mismatch == "" {
+		return nil
+	}
+
+	switch c.spoofCheck.mode {
+	case SpoofCheckLog:
+		c.logger.Printf("Spoof check: %s", mismatch)
+	case SpoofCheckReject:
+		c.logger.Printf("Spoof check: rejecting boot script request: %s", mismatch)
+		return fmt.Errorf("%w: %s", ErrRequesterMismatch, mismatch)
+	}
+	return nil
+}
+
+// resolveRequester resolves the node a request for one of its boot endpoints
+// names and verifies the requester. Cloud-init data and Ignition configs carry
+// secrets, so they are held to the same requester checks as boot scripts, and
+// pending and removed nodes are not found.
+func (c *BootScriptController) resolveRequester(ctx context.Context, identifier, endpoint string) (*node.Node, error) {
+	n, err := c.resolveNode(ctx, c.parseNodeIdentifier(identifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeNotFound, err)
+	}
+	if err := servable(n); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNodeNotFound, identifier, err)
+	}
+
+	nodeKey := c.generateCacheKey(n)
+	c.spoofCheck.remember(nodeKey, n)
+	if err := c.verifyRequester(ctx, nodeKey); err != nil {
+		return nil, err
+	}
+	c.recordAccess(nodeKey, endpoint)
+	return n, nil
+}
+
+// flightKey returns the coalescing key for an identifier. When requesters are
+// verified, each requester resolves separately so every caller is checked.
+func (c *BootScriptController) flightKey(ctx context.Context, alias string) string {
+	if c.spoofCheck.enabled() {
+		return "id:" + alias + "|from:" + requesterIP(ctx)
+	}
+	return "id:" + alias
+}
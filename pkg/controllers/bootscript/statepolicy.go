@@ -0,0 +1,211 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package bootscript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// State policy actions decide how a node boots given its HSM component state
+const (
+	StateActionBoot   = "boot"   // Boot the node's best matching configuration
+	StateActionHalt   = "halt"   // Serve a script that halts the node
+	StateActionLocal  = "local"  // Exit iPXE so the firmware boots the next device, e.g., the local disk
+	StateActionConfig = "config" // Boot the named configuration, e.g., diagnostics
+)
+
+// StatePolicyRule matches nodes by the HSM component state recorded in their
+// status. Every listed attribute must match; states and flags are compared
+// without regard to case.
+type StatePolicyRule struct {
+	States  []string `yaml:"states" mapstructure:"states"`
+	Flags   []string `yaml:"flags" mapstructure:"flags"`
+	Enabled *bool    `yaml:"enabled" mapstructure:"enabled"`
+
+	// Action is one of "boot", "halt", "local" or "config"
+	Action string `yaml:"action" mapstructure:"action"`
+	// Config names the boot configuration of the "config" action
+	Config string `yaml:"config" mapstructure:"config"`
+}
+
+// StatePolicyConfig controls boot decisions based on HSM component state.
+// Rules are evaluated in order and the first match decides. Nodes that match
+// no rule, or have no HSM state, boot normally.
+type StatePolicyConfig struct {
+	Rules []StatePolicyRule `yaml:"rules"`
+}
+
+// DefaultStatePolicyConfig returns the default state policy, which halts
+// components disabled in HSM
+func DefaultStatePolicyConfig() StatePolicyConfig {
+	disabled := false
+	return StatePolicyConfig{
+		Rules: []StatePolicyRule{
+			{Enabled: &disabled, Action: StateActionHalt},
+		},
+	}
+}
+
+// Validate checks that every rule has a known action
+func (c StatePolicyConfig) Validate() error {
+	for i, rule := range c.Rules {
+		switch rule.Action {
+		case StateActionBoot, StateActionHalt, StateActionLocal:
+		case StateActionConfig:
+			if rule.Config == "" {
+				return fmt.Errorf("rule %d: config is required for the %s action", i, StateActionConfig)
+			}
+		default:
+			return fmt.Errorf("rule %d: unknown action %q", i, rule.Action)
+		}
+	}
+	return nil
+}
+
+// StateDecision is the outcome of the state policy for a node
+type StateDecision struct {
+	Action string `json:"action"`
+	Config string `json:"config,omitempty"`
+	Rule   int    `json:"rule"` // Index of the matching rule, or -1
+	Reason string `json:"reason"`
+}
+
+// matches reports whether a rule matches a node's HSM component state
+func (r StatePolicyRule) matches(status node.NodeStatus) bool {
+	if len(r.States) > 0 && !containsFold(r.States, status.HSMState) {
+		return false
+	}
+	if len(r.Flags) > 0 && !containsFold(r.Flags, status.HSMFlag) {
+		return false
+	}
+	if r.Enabled != nil && (status.HSMEnabled == nil || *status.HSMEnabled != *r.Enabled) {
+		return false
+	}
+	return true
+}
+
+// describeComponentState summarizes a node's HSM component state for decisions and logs
+func describeComponentState(status node.NodeStatus) string {
+	enabled := "unknown"
+	if status.HSMEnabled != nil {
+		enabled = fmt.Sprint(*status.HSMEnabled)
+	}
+	return fmt.Sprintf("state=%s flag=%s enabled=%s", status.HSMState, status.HSMFlag, enabled)
+}
+
+// containsFold reports whether values contains value without regard to case
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// stateDecision applies the state policy to a node
+func (c *BootScriptController) stateDecision(n *node.Node) StateDecision {
+	if n.Status.HSMState == "" && n.Status.HSMFlag == "" && n.Status.HSMEnabled == nil {
+		return StateDecision{Action: StateActionBoot, Rule: -1, Reason: "no HSM state recorded"}
+	}
+
+	state := describeComponentState(n.Status)
+	for i, rule := range c.config.StatePolicy.Rules {
+		if rule.matches(n.Status) {
+			return StateDecision{
+				Action: rule.Action,
+				Config: rule.Config,
+				Rule:   i,
+				Reason: fmt.Sprintf("HSM %s matches state policy rule %d", state, i),
+			}
+		}
+	}
+	return StateDecision{Action: StateActionBoot, Rule: -1, Reason: fmt.Sprintf("HSM %s matches no state policy rule", state)}
+}
+
+// ErrNotBooting is returned for the boot data of a node the state policy halts
+// or sends to its local disk
+var ErrNotBooting = errors.New("node does not boot a configuration under the state policy")
+
+// nodeBootConfiguration finds the configuration a node boots under the state policy
+func (c *BootScriptController) nodeBootConfiguration(ctx context.Context, n *node.Node, decision StateDecision) (*bootconfiguration.BootConfiguration, error) {
+	switch decision.Action {
+	case StateActionHalt, StateActionLocal:
+		return nil, fmt.Errorf("%w: %s", ErrNotBooting, decision.Action)
+	case StateActionConfig:
+		configs, err := c.client.GetBootConfigurations(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting boot configurations: %w", err)
+		}
+		for i := range configs {
+			if configs[i].GetName() == decision.Config {
+				return &configs[i], nil
+			}
+		}
+		return nil, fmt.Errorf("state policy configuration %s not found", decision.Config)
+	default:
+		return c.findBootConfiguration(ctx, n)
+	}
+}
+
+// generateStateScript creates the script of a node the state policy keeps from booting
+func (c *BootScriptController) generateStateScript(identifier string, decision StateDecision) string {
+	script := HaltIPXETemplate
+	if decision.Action == StateActionLocal {
+		script = LocalBootIPXETemplate
+	}
+	script = strings.ReplaceAll(script, "{{.Identifier}}", identifier)
+	script = strings.ReplaceAll(script, "{{.Reason}}", decision.Reason)
+
+	return script
+}
+
+// Explanation describes how the boot service decides what a node boots
+type Explanation struct {
+	Identifier    string        `json:"identifier"`
+	XName         string        `json:"xname"`
+	HSMState      string        `json:"hsmState,omitempty"`
+	HSMFlag       string        `json:"hsmFlag,omitempty"`
+	HSMEnabled    *bool         `json:"hsmEnabled,omitempty"`
+	Decision      StateDecision `json:"decision"`
+	Configuration string        `json:"configuration,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// Explain reports the state policy decision and boot configuration for a
+// node without rendering a script or counting a boot
+func (c *BootScriptController) Explain(ctx context.Context, identifier string) (*Explanation, error) {
+	n, err := c.resolveNode(ctx, c.parseNodeIdentifier(identifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNodeNotFound, err)
+	}
+
+	decision := c.stateDecision(n)
+	explanation := &Explanation{
+		Identifier: identifier,
+		XName:      n.Spec.XName,
+		HSMState:   n.Status.HSMState,
+		HSMFlag:    n.Status.HSMFlag,
+		HSMEnabled: n.Status.HSMEnabled,
+		Decision:   decision,
+	}
+	if err := servable(n); err != nil {
+		explanation.Error = err.Error()
+		return explanation, nil
+	}
+	if decision.Action == StateActionHalt || decision.Action == StateActionLocal {
+		return explanation, nil
+	}
+
+	config, err := c.nodeBootConfiguration(ctx, n, decision)
+	if err != nil {
+		explanation.Error = err.Error()
+		return explanation, nil
+	}
+	explanation.Configuration = config.GetName()
+	return explanation, nil
+}
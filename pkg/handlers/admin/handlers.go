@@ -0,0 +1,138 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package admin provides operator endpoints for inspecting and managing the boot service
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+)
+
+// CacheController exposes the boot script cache of a controller
+type CacheController interface {
+	CacheStats() bootscript.CacheStats
+	InvalidateNode(identifier string) int
+	InvalidateConfig(name string) int
+	ClearCache()
+}
+
+// Controller exposes the boot script controller state managed by the admin API
+type Controller interface {
+	CacheController
+	AdmissionStats() bootscript.AdmissionStats
+	SpoofCheckStats() bootscript.SpoofCheckStats
+	Explain(ctx context.Context, identifier string) (*bootscript.Explanation, error)
+}
+
+// Handler handles admin API requests
+type Handler struct {
+	controller Controller
+	logger     *log.Logger
+}
+
+// NewHandler creates a new admin handler
+func NewHandler(controller Controller, logger *log.Logger) *Handler {
+	return &Handler{
+		controller: controller,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers admin routes
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Route("/cache", func(r chi.Router) {
+			r.Get("/", h.GetCacheStats)
+			r.Delete("/", h.FlushCache)
+			r.Delete("/nodes/{node}", h.FlushNode)
+			r.Delete("/configs/{config}", h.FlushConfig)
+		})
+		r.Get("/admission", h.GetAdmissionStats)
+		r.Get("/spoofcheck", h.GetSpoofCheckStats)
+		r.Get("/explain/{node}", h.Explain)
+	})
+}
+
+// FlushResponse reports how many cache entries a flush removed
+type FlushResponse struct {
+	Removed int `json:"removed"`
+}
+
+// GetCacheStats handles GET /admin/cache
+func (h *Handler) GetCacheStats(w http.ResponseWriter, r *http.Request) { //nolint:revive
+	h.writeJSON(w, http.StatusOK, h.controller.CacheStats())
+}
+
+// FlushCache handles DELETE /admin/cache
+func (h *Handler) FlushCache(w http.ResponseWriter, r *http.Request) { //nolint:revive
+	removed := h.controller.CacheStats().TotalEntries
+	h.controller.ClearCache()
+
+	h.logger.Printf("Flushed boot script cache (%d entries)", removed)
+	h.writeJSON(w, http.StatusOK, FlushResponse{Removed: removed})
+}
+
+// FlushNode handles DELETE /admin/cache/nodes/{node}.
+// The node may be given by xname or by any identifier it booted with.
+func (h *Handler) FlushNode(w http.ResponseWriter, r *http.Request) {
+	nodeID := chi.URLParam(r, "node")
+	removed := h.controller.InvalidateNode(nodeID)
+
+	h.logger.Printf("Flushed boot script cache for node %s (%d entries)", nodeID, removed)
+	h.writeJSON(w, http.StatusOK, FlushResponse{Removed: removed})
+}
+
+// FlushConfig handles DELETE /admin/cache/configs/{config}
+func (h *Handler) FlushConfig(w http.ResponseWriter, r *http.Request) {
+	configName := chi.URLParam(r, "config")
+	removed := h.controller.InvalidateConfig(configName)
+
+	h.logger.Printf("Flushed boot script cache for configuration %s (%d entries)", configName, removed)
+	h.writeJSON(w, http.StatusOK, FlushResponse{Removed: removed})
+}
+
+// GetAdmissionStats handles GET /admin/admission
+func (h *Handler) GetAdmissionStats(w http.ResponseWriter, r *http.Request) { //nolint:revive
+	h.writeJSON(w, http.StatusOK, h.controller.AdmissionStats())
+}
+
+// GetSpoofCheckStats handles GET /admin/spoofcheck
+func (h *Handler) GetSpoofCheckStats(w http.ResponseWriter, r *http.Request) { //nolint:revive
+	h.writeJSON(w, http.StatusOK, h.controller.SpoofCheckStats())
+}
+
+// Explain handles GET /admin/explain/{node}, reporting how the node's boot
+// script would be decided without serving it
+func (h *Handler) Explain(w http.ResponseWriter, r *http.Request) {
+	nodeID := chi.URLParam(r, "node")
+	explanation, err := h.controller.Explain(r.Context(), nodeID)
+	switch {
+	case errors.Is(err, bootscript.ErrNodeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		h.logger.Printf("Error explaining boot of node %s: %v", nodeID, err)
+		http.Error(w, "failed to explain boot", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, explanation)
+}
+
+// Helper methods
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Printf("Error encoding JSON response: %v", err)
+	}
+}
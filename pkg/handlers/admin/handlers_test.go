@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+)
+
+// fakeCacheController records flush calls and reports fixed statistics
+type fakeCacheController struct {
+	entries       int
+	flushedNode   string
+	flushedConfig string
+}
+
+func (f *fakeCacheController) CacheStats() bootscript.CacheStats {
+	return bootscript.CacheStats{TotalEntries: f.entries, Hits: 7, Misses: 3}
+}
+
+func (f *fakeCacheController) InvalidateNode(identifier string) int {
+	f.flushedNode = identifier
+	return 1
+}
+
+func (f *fakeCacheController) InvalidateConfig(name string) int {
+	f.flushedConfig = name
+	return 2
+}
+
+func (f *fakeCacheController) ClearCache() {
+	f.entries = 0
+}
+
+func (f *fakeCacheController) AdmissionStats() bootscript.AdmissionStats {
+	return bootscript.AdmissionStats{Enabled: true, Booting: 4, Deferred: 9}
+}
+
+func (f *fakeCacheController) SpoofCheckStats() bootscript.SpoofCheckStats {
+	return bootscript.SpoofCheckStats{Mode: "reject", Mismatches: 2, Rejected: 2}
+}
+
+func (f *fakeCacheController) Explain(ctx context.Context, identifier string) (*bootscript.Explanation, error) { //nolint:revive
+	if identifier != "x1000c0s0b0n0" {
+		return nil, fmt.Errorf("%w: %s", bootscript.ErrNodeNotFound, identifier)
+	}
+	return &bootscript.Explanation{
+		Identifier: identifier,
+		XName:      identifier,
+		HSMState:   "Ready",
+		Decision:   bootscript.StateDecision{Action: bootscript.StateActionHalt, Rule: 0, Reason: "disabled"},
+	}, nil
+}
+
+func TestCacheEndpoints(t *testing.T) {
+	controller := &fakeCacheController{entries: 5}
+	r := chi.NewRouter()
+	NewHandler(controller, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	do := func(method, path string, out interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d", method, path, resp.StatusCode)
+		}
+		json.NewDecoder(resp.Body).Decode(out) //nolint:errcheck
+	}
+
+	var stats bootscript.CacheStats
+	do(http.MethodGet, "/admin/cache", &stats)
+	if stats.TotalEntries != 5 || stats.Hits != 7 || stats.Misses != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	var flushed FlushResponse
+	do(http.MethodDelete, "/admin/cache/nodes/x1000c0s0b0n0", &flushed)
+	if controller.flushedNode != "x1000c0s0b0n0" || flushed.Removed != 1 {
+		t.Errorf("Node flush not applied: %q, %+v", controller.flushedNode, flushed)
+	}
+
+	do(http.MethodDelete, "/admin/cache/configs/compute", &flushed)
+	if controller.flushedConfig != "compute" || flushed.Removed != 2 {
+		t.Errorf("Config flush not applied: %q, %+v", controller.flushedConfig, flushed)
+	}
+
+	do(http.MethodDelete, "/admin/cache", &flushed)
+	if controller.entries != 0 || flushed.Removed != 5 {
+		t.Errorf("Full flush not applied: %+v", flushed)
+	}
+
+	var admission bootscript.AdmissionStats
+	do(http.MethodGet, "/admin/admission", &admission)
+	if !admission.Enabled || admission.Booting != 4 || admission.Deferred != 9 {
+		t.Errorf("Unexpected admission stats: %+v", admission)
+	}
+
+	var spoofCheck bootscript.SpoofCheckStats
+	do(http.MethodGet, "/admin/spoofcheck", &spoofCheck)
+	if spoofCheck.Mode != "reject" || spoofCheck.Rejected != 2 {
+		t.Errorf("Unexpected spoof check stats: %+v", spoofCheck)
+	}
+
+	var explanation bootscript.Explanation
+	do(http.MethodGet, "/admin/explain/x1000c0s0b0n0", &explanation)
+	if explanation.XName != "x1000c0s0b0n0" || explanation.Decision.Action != bootscript.StateActionHalt {
+		t.Errorf("Unexpected explanation: %+v", explanation)
+	}
+
+	resp, err := http.Get(server.URL + "/admin/explain/unknown")
+	if err != nil {
+		t.Fatalf("GET /admin/explain/unknown failed: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown node, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package cloudinit serves cloud-init NoCloud datasource documents for nodes
+package cloudinit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+)
+
+// Controller renders cloud-init documents and identifies requesting nodes
+type Controller interface {
+	RenderCloudInit(ctx context.Context, identifier string) (*bootscript.NodeCloudInit, error)
+	ResolveNodeByIP(ctx context.Context, ip string) (string, error)
+}
+
+// NoCloud document names
+const (
+	DocumentMetaData      = "meta-data"
+	DocumentUserData      = "user-data"
+	DocumentVendorData    = "vendor-data"
+	DocumentNetworkConfig = "network-config"
+)
+
+// Handler handles cloud-init datasource requests
+type Handler struct {
+	controller Controller
+	logger     *log.Logger
+}
+
+// NewHandler creates a new cloud-init handler
+func NewHandler(controller Controller, logger *log.Logger) *Handler {
+	return &Handler{
+		controller: controller,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers cloud-init routes. Nodes that cannot name themselves
+// may omit the node segment and are identified by ?mac= or their requester IP.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/cloud-init", func(r chi.Router) {
+		r.Get("/{document}", h.GetRequesterDocument)
+		r.Get("/{node}/{document}", h.GetNodeDocument)
+	})
+}
+
+// GetNodeDocument handles GET /cloud-init/{node}/{document}.
+// The node may be given by xname, NID, MAC or hostname.
+func (h *Handler) GetNodeDocument(w http.ResponseWriter, r *http.Request) {
+	h.serveDocument(w, r, chi.URLParam(r, "node"), chi.URLParam(r, "document"))
+}
+
+// GetRequesterDocument handles GET /cloud-init/{document}
+func (h *Handler) GetRequesterDocument(w http.ResponseWriter, r *http.Request) {
+	identifier, err := bootscript.IdentifyRequester(r, h.controller)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.serveDocument(w, r, identifier, chi.URLParam(r, "document"))
+}
+
+// serveDocument renders and writes one NoCloud document for a node
+func (h *Handler) serveDocument(w http.ResponseWriter, r *http.Request, identifier, document string) {
+	switch document {
+	case DocumentMetaData, DocumentUserData, DocumentVendorData, DocumentNetworkConfig:
+	default:
+		http.Error(w, fmt.Sprintf("unknown cloud-init document %q", document), http.StatusNotFound)
+		return
+	}
+
+	ctx := bootscript.WithRequesterIP(r.Context(), bootscript.RequesterIP(r))
+	cloudInit, err := h.controller.RenderCloudInit(ctx, identifier)
+	switch {
+	case errors.Is(err, bootscript.ErrNodeNotFound), errors.Is(err, bootscript.ErrNotBooting):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, bootscript.ErrRequesterMismatch):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		h.logger.Printf("Failed to render cloud-init for %s: %v", identifier, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var body []byte
+	switch document {
+	case DocumentMetaData:
+		body = cloudInit.MetaData
+	case DocumentUserData:
+		body = cloudInit.UserData
+	case DocumentVendorData:
+		body = cloudInit.VendorData
+	case DocumentNetworkConfig:
+		body = cloudInit.NetworkConfig
+	}
+
+	// Documents no configuration provides are not found; cloud-init skips
+	// the optional vendor-data and network-config when they are missing
+	if body == nil {
+		http.Error(w, fmt.Sprintf("no %s configured for node %s", document, identifier), http.StatusNotFound)
+		return
+	}
+
+	h.logger.Printf("Served cloud-init %s for node %s", document, identifier)
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body) //nolint:errcheck
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package cloudinit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+	"github.com/openchami/boot-service/pkg/handlers/discovery"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// fakeController renders fixed documents for one node known by xname, MAC and IP
+type fakeController struct {
+	spoofed bool // reject every request as spoofed
+}
+
+func (f *fakeController) RenderCloudInit(ctx context.Context, identifier string) (*bootscript.NodeCloudInit, error) { //nolint:revive
+	if f.spoofed {
+		return nil, bootscript.ErrRequesterMismatch
+	}
+	if identifier == "x1000c0s0b0n3" {
+		return nil, fmt.Errorf("%w: halt", bootscript.ErrNotBooting)
+	}
+	if identifier != "x1000c0s0b0n0" && identifier != "aa:bb:cc:dd:ee:01" {
+		return nil, fmt.Errorf("%w: %s", bootscript.ErrNodeNotFound, identifier)
+	}
+	return &bootscript.NodeCloudInit{
+		Node:     &node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n0"}},
+		MetaData: []byte("instance-id: x1000c0s0b0n0\n"),
+		UserData: []byte("#cloud-config\n"),
+	}, nil
+}
+
+func (f *fakeController) ResolveNodeByIP(ctx context.Context, ip string) (string, error) { //nolint:revive
+	if ip == "10.1.0.1" {
+		return "x1000c0s0b0n0", nil
+	}
+	return "", fmt.Errorf("no node has interface address %s", ip)
+}
+
+func TestCloudInitEndpoints(t *testing.T) {
+	controller := &fakeController{}
+	r := chi.NewRouter()
+	NewHandler(controller, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	get := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		status     int
+		body       string
+	}{
+		{"Meta-data by xname", "/cloud-init/x1000c0s0b0n0/meta-data", "192.0.2.1:1234", http.StatusOK, "instance-id: x1000c0s0b0n0\n"},
+		{"User-data by MAC", "/cloud-init/aa:bb:cc:dd:ee:01/user-data", "192.0.2.1:1234", http.StatusOK, "#cloud-config\n"},
+		{"User-data by MAC query", "/cloud-init/user-data?mac=aa:bb:cc:dd:ee:01", "192.0.2.1:1234", http.StatusOK, "#cloud-config\n"},
+		{"Meta-data by requester IP", "/cloud-init/meta-data", "10.1.0.1:1234", http.StatusOK, "instance-id: x1000c0s0b0n0\n"},
+		{"Unknown requester IP", "/cloud-init/meta-data", "10.1.0.2:1234", http.StatusNotFound, ""},
+		{"Unknown node", "/cloud-init/x9999c0s0b0n0/meta-data", "192.0.2.1:1234", http.StatusNotFound, ""},
+		{"Halted by state policy", "/cloud-init/x1000c0s0b0n3/user-data", "192.0.2.1:1234", http.StatusNotFound, ""},
+		{"Unconfigured vendor-data", "/cloud-init/x1000c0s0b0n0/vendor-data", "192.0.2.1:1234", http.StatusNotFound, ""},
+		{"Unknown document", "/cloud-init/x1000c0s0b0n0/secrets", "192.0.2.1:1234", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.path, tt.remoteAddr)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, rec.Body.String())
+			}
+		})
+	}
+
+	controller.spoofed = true
+	if rec := get("/cloud-init/x1000c0s0b0n0/user-data", "192.0.2.1:1234"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for spoofed requester, got %d", rec.Code)
+	}
+}
+
+// TestPendingNodeUserData registers an unknown MAC through discovery and checks
+// that the pending node is not handed the user-data of a catch-all configuration
+func TestPendingNodeUserData(t *testing.T) {
+	var mu sync.Mutex
+	nodes := map[string]*node.Node{}
+	backend := chi.NewRouter()
+	backend.Get("/nodes", func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		list := []node.Node{}
+		for _, n := range nodes {
+			list = append(list, *n)
+		}
+		json.NewEncoder(w).Encode(list) //nolint:errcheck
+	})
+	backend.Post("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateNodeRequest
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		mu.Lock()
+		defer mu.Unlock()
+		n := &node.Node{Spec: req.NodeSpec}
+		n.Metadata.Name = req.Name
+		n.Metadata.UID = fmt.Sprintf("nod-%04d", len(nodes)+1)
+		nodes[n.Metadata.UID] = n
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	backend.Put("/nodes/{uid}/status", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		n := nodes[chi.URLParam(r, "uid")]
+		json.NewDecoder(r.Body).Decode(&n.Status) //nolint:errcheck
+		json.NewEncoder(w).Encode(n)              //nolint:errcheck
+	})
+	backend.Get("/bootconfigurations", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode([]bootconfiguration.BootConfiguration{{ //nolint:errcheck
+			Spec: bootconfiguration.BootConfigurationSpec{
+				Default: true,
+				Kernel:  "http://files.example.com/vmlinuz",
+				CloudInit: &bootconfiguration.CloudInit{
+					UserData: json.RawMessage(`"#cloud-config\nwrite_files:\n  - content: secret\n"`),
+				},
+			},
+		}})
+	})
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	bootClient, err := client.NewClient(server.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+	controller := bootscript.NewBootScriptControllerWithConfig(*bootClient, bootscript.DefaultControllerConfig(), logger)
+
+	r := chi.NewRouter()
+	discovery.NewHandler(*bootClient, discovery.DefaultConfig(), logger).RegisterRoutes(r)
+	NewHandler(controller, logger).RegisterRoutes(r)
+
+	form := url.Values{"mac": {"aa:bb:cc:dd:ee:99"}, "serial": {"SN1234"}}
+	req := httptest.NewRequest(http.MethodPost, "/discovery/register", nil)
+	req.PostForm = form
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected registration to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, path := range []string{"/cloud-init/aa:bb:cc:dd:ee:99/user-data", "/cloud-init/user-data?mac=aa:bb:cc:dd:ee:99"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for pending node at %s, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
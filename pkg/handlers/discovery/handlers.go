@@ -0,0 +1,378 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package discovery provides handlers for registering and approving nodes found by PXE discovery
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/node"
+	"github.com/openchami/boot-service/pkg/validation"
+)
+
+// Config holds configuration for the discovery handler
+type Config struct {
+	// PollInterval is how long (in seconds) a pending node waits before asking for its boot script again
+	PollInterval int `yaml:"poll_interval"`
+
+	// BootScriptPath is the path pending nodes chain back to once they have waited
+	BootScriptPath string `yaml:"bootscript_path"`
+}
+
+// DefaultConfig returns the default discovery handler configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:   60,
+		BootScriptPath: "/boot/v1/bootscript",
+	}
+}
+
+// Handler handles node discovery registration and approval requests
+type Handler struct {
+	client client.Client
+	config Config
+	logger *log.Logger
+}
+
+// NewHandler creates a new discovery handler
+func NewHandler(c client.Client, config Config, logger *log.Logger) *Handler {
+	return &Handler{
+		client: c,
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers discovery routes
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/discovery", func(r chi.Router) {
+		// Called by iPXE on unknown nodes
+		r.Post("/register", h.Register)
+
+		// Operator endpoints
+		r.Route("/nodes", func(r chi.Router) {
+			r.Get("/", h.ListPending)
+			r.Post("/{uid}/approve", h.Approve)
+			r.Delete("/{uid}", h.Reject)
+		})
+	})
+}
+
+// Register handles POST /discovery/register from iPXE.
+// It creates (or refreshes) a pending Node record and answers with an iPXE
+// script that waits and then asks for the node's boot script again.
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := parseRegistration(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existing, err := h.findNodeByMAC(r, req.MAC)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to look up nodes: %w", err))
+		return
+	}
+
+	facts := req.toDiscoveryInfo()
+
+	switch {
+	case existing == nil:
+		facts.DiscoveredAt = time.Now().UTC().Format(time.RFC3339)
+		createReq := client.CreateNodeRequest{
+			Name: "discovered-" + strings.ReplaceAll(req.MAC, ":", "-"),
+			NodeSpec: node.NodeSpec{
+				BootMAC:    req.MAC,
+				Interfaces: []node.Interface{{MAC: req.MAC}},
+				Discovery:  facts,
+			},
+		}
+
+		created, err := h.client.CreateNode(ctx, createReq)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create discovered node: %w", err))
+			return
+		}
+
+		if _, err := h.client.UpdateNodeStatus(ctx, created.Metadata.UID, node.NodeStatus{State: node.StatePending}); err != nil {
+			h.logger.Printf("Warning: Failed to mark node %s pending: %v", created.Metadata.UID, err)
+		}
+
+		h.logger.Printf("Registered discovered node %s (MAC %s, serial %q)", created.Metadata.UID, req.MAC, facts.Serial)
+
+	case existing.IsPendingDiscovery():
+		// Refresh facts in case the hardware changed since the first report.
+		// Nodes poll until approved, so unchanged facts are not written again.
+		facts.DiscoveredAt = existing.Spec.Discovery.DiscoveredAt
+		if *facts == *existing.Spec.Discovery {
+			break
+		}
+		spec := existing.Spec
+		spec.Discovery = facts
+		if _, err := h.client.UpdateNode(ctx, existing.Metadata.UID, client.UpdateNodeRequest{NodeSpec: spec}); err != nil {
+			h.logger.Printf("Warning: Failed to refresh discovery facts for %s: %v", existing.Metadata.UID, err)
+		}
+
+	default:
+		// Already approved - the node will get its real script on the next request
+		h.logger.Printf("Node %s with MAC %s is already approved", existing.Spec.XName, req.MAC)
+	}
+
+	h.writeScript(w, h.pendingScript(r, req.MAC))
+}
+
+// ListPending handles GET /discovery/nodes
+func (h *Handler) ListPending(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.client.GetNodes(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list nodes: %w", err))
+		return
+	}
+
+	pending := []node.Node{}
+	for _, n := range nodes {
+		if n.IsPendingDiscovery() {
+			pending = append(pending, n)
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, pending)
+}
+
+// ApproveRequest assigns an identity to a discovered node
+type ApproveRequest struct {
+	XName    string   `json:"xname"`
+	Name     string   `json:"name,omitempty"`
+	NID      int32    `json:"nid,omitempty"`
+	Hostname string   `json:"hostname,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	SubRole  string   `json:"subRole,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// Approve handles POST /discovery/nodes/{uid}/approve
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := chi.URLParam(r, "uid")
+
+	var req ApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if !validation.ValidateXName(req.XName) {
+		h.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid XName format: %s", req.XName))
+		return
+	}
+
+	nodes, err := h.client.GetNodes(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list nodes: %w", err))
+		return
+	}
+
+	var target *node.Node
+	for i := range nodes {
+		if nodes[i].Metadata.UID == uid {
+			target = &nodes[i]
+		} else if nodes[i].Spec.XName == req.XName {
+			h.writeError(w, http.StatusConflict, fmt.Errorf("XName %s is already assigned to node %s", req.XName, nodes[i].Metadata.UID))
+			return
+		}
+	}
+
+	if target == nil || !target.IsPendingDiscovery() {
+		h.writeError(w, http.StatusNotFound, fmt.Errorf("no pending discovered node %s", uid))
+		return
+	}
+
+	spec := target.Spec
+	spec.XName = req.XName
+	spec.NID = req.NID
+	spec.Hostname = req.Hostname
+	spec.Role = req.Role
+	spec.SubRole = req.SubRole
+	spec.Groups = req.Groups
+	facts := *spec.Discovery
+	facts.ApprovedAt = time.Now().UTC().Format(time.RFC3339)
+	spec.Discovery = &facts
+
+	name := req.Name
+	if name == "" {
+		name = req.XName
+	}
+
+	updated, err := h.client.UpdateNode(ctx, uid, client.UpdateNodeRequest{NodeSpec: spec, Name: name})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to approve node: %w", err))
+		return
+	}
+
+	status := updated.Status
+	status.State = node.StateReady
+	if updated, err = h.client.UpdateNodeStatus(ctx, uid, status); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to update node status: %w", err))
+		return
+	}
+
+	h.logger.Printf("Approved discovered node %s as %s", uid, req.XName)
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// Reject handles DELETE /discovery/nodes/{uid}
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := chi.URLParam(r, "uid")
+
+	existing, err := h.client.GetNode(ctx, uid)
+	if err != nil || !existing.IsPendingDiscovery() {
+		h.writeError(w, http.StatusNotFound, fmt.Errorf("no pending discovered node %s", uid))
+		return
+	}
+
+	if err := h.client.DeleteNode(ctx, uid); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete node: %w", err))
+		return
+	}
+
+	h.logger.Printf("Rejected discovered node %s (MAC %s)", uid, existing.Spec.BootMAC)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registration holds the facts posted by the discovery iPXE script
+type registration struct {
+	MAC          string `json:"mac"`
+	UUID         string `json:"uuid"`
+	Serial       string `json:"serial"`
+	Manufacturer string `json:"manufacturer"`
+	Product      string `json:"product"`
+	Platform     string `json:"platform"`
+	BuildArch    string `json:"buildarch"`
+}
+
+// parseRegistration reads a registration from an iPXE form post or a JSON body
+func parseRegistration(r *http.Request) (registration, error) {
+	var req registration
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid request body: %w", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return req, fmt.Errorf("invalid form data: %w", err)
+		}
+		req = registration{
+			MAC:          r.Form.Get("mac"),
+			UUID:         r.Form.Get("uuid"),
+			Serial:       r.Form.Get("serial"),
+			Manufacturer: r.Form.Get("manufacturer"),
+			Product:      r.Form.Get("product"),
+			Platform:     r.Form.Get("platform"),
+			BuildArch:    r.Form.Get("buildarch"),
+		}
+	}
+
+	mac, err := validation.NormalizeMAC(req.MAC)
+	if err != nil {
+		return req, fmt.Errorf("a valid mac is required, got %q", req.MAC)
+	}
+	req.MAC = mac
+
+	return req, nil
+}
+
+func (req registration) toDiscoveryInfo() *node.DiscoveryInfo {
+	return &node.DiscoveryInfo{
+		UUID:         req.UUID,
+		Serial:       req.Serial,
+		Manufacturer: req.Manufacturer,
+		Product:      req.Product,
+		Platform:     req.Platform,
+		BuildArch:    req.BuildArch,
+	}
+}
+
+// Helper methods
+
+func (h *Handler) findNodeByMAC(r *http.Request, mac string) (*node.Node, error) {
+	nodes, err := h.client.GetNodes(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		if validation.EqualMAC(nodes[i].Spec.BootMAC, mac) {
+			return &nodes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// pendingScript builds the iPXE script returned to a registered node
+func (h *Handler) pendingScript(r *http.Request, mac string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	bootScriptURL := fmt.Sprintf("%s://%s%s?mac=%s", scheme, r.Host, h.config.BootScriptPath, url.QueryEscape(mac))
+
+	pollInterval := h.config.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultConfig().PollInterval
+	}
+
+	script := PendingIPXETemplate
+	script = strings.ReplaceAll(script, "{{.MAC}}", mac)
+	script = strings.ReplaceAll(script, "{{.PollInterval}}", strconv.Itoa(pollInterval))
+	script = strings.ReplaceAll(script, "{{.BootScriptURL}}", bootScriptURL)
+
+	return script
+}
+
+func (h *Handler) writeScript(w http.ResponseWriter, script string) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(script)) //nolint:errcheck
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, status int, err error) {
+	h.writeJSON(w, status, map[string]interface{}{
+		"error": err.Error(),
+		"code":  status,
+	})
+}
+
+// PendingIPXETemplate is returned to registered nodes while they await approval
+const PendingIPXETemplate = `#!ipxe
+# Discovery Pending iPXE Boot Script
+# Node: {{.MAC}}
+
+echo Node {{.MAC}} is registered and awaiting operator approval
+echo Checking again in {{.PollInterval}} seconds...
+sleep {{.PollInterval}}
+chain --autofree {{.BootScriptURL}}
+`
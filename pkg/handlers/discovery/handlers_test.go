@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// fakeNodeStore is a minimal in-memory stand-in for the boot service node API
+type fakeNodeStore struct {
+	mu      sync.Mutex
+	nodes   map[string]*node.Node
+	next    int
+	updates int // number of node spec updates
+}
+
+func newFakeNodeStore() *fakeNodeStore {
+	return &fakeNodeStore{nodes: make(map[string]*node.Node)}
+}
+
+func (s *fakeNodeStore) router() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/nodes", func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		list := []node.Node{}
+		for _, n := range s.nodes {
+			list = append(list, *n)
+		}
+		json.NewEncoder(w).Encode(list) //nolint:errcheck
+	})
+	r.Post("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateNodeRequest
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.next++
+		n := &node.Node{Spec: req.NodeSpec}
+		n.Metadata.Name = req.Name
+		n.Metadata.UID = fmt.Sprintf("nod-%04d", s.next)
+		s.nodes[n.Metadata.UID] = n
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Get("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		n, ok := s.nodes[chi.URLParam(r, "uid")]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Put("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		var req client.UpdateNodeRequest
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		n, ok := s.nodes[chi.URLParam(r, "uid")]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		s.updates++
+		n.Spec = req.NodeSpec
+		if req.Name != "" {
+			n.Metadata.Name = req.Name
+		}
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Put("/nodes/{uid}/status", func(w http.ResponseWriter, r *http.Request) {
+		var status node.NodeStatus
+		json.NewDecoder(r.Body).Decode(&status) //nolint:errcheck
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		n, ok := s.nodes[chi.URLParam(r, "uid")]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		n.Status = status
+		json.NewEncoder(w).Encode(n) //nolint:errcheck
+	})
+	r.Delete("/nodes/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.nodes, chi.URLParam(r, "uid"))
+		json.NewEncoder(w).Encode(client.DeleteResponse{}) //nolint:errcheck
+	})
+	return r
+}
+
+func (s *fakeNodeStore) only(t *testing.T) *node.Node {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.nodes) != 1 {
+		t.Fatalf("Expected exactly one node, got %d", len(s.nodes))
+	}
+	for _, n := range s.nodes {
+		return n
+	}
+	return nil
+}
+
+func setupDiscovery(t *testing.T) (*fakeNodeStore, *httptest.Server) {
+	t.Helper()
+
+	store := newFakeNodeStore()
+	backend := httptest.NewServer(store.router())
+	t.Cleanup(backend.Close)
+
+	bootClient, err := client.NewClient(backend.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	handler := NewHandler(*bootClient, DefaultConfig(), log.New(io.Discard, "", 0))
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return store, server
+}
+
+func register(t *testing.T, server *httptest.Server, form url.Values) (int, string) {
+	t.Helper()
+	resp, err := http.PostForm(server.URL+"/discovery/register", form)
+	if err != nil {
+		t.Fatalf("Register request failed: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRegisterCreatesPendingNode(t *testing.T) {
+	store, server := setupDiscovery(t)
+
+	form := url.Values{
+		"mac":          {"AA:BB:CC:DD:EE:01"},
+		"uuid":         {"4c4c4544-0042"},
+		"serial":       {"SN1234"},
+		"manufacturer": {"Dell Inc."},
+		"product":      {"PowerEdge R650"},
+		"platform":     {"efi"},
+	}
+	status, script := register(t, server, form)
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", status, script)
+	}
+
+	for _, expected := range []string{"#!ipxe", "awaiting operator approval", "/boot/v1/bootscript?mac=aa%3Abb%3Acc%3Add%3Aee%3A01"} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Pending script missing %q:\n%s", expected, script)
+		}
+	}
+
+	n := store.only(t)
+	if !n.IsPendingDiscovery() {
+		t.Errorf("Expected node to be pending discovery")
+	}
+	if n.Spec.BootMAC != "aa:bb:cc:dd:ee:01" {
+		t.Errorf("Expected normalized BootMAC, got %s", n.Spec.BootMAC)
+	}
+	if n.Spec.Discovery.Serial != "SN1234" || n.Spec.Discovery.Product != "PowerEdge R650" {
+		t.Errorf("Discovery facts not recorded: %+v", n.Spec.Discovery)
+	}
+	if n.Status.State != node.StatePending {
+		t.Errorf("Expected state %s, got %s", node.StatePending, n.Status.State)
+	}
+
+	if n.Spec.Discovery.DiscoveredAt == "" {
+		t.Errorf("Expected the discovery time to be recorded")
+	}
+	discoveredAt := "2025-01-01T00:00:00Z"
+	store.mu.Lock()
+	n.Spec.Discovery.DiscoveredAt = discoveredAt
+	store.mu.Unlock()
+
+	// Polling with unchanged facts does not rewrite the node
+	register(t, server, form)
+	if store.updates != 0 {
+		t.Errorf("Expected no update for unchanged facts, got %d", store.updates)
+	}
+
+	// A second registration refreshes the same record instead of duplicating it
+	register(t, server, url.Values{"mac": {"aa:bb:cc:dd:ee:01"}, "serial": {"SN9999"}})
+	if n := store.only(t); n.Spec.Discovery.Serial != "SN9999" {
+		t.Errorf("Expected refreshed serial, got %s", n.Spec.Discovery.Serial)
+	}
+	if n := store.only(t); n.Spec.Discovery.DiscoveredAt != discoveredAt || store.updates != 1 {
+		t.Errorf("Expected one update keeping discovery time %s, got %s after %d updates",
+			discoveredAt, n.Spec.Discovery.DiscoveredAt, store.updates)
+	}
+}
+
+func TestRegisterRequiresMAC(t *testing.T) {
+	_, server := setupDiscovery(t)
+
+	if status, _ := register(t, server, url.Values{"serial": {"SN1234"}}); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a MAC, got %d", status)
+	}
+	if status, _ := register(t, server, url.Values{"mac": {"not-a-mac"}}); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid MAC, got %d", status)
+	}
+}
+
+func TestApproveDiscoveredNode(t *testing.T) {
+	store, server := setupDiscovery(t)
+	register(t, server, url.Values{"mac": {"aa:bb:cc:dd:ee:02"}})
+	uid := store.only(t).Metadata.UID
+
+	// An existing node already owns the requested xname
+	store.nodes["nod-taken"] = &node.Node{Spec: node.NodeSpec{XName: "x1000c0s0b0n1"}}
+	store.nodes["nod-taken"].Metadata.UID = "nod-taken"
+
+	approve := func(body string) *http.Response {
+		resp, err := http.Post(server.URL+"/discovery/nodes/"+uid+"/approve", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Approve request failed: %v", err)
+		}
+		resp.Body.Close() //nolint:errcheck
+		return resp
+	}
+
+	if resp := approve(`{"xname": "bogus"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid xname, got %d", resp.StatusCode)
+	}
+	if resp := approve(`{"xname": "x1000c0s0b0n1"}`); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a duplicate xname, got %d", resp.StatusCode)
+	}
+	if resp := approve(`{"xname": "x1000c0s0b0n0", "nid": 7, "role": "Compute", "groups": ["compute"]}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+
+	n := store.nodes[uid]
+	if n.IsPendingDiscovery() {
+		t.Errorf("Expected node to no longer be pending")
+	}
+	if n.Spec.XName != "x1000c0s0b0n0" || n.Spec.NID != 7 || n.Metadata.Name != "x1000c0s0b0n0" {
+		t.Errorf("Identity not assigned: %+v", n.Spec)
+	}
+	if n.Status.State != node.StateReady {
+		t.Errorf("Expected state %s, got %s", node.StateReady, n.Status.State)
+	}
+
+	// Approved nodes are no longer listed as pending
+	resp, err := http.Get(server.URL + "/discovery/nodes")
+	if err != nil {
+		t.Fatalf("List request failed: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	var pending []node.Node
+	json.NewDecoder(resp.Body).Decode(&pending) //nolint:errcheck
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending nodes, got %d", len(pending))
+	}
+}
+
+func TestRejectDiscoveredNode(t *testing.T) {
+	store, server := setupDiscovery(t)
+	register(t, server, url.Values{"mac": {"aa:bb:cc:dd:ee:03"}})
+	uid := store.only(t).Metadata.UID
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/discovery/nodes/"+uid, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Reject request failed: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+	if len(store.nodes) != 0 {
+		t.Errorf("Expected node to be deleted")
+	}
+}
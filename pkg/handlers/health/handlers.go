@@ -0,0 +1,275 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package health provides liveness and readiness endpoints backed by dependency checks
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Names of the dependency checks registered by the server
+const (
+	CheckStorage      = "storage"
+	CheckNodeProvider = "node_provider"
+	CheckProviderSync = "provider_sync"
+)
+
+// CheckFunc reports whether a dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// NewBuildInfo returns build info from the values linked into the binary,
+// falling back to the module version and VCS stamp recorded by the Go toolchain
+func NewBuildInfo(version, commit, date string) BuildInfo {
+	build := BuildInfo{Version: version, Commit: commit, Date: date}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	if build.Version == "" || build.Version == "dev" {
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+	}
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && (build.Commit == "" || build.Commit == "unknown"):
+			build.Commit = setting.Value
+		case setting.Key == "vcs.time" && (build.Date == "" || build.Date == "unknown"):
+			build.Date = setting.Value
+		}
+	}
+	return build
+}
+
+// Config holds health check settings
+type Config struct {
+	// Timeout bounds each check so a hung dependency fails readiness instead of blocking the probe
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// DefaultConfig returns the default health check configuration
+func DefaultConfig() Config {
+	return Config{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// CheckResult is the outcome of a single dependency check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of all dependency checks
+type Report struct {
+	Status  string                 `json:"status"`
+	Service string                 `json:"service"`
+	Build   BuildInfo              `json:"build"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready reports whether every check passed
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs the registered dependency checks
+type Checker struct {
+	config Config
+	build  BuildInfo
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker creates a checker with no registered checks
+func NewChecker(config Config, build BuildInfo) *Checker {
+	return &Checker{
+		config: config,
+		build:  build,
+	}
+}
+
+// Register adds a named dependency check to readiness
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Build returns the build info of the running binary
+func (c *Checker) Build() BuildInfo {
+	return c.build
+}
+
+// Check runs every registered check concurrently
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{
+		Status:  StatusOK,
+		Service: "boot-service",
+		Build:   c.build,
+		Checks:  make(map[string]CheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := c.run(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs one check with the configured timeout
+func (c *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %v", c.config.Timeout)
+	}
+
+	result := CheckResult{Status: StatusOK, Duration: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DirectoryCheck reports whether a storage directory exists and is writable
+func DirectoryCheck(dir string) CheckFunc {
+	return func(ctx context.Context) error { //nolint:revive
+		file, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return fmt.Errorf("storage directory not writable: %w", err)
+		}
+		file.Close()           //nolint:errcheck
+		os.Remove(file.Name()) //nolint:errcheck
+		return nil
+	}
+}
+
+// Handler handles liveness and readiness requests
+type Handler struct {
+	checker *Checker
+	logger  *log.Logger
+}
+
+// NewHandler creates a new health handler
+func NewHandler(checker *Checker, logger *log.Logger) *Handler {
+	return &Handler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers health routes. /health is kept as an alias of
+// readiness for existing probes.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/health", h.GetReadiness)
+	r.Get("/health/live", h.GetLiveness)
+	r.Get("/health/ready", h.GetReadiness)
+}
+
+// GetLiveness handles GET /health/live. It only reports that the server is
+// serving requests; dependency failures must not restart the process.
+func (h *Handler) GetLiveness(w http.ResponseWriter, r *http.Request) { //nolint:revive
+	h.writeJSON(w, http.StatusOK, Report{Status: StatusOK, Service: "boot-service", Build: h.checker.Build()})
+}
+
+// GetReadiness handles GET /health/ready, returning 503 when any dependency check fails
+func (h *Handler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+		h.logger.Printf("Not ready: %s", failedChecks(report))
+	}
+	h.writeJSON(w, status, report)
+}
+
+// failedChecks summarizes the failed checks of a report for logging
+func failedChecks(report Report) string {
+	names := make([]string, 0, len(report.Checks))
+	for name := range report.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summary := ""
+	for _, name := range names {
+		if result := report.Checks[name]; result.Status != StatusOK {
+			if summary != "" {
+				summary += "; "
+			}
+			summary += name + ": " + result.Error
+		}
+	}
+	return summary
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Printf("Error encoding JSON response: %v", err)
+	}
+}
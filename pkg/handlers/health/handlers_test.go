@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	var providerErr error
+	checker := NewChecker(Config{Timeout: 50 * time.Millisecond}, BuildInfo{Version: "1.2.3", Commit: "abc123", Date: "2025-10-08"})
+	checker.Register(CheckStorage, DirectoryCheck(t.TempDir()))
+	checker.Register(CheckNodeProvider, func(ctx context.Context) error { return providerErr }) //nolint:revive
+
+	r := chi.NewRouter()
+	NewHandler(checker, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	get := func(path string) (int, Report) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("GET %s: failed to decode report: %v", path, err)
+		}
+		return rec.Code, report
+	}
+
+	for _, path := range []string{"/health", "/health/ready"} {
+		if code, report := get(path); code != http.StatusOK || !report.Ready() || len(report.Checks) != 2 {
+			t.Errorf("GET %s: expected ready with 2 checks, got %d %+v", path, code, report)
+		}
+	}
+
+	providerErr = errors.New("HSM unreachable")
+	code, report := get("/health/ready")
+	if code != http.StatusServiceUnavailable || report.Checks[CheckNodeProvider].Error != "HSM unreachable" ||
+		report.Checks[CheckStorage].Status != StatusOK {
+		t.Errorf("Expected the failed provider check to fail readiness, got %d %+v", code, report)
+	}
+
+	// Dependency failures never fail liveness
+	if code, report := get("/health/live"); code != http.StatusOK || report.Build.Version != "1.2.3" {
+		t.Errorf("Expected liveness to pass with build info, got %d %+v", code, report)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := NewChecker(Config{Timeout: 20 * time.Millisecond}, BuildInfo{})
+	checker.Register("hung", func(ctx context.Context) error { //nolint:revive
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+	if report.Ready() || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected a hung check to fail after the timeout, got %+v after %v", report, time.Since(start))
+	}
+}
+
+func TestDirectoryCheck(t *testing.T) {
+	if err := DirectoryCheck(t.TempDir())(context.Background()); err != nil {
+		t.Errorf("Expected a writable directory to pass: %v", err)
+	}
+	if err := DirectoryCheck(filepath.Join(t.TempDir(), "missing"))(context.Background()); err == nil {
+		t.Errorf("Expected a missing directory to fail")
+	}
+}
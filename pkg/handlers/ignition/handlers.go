@@ -0,0 +1,86 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package ignition serves Ignition configs for CoreOS and Flatcar style images
+package ignition
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+)
+
+// Controller renders Ignition configs and identifies requesting nodes
+type Controller interface {
+	RenderIgnition(ctx context.Context, identifier string) ([]byte, error)
+	ResolveNodeByIP(ctx context.Context, ip string) (string, error)
+}
+
+// Handler handles Ignition config requests
+type Handler struct {
+	controller Controller
+	logger     *log.Logger
+}
+
+// NewHandler creates a new Ignition handler
+func NewHandler(controller Controller, logger *log.Logger) *Handler {
+	return &Handler{
+		controller: controller,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers Ignition routes. Nodes that cannot name themselves
+// may omit the node segment and are identified by ?mac= or their requester IP.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/ignition", func(r chi.Router) {
+		r.Get("/", h.GetRequesterConfig)
+		r.Get("/{node}", h.GetNodeConfig)
+	})
+}
+
+// GetNodeConfig handles GET /ignition/{node}.
+// The node may be given by xname, NID, MAC or hostname.
+func (h *Handler) GetNodeConfig(w http.ResponseWriter, r *http.Request) {
+	h.serveConfig(w, r, chi.URLParam(r, "node"))
+}
+
+// GetRequesterConfig handles GET /ignition
+func (h *Handler) GetRequesterConfig(w http.ResponseWriter, r *http.Request) {
+	identifier, err := bootscript.IdentifyRequester(r, h.controller)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.serveConfig(w, r, identifier)
+}
+
+// serveConfig renders and writes the Ignition config for a node
+func (h *Handler) serveConfig(w http.ResponseWriter, r *http.Request, identifier string) {
+	ctx := bootscript.WithRequesterIP(r.Context(), bootscript.RequesterIP(r))
+	config, err := h.controller.RenderIgnition(ctx, identifier)
+	switch {
+	case errors.Is(err, bootscript.ErrNodeNotFound), errors.Is(err, bootscript.ErrNoIgnitionConfig),
+		errors.Is(err, bootscript.ErrNotBooting):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, bootscript.ErrRequesterMismatch):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		h.logger.Printf("Failed to render Ignition config for %s: %v", identifier, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Printf("Served Ignition config for node %s", identifier)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(config) //nolint:errcheck
+}
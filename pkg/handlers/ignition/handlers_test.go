@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package ignition
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+)
+
+// fakeController serves one node's config and knows nodes without a config
+type fakeController struct{}
+
+func (f *fakeController) RenderIgnition(ctx context.Context, identifier string) ([]byte, error) { //nolint:revive
+	switch identifier {
+	case "x1000c0s0b0n0":
+		return []byte(`{"ignition":{"version":"3.4.0"}}`), nil
+	case "x1000c0s0b0n1":
+		return nil, fmt.Errorf("%w %s", bootscript.ErrNoIgnitionConfig, identifier)
+	case "x1000c0s0b0n2":
+		return nil, bootscript.ErrRequesterMismatch
+	case "x1000c0s0b0n3":
+		return nil, fmt.Errorf("selecting configuration for node %s: %w: halt", identifier, bootscript.ErrNotBooting)
+	}
+	return nil, fmt.Errorf("%w: %s", bootscript.ErrNodeNotFound, identifier)
+}
+
+func (f *fakeController) ResolveNodeByIP(ctx context.Context, ip string) (string, error) { //nolint:revive
+	if ip == "10.1.0.1" {
+		return "x1000c0s0b0n0", nil
+	}
+	return "", fmt.Errorf("no node has interface address %s", ip)
+}
+
+func TestIgnitionEndpoints(t *testing.T) {
+	r := chi.NewRouter()
+	NewHandler(&fakeController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		status     int
+	}{
+		{"Config by xname", "/ignition/x1000c0s0b0n0", "192.0.2.1:1234", http.StatusOK},
+		{"Config by requester IP", "/ignition", "10.1.0.1:1234", http.StatusOK},
+		{"Unknown requester IP", "/ignition", "10.1.0.2:1234", http.StatusNotFound},
+		{"No Ignition config", "/ignition/x1000c0s0b0n1", "192.0.2.1:1234", http.StatusNotFound},
+		{"Spoofed requester", "/ignition/x1000c0s0b0n2", "192.0.2.1:1234", http.StatusForbidden},
+		{"Halted by state policy", "/ignition/x1000c0s0b0n3", "192.0.2.1:1234", http.StatusNotFound},
+		{"Unknown node", "/ignition/x9999c0s0b0n0", "192.0.2.1:1234", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
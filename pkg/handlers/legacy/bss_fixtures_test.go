@@ -0,0 +1,304 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+)
+
+// bssRecordURL is the BSS the fixture responses are recorded from, e.g.,
+// go test ./pkg/handlers/legacy -run TestBSSFixtures -bss-url http://localhost:27778
+// The BSS must hold no boot parameters for the fixtures' targets.
+var bssRecordURL = flag.String("bss-url", "", "record the BSS fixture responses from the BSS at this URL")
+
+// bssFixture is a sequence of legacy API requests with the responses BSS gives
+// them. Recorded names the BSS the responses were recorded from; responses of
+// fixtures without it were written by hand from BSS's documented behavior.
+type bssFixture struct {
+	Description string        `json:"description"`
+	Recorded    string        `json:"recorded,omitempty"`
+	Exchanges   []bssExchange `json:"exchanges"`
+}
+
+// bssExchange is one legacy API request and its response
+type bssExchange struct {
+	Request struct {
+		Method string          `json:"method"`
+		Query  string          `json:"query,omitempty"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	} `json:"response"`
+}
+
+// path returns the request's path and query
+func (e bssExchange) path() string {
+	if e.Request.Query != "" {
+		return "/boot/v1/bootparameters?" + e.Request.Query
+	}
+	return "/boot/v1/bootparameters"
+}
+
+// record replays the fixture's requests against a BSS and replaces the
+// expected responses with the ones it gives
+func (f *bssFixture) record(baseURL string) error {
+	for i := range f.Exchanges {
+		exchange := &f.Exchanges[i]
+		req, err := http.NewRequest(exchange.Request.Method, strings.TrimSuffix(baseURL, "/")+exchange.path(),
+			bytes.NewReader(exchange.Request.Body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("exchange %d: %w", i+1, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:errcheck
+		if err != nil {
+			return fmt.Errorf("exchange %d: %w", i+1, err)
+		}
+
+		exchange.Response.Status = resp.StatusCode
+		exchange.Response.Body = nil
+		if body = bytes.TrimSpace(body); len(body) > 0 {
+			if !json.Valid(body) {
+				body, _ = json.Marshal(string(body))
+			}
+			exchange.Response.Body = body
+		}
+	}
+	f.Recorded = fmt.Sprintf("%s at %s", baseURL, time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// fakeConfigurationStore serves the boot configuration API from memory.
+// Names are unique, as in the boot service's storage.
+type fakeConfigurationStore struct {
+	mu          sync.Mutex
+	next        int
+	configs     map[string]*bootconfiguration.BootConfiguration
+	failUpdates bool // refuse every update
+	failUpdate  int  // when set, refuse the update with this number
+	updates     int  // number of updates requested
+}
+
+func newFakeConfigurationStore() *fakeConfigurationStore {
+	return &fakeConfigurationStore{configs: make(map[string]*bootconfiguration.BootConfiguration)}
+}
+
+func (s *fakeConfigurationStore) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/bootconfigurations", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		configs := make([]bootconfiguration.BootConfiguration, 0, len(s.configs))
+		for _, config := range s.configs {
+			configs = append(configs, *config)
+		}
+		sort.Slice(configs, func(i, j int) bool { return configs[i].Metadata.UID < configs[j].Metadata.UID })
+		json.NewEncoder(w).Encode(configs) //nolint:errcheck
+	})
+	r.Post("/bootconfigurations", func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateBootConfigurationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, existing := range s.configs {
+			if existing.Metadata.Name == req.Name {
+				http.Error(w, "name already exists: "+req.Name, http.StatusConflict)
+				return
+			}
+		}
+		s.next++
+		config := &bootconfiguration.BootConfiguration{Spec: req.BootConfigurationSpec}
+		config.Metadata.UID = fmt.Sprintf("boo-%04d", s.next)
+		config.Metadata.Name = req.Name
+		s.store(w, config, http.StatusCreated)
+	})
+	r.Put("/bootconfigurations/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		var req client.UpdateBootConfigurationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		existing, found := s.configs[chi.URLParam(r, "uid")]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		s.updates++
+		if s.failUpdates || s.updates == s.failUpdate {
+			http.Error(w, "update refused", http.StatusInternalServerError)
+			return
+		}
+		config := *existing
+		config.Spec = req.BootConfigurationSpec
+		s.store(w, &config, http.StatusOK)
+	})
+	r.Delete("/bootconfigurations/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, found := s.configs[chi.URLParam(r, "uid")]; !found {
+			http.NotFound(w, r)
+			return
+		}
+		delete(s.configs, chi.URLParam(r, "uid"))
+		json.NewEncoder(w).Encode(client.DeleteResponse{}) //nolint:errcheck
+	})
+	return r
+}
+
+// add stores a configuration directly, as if it was created through the boot configuration API
+func (s *fakeConfigurationStore) add(name string, spec bootconfiguration.BootConfigurationSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	config := &bootconfiguration.BootConfiguration{Spec: spec}
+	config.Metadata.UID = fmt.Sprintf("boo-%04d", s.next)
+	config.Metadata.Name = name
+	s.configs[config.Metadata.UID] = config
+}
+
+// store validates and saves a configuration; callers hold the lock
+func (s *fakeConfigurationStore) store(w http.ResponseWriter, config *bootconfiguration.BootConfiguration, status int) {
+	if err := config.Validate(context.Background()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.configs[config.Metadata.UID] = config
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(config) //nolint:errcheck
+}
+
+// bootParametersByTarget maps every host, MAC and NID in a response body to
+// the boot parameters it is given. BSS stores parameters per target, so this
+// compares responses independently of how targets are grouped into entries.
+// Bodies are a list of entries, as BSS returns them, or wrapped in an object.
+func bootParametersByTarget(body []byte) (map[string]string, error) {
+	var params []BootParameters
+	if len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &params) != nil {
+		var response BootParametersResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+		params = response.BootParameters
+	}
+
+	byTarget := make(map[string]string)
+	for _, p := range params {
+		cloudInit, _ := json.Marshal(p.CloudInit)
+		value := fmt.Sprintf("kernel=%s initrd=%s params=%s cloud-init=%s", p.Kernel, p.Initrd, p.Params, cloudInit)
+		for _, host := range p.Hosts {
+			byTarget["host:"+host] = value
+		}
+		for _, mac := range p.Macs {
+			byTarget["mac:"+strings.ToLower(mac)] = value
+		}
+		for _, nid := range p.Nids {
+			byTarget["nid:"+nid] = value
+		}
+	}
+	return byTarget, nil
+}
+
+func TestBSSFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "bss", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No legacy API fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+			var fixture bssFixture
+			if err := json.Unmarshal(data, &fixture); err != nil {
+				t.Fatalf("Failed to decode fixture: %v", err)
+			}
+
+			if *bssRecordURL != "" {
+				if err := fixture.record(*bssRecordURL); err != nil {
+					t.Fatalf("Failed to record fixture: %v", err)
+				}
+				data, _ := json.MarshalIndent(fixture, "", "  ")
+				if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil { //nolint:gosec
+					t.Fatalf("Failed to write fixture: %v", err)
+				}
+			}
+			if fixture.Recorded == "" {
+				t.Logf("%s: responses were not recorded from BSS", filepath.Base(file))
+			}
+
+			backend := httptest.NewServer(newFakeConfigurationStore().routes())
+			defer backend.Close()
+			bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("Failed to create boot client: %v", err)
+			}
+
+			r := chi.NewRouter()
+			NewLegacyHandlerWithController(*bootClient, &fakeBootController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+			for i, exchange := range fixture.Exchanges {
+				req := httptest.NewRequest(exchange.Request.Method, exchange.path(), bytes.NewReader(exchange.Request.Body))
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, req)
+
+				if rec.Code != exchange.Response.Status {
+					t.Fatalf("%s: exchange %d (%s %s): expected status %d, got %d: %s", fixture.Description, i+1,
+						exchange.Request.Method, exchange.path(), exchange.Response.Status, rec.Code, rec.Body.String())
+				}
+				// Error documents differ between BSS versions; only the status is compared
+				if rec.Code >= http.StatusBadRequest {
+					continue
+				}
+
+				expected, err := bootParametersByTarget(exchange.Response.Body)
+				if err != nil {
+					t.Fatalf("exchange %d: failed to decode expected response: %v", i+1, err)
+				}
+				returned, err := bootParametersByTarget(rec.Body.Bytes())
+				if err != nil {
+					t.Fatalf("exchange %d: failed to decode response: %v", i+1, err)
+				}
+				if !reflect.DeepEqual(expected, returned) {
+					t.Errorf("%s: exchange %d (%s %s): response differs from the expected one\nexpected: %v\nreturned: %v",
+						fixture.Description, i+1, exchange.Request.Method, exchange.path(), expected, returned)
+				}
+			}
+		})
+	}
+}
@@ -5,12 +5,15 @@
 package legacy
 
 import (
+	"fmt"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/openchami/boot-service/pkg/handlers/health"
 	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
 	"github.com/openchami/boot-service/pkg/resources/node"
+	"github.com/openchami/boot-service/pkg/validation"
 )
 
 // ConvertNodeToLegacyIdentifiers extracts legacy identifiers from a modern Node resource
@@ -30,22 +33,44 @@ func ConvertNodeToLegacyIdentifiers(n *node.Node) (hosts []string, macs []string
 	return hosts, macs, nids
 }
 
+// ConvertNodeToLegacyHost converts a modern Node resource to a legacy BSS host
+func ConvertNodeToLegacyHost(n *node.Node) HostComponent {
+	host := HostComponent{
+		ID:      n.Spec.XName,
+		Type:    "Node",
+		State:   n.Status.State,
+		Role:    n.Spec.Role,
+		SubRole: n.Spec.SubRole,
+		NID:     n.Spec.NID,
+		FQDN:    n.Spec.Hostname,
+	}
+
+	// The boot MAC comes first, followed by the node's other interfaces
+	if n.Spec.BootMAC != "" {
+		host.MAC = append(host.MAC, n.Spec.BootMAC)
+	}
+	for _, iface := range n.Spec.Interfaces {
+		if iface.MAC != "" && !slices.ContainsFunc(host.MAC, func(mac string) bool { return validation.EqualMAC(mac, iface.MAC) }) {
+			host.MAC = append(host.MAC, iface.MAC)
+		}
+	}
+
+	return host
+}
+
 // ConvertBootConfigurationToLegacy converts a modern BootConfiguration to legacy BootParameters
 func ConvertBootConfigurationToLegacy(config *bootconfiguration.BootConfiguration) BootParameters {
 	// Extract target identifiers from boot configuration
-	var hosts, macs, nids []string
+	var nids []string
 
-	hosts = config.Spec.Hosts
-	macs = config.Spec.MACs
+	hosts := ConvertTargetsToLegacyHosts(config.Spec)
+	macs := config.Spec.MACs
 
 	// Convert NIDs to strings
 	for _, nid := range config.Spec.NIDs {
 		nids = append(nids, strconv.Itoa(int(nid)))
 	}
 
-	// Include groups as hosts
-	hosts = append(hosts, config.Spec.Groups...)
-
 	// Create metadata from resource metadata
 	meta := MetaData{
 		Comment:    "Converted from modern BootConfiguration",
@@ -60,51 +85,165 @@ func ConvertBootConfigurationToLegacy(config *bootconfiguration.BootConfiguratio
 		Params:    config.Spec.Params,
 		Kernel:    config.Spec.Kernel,
 		Initrd:    config.Spec.Initrd,
-		CloudInit: CloudInitConfig{}, // Empty for now - will add if needed
+		CloudInit: ConvertCloudInitToLegacy(config.Spec.CloudInit),
 		Meta:      meta,
 	}
 }
 
 // ConvertLegacyToBootConfiguration converts legacy BootParameters to modern BootConfiguration
 func ConvertLegacyToBootConfiguration(legacy BootParameters) *bootconfiguration.BootConfiguration {
-	// Convert string NIDs to int32
-	var nids []int32
-	for _, nidStr := range legacy.Nids {
-		if nid, err := strconv.Atoi(nidStr); err == nil {
-			nids = append(nids, int32(nid))
-		}
-	}
+	// Invalid NIDs are rejected by the handlers before conversion
+	nids, _ := ParseLegacyNIDs(legacy.Nids)
+
+	hosts, roles, groups, isDefault := ConvertLegacyHosts(legacy.Hosts)
 
 	return &bootconfiguration.BootConfiguration{
 		Spec: bootconfiguration.BootConfigurationSpec{
-			Hosts:  legacy.Hosts,
-			MACs:   legacy.Macs,
-			NIDs:   nids,
-			Kernel: legacy.Kernel,
-			Initrd: legacy.Initrd,
-			Params: legacy.Params,
+			Hosts:     hosts,
+			MACs:      legacy.Macs,
+			NIDs:      nids,
+			Groups:    groups,
+			Roles:     roles,
+			Default:   isDefault,
+			Kernel:    legacy.Kernel,
+			Initrd:    legacy.Initrd,
+			Params:    legacy.Params,
+			CloudInit: ConvertLegacyCloudInit(legacy.CloudInit),
 		},
 	}
 }
 
-// ConvertLegacyRequestToBootConfiguration converts a legacy request to modern BootConfiguration
-func ConvertLegacyRequestToBootConfiguration(req BootParametersRequest) *bootconfiguration.BootConfiguration {
-	// Convert string NIDs to int32
+// ParseLegacyNIDs converts BSS NID strings to NIDs. NIDs that are not
+// non-negative integers are reported in the error and left out of the result.
+func ParseLegacyNIDs(legacyNIDs []string) ([]int32, error) {
 	var nids []int32
-	for _, nidStr := range req.Nids {
-		if nid, err := strconv.Atoi(nidStr); err == nil {
-			nids = append(nids, int32(nid))
+	var invalid []string
+	for _, nidStr := range legacyNIDs {
+		nid, err := strconv.ParseInt(strings.TrimSpace(nidStr), 10, 32)
+		if err != nil || nid < 0 {
+			invalid = append(invalid, nidStr)
+			continue
 		}
+		nids = append(nids, int32(nid))
 	}
+	if len(invalid) > 0 {
+		return nids, fmt.Errorf("invalid NIDs: %s", strings.Join(invalid, ", "))
+	}
+	return nids, nil
+}
+
+// ValidateLegacyTargets checks the hosts and NIDs of a BSS entry or request.
+// NIDs must be non-negative integers, and a host that is a role name in a
+// spelling other than HSM's is rejected rather than guessed at.
+func ValidateLegacyTargets(legacyHosts, legacyNIDs []string) error {
+	if _, err := ParseLegacyNIDs(legacyNIDs); err != nil {
+		return err
+	}
+	for _, host := range legacyHosts {
+		if role, ok := validation.CanonicalNodeRole(host); ok && role != host {
+			return fmt.Errorf("host %q is ambiguous: use %q for the %s role", host, role, role)
+		}
+	}
+	return nil
+}
+
+// LegacyDefaultHost is the BSS host name of the global fallback boot parameters
+const LegacyDefaultHost = "Default"
+
+// LegacyGroupPrefix marks inventory group targets among BSS hosts (e.g., "group:compute-gpu")
+// so they are not mistaken for host names when a GET response is sent back in a PUT
+const LegacyGroupPrefix = "group:"
+
+// ConvertLegacyHosts splits BSS hosts into node hosts, HSM role names,
+// "group:" prefixed inventory groups and the "Default" fallback, which BSS
+// treats as targets rather than nodes. Only the HSM spelling of a role (e.g.,
+// "Compute") is a role target; other spellings are rejected by
+// ValidateLegacyTargets, as they could name either a host or the role.
+func ConvertLegacyHosts(legacyHosts []string) (hosts, roles, groups []string, isDefault bool) {
+	for _, host := range legacyHosts {
+		if strings.EqualFold(host, LegacyDefaultHost) {
+			isDefault = true
+			continue
+		}
+		if group, ok := strings.CutPrefix(host, LegacyGroupPrefix); ok && group != "" {
+			groups = append(groups, group)
+			continue
+		}
+		if role, ok := validation.CanonicalNodeRole(host); ok && role == host {
+			roles = append(roles, role)
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, roles, groups, isDefault
+}
+
+// ConvertTargetsToLegacyHosts lists the BSS hosts of a configuration: its hosts,
+// roles, "Default" for catch-all configurations and its "group:" prefixed groups
+func ConvertTargetsToLegacyHosts(spec bootconfiguration.BootConfigurationSpec) []string {
+	hosts := make([]string, 0, len(spec.Hosts)+len(spec.Roles)+len(spec.Groups)+1)
+	hosts = append(hosts, spec.Hosts...)
+	hosts = append(hosts, spec.Roles...)
+	if spec.Default {
+		hosts = append(hosts, LegacyDefaultHost)
+	}
+	for _, group := range spec.Groups {
+		hosts = append(hosts, LegacyGroupPrefix+group)
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	return hosts
+}
+
+// ConvertLegacyCloudInit converts legacy cloud-init data to the modern form, or nil when empty
+func ConvertLegacyCloudInit(legacy CloudInitConfig) *bootconfiguration.CloudInit {
+	cloudInit := &bootconfiguration.CloudInit{
+		MetaData:     legacy.MetaData,
+		UserData:     legacy.UserData,
+		VendorData:   legacy.VendorData,
+		NetworkData:  legacy.NetworkData,
+		PhoneHomeURL: legacy.PhoneHomeURL,
+	}
+	if cloudInit.IsEmpty() {
+		return nil
+	}
+	return cloudInit
+}
+
+// ConvertCloudInitToLegacy converts modern cloud-init data to the legacy form
+func ConvertCloudInitToLegacy(cloudInit *bootconfiguration.CloudInit) CloudInitConfig {
+	if cloudInit == nil {
+		return CloudInitConfig{}
+	}
+	return CloudInitConfig{
+		MetaData:     cloudInit.MetaData,
+		UserData:     cloudInit.UserData,
+		VendorData:   cloudInit.VendorData,
+		NetworkData:  cloudInit.NetworkData,
+		PhoneHomeURL: cloudInit.PhoneHomeURL,
+	}
+}
+
+// ConvertLegacyRequestToBootConfiguration converts a legacy request to modern BootConfiguration
+func ConvertLegacyRequestToBootConfiguration(req BootParametersRequest) *bootconfiguration.BootConfiguration {
+	// Invalid NIDs are rejected by the handlers before conversion
+	nids, _ := ParseLegacyNIDs(req.Nids)
+
+	hosts, roles, groups, isDefault := ConvertLegacyHosts(req.Hosts)
 
 	return &bootconfiguration.BootConfiguration{
 		Spec: bootconfiguration.BootConfigurationSpec{
-			Hosts:  req.Hosts,
-			MACs:   req.Macs,
-			NIDs:   nids,
-			Kernel: req.Kernel,
-			Initrd: req.Initrd,
-			Params: req.Params,
+			Hosts:     hosts,
+			MACs:      req.Macs,
+			NIDs:      nids,
+			Groups:    groups,
+			Roles:     roles,
+			Default:   isDefault,
+			Kernel:    req.Kernel,
+			Initrd:    req.Initrd,
+			Params:    req.Params,
+			CloudInit: ConvertLegacyCloudInit(req.CloudInit),
 		},
 	}
 }
@@ -158,17 +297,39 @@ func CreateErrorResponse(status int, title, detail string) ErrorResponse {
 	}
 }
 
-// CreateServiceStatus creates a legacy service status response
-func CreateServiceStatus(version string) ServiceStatus {
-	return ServiceStatus{
-		ServiceName:    "boot-script-service",
-		ServiceVersion: version,
-		ServiceStatus:  "running",
-		Details: map[string]string{
-			"framework": "fabrica",
-			"timestamp": time.Now().Format(time.RFC3339),
-		},
+// Legacy service status values
+const (
+	ServiceRunning   = "running"
+	ServiceError     = "error"
+	ServiceConnected = "connected"
+)
+
+// CreateServiceStatus creates a legacy service status response from a health
+// report. The node provider checks are reported as the HSM status.
+func CreateServiceStatus(report health.Report) ServiceStatus {
+	status := ServiceStatus{Status: ServiceRunning, Version: report.Build.Version}
+	if !report.Ready() {
+		status.Status = ServiceError
+	}
+
+	if result, found := report.Checks[health.CheckStorage]; found {
+		status.StorageStatus = dependencyStatus(result)
+	}
+	for _, name := range []string{health.CheckNodeProvider, health.CheckProviderSync} {
+		if result, found := report.Checks[name]; found && status.HSMStatus != ServiceError {
+			status.HSMStatus = dependencyStatus(result)
+		}
+	}
+
+	return status
+}
+
+// dependencyStatus converts a health check result to a legacy dependency status
+func dependencyStatus(result health.CheckResult) string {
+	if result.Status != health.StatusOK {
+		return ServiceError
 	}
+	return ServiceConnected
 }
 
 // CreateServiceVersion creates a legacy service version response
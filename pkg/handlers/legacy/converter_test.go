@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+)
+
+func TestCloudInitRoundTrip(t *testing.T) {
+	// Key order, large integers and string user-data must all survive
+	payload := `{
+		"hosts": ["x1000c0s0b0n0"],
+		"kernel": "http://files.example.com/vmlinuz",
+		"cloud-init": {
+			"meta-data": {"zeta": 1, "alpha": {"instance-id": "i-1234", "serial": 12345678901234567890}},
+			"user-data": "#cloud-config\nruncmd:\n  - echo hello\n",
+			"vendor-data": null,
+			"network-data": {"version": 2, "ethernets": {"eth0": {"dhcp4": true}}},
+			"phone-home-url": "http://boot.example.com/phone-home"
+		}
+	}`
+
+	var req BootParametersRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+
+	config := ConvertLegacyRequestToBootConfiguration(req)
+	if err := config.Validate(context.Background()); err != nil {
+		t.Fatalf("Converted configuration is invalid: %v", err)
+	}
+
+	// Simulate storage by round-tripping the resource through JSON
+	stored, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to encode configuration: %v", err)
+	}
+	var loaded bootconfiguration.BootConfiguration
+	if err := json.Unmarshal(stored, &loaded); err != nil {
+		t.Fatalf("Failed to decode configuration: %v", err)
+	}
+
+	response, err := json.Marshal(ConvertBootConfigurationToLegacy(&loaded))
+	if err != nil {
+		t.Fatalf("Failed to encode legacy parameters: %v", err)
+	}
+
+	var original, returned struct {
+		CloudInit json.RawMessage `json:"cloud-init"`
+	}
+	json.Unmarshal([]byte(payload), &original) //nolint:errcheck
+	json.Unmarshal(response, &returned)        //nolint:errcheck
+
+	var expected bytes.Buffer
+	json.Compact(&expected, original.CloudInit) //nolint:errcheck
+	if !bytes.Equal(expected.Bytes(), returned.CloudInit) {
+		t.Errorf("Cloud-init changed in round trip:\nsent:     %s\nreturned: %s", expected.Bytes(), returned.CloudInit)
+	}
+}
+
+func TestCloudInitEmpty(t *testing.T) {
+	config := ConvertLegacyRequestToBootConfiguration(BootParametersRequest{
+		Hosts:  []string{"x1000c0s0b0n0"},
+		Kernel: "http://files.example.com/vmlinuz",
+	})
+	if config.Spec.CloudInit != nil {
+		t.Errorf("Expected no cloud-init for request without cloud-init, got %+v", config.Spec.CloudInit)
+	}
+
+	config.Spec.CloudInit = &bootconfiguration.CloudInit{UserData: json.RawMessage(`{"broken"`)}
+	if err := config.Validate(context.Background()); err == nil {
+		t.Errorf("Expected invalid cloud-init JSON to fail validation")
+	}
+}
+
+func TestDefaultAndRoleHosts(t *testing.T) {
+	tests := []struct {
+		name      string
+		hosts     []string
+		expected  []string
+		roles     []string
+		isDefault bool
+	}{
+		{"Default", []string{"Default"}, nil, nil, true},
+		{"Roles", []string{"Compute", "Application"}, nil, []string{"Compute", "Application"}, false},
+		{"Mixed", []string{"x1000c0s0b0n0", "Storage", "Default"}, []string{"x1000c0s0b0n0"}, []string{"Storage"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ConvertLegacyRequestToBootConfiguration(BootParametersRequest{
+				Hosts:  tt.hosts,
+				Kernel: "http://files.example.com/vmlinuz",
+			})
+			if err := config.Validate(context.Background()); err != nil {
+				t.Fatalf("Converted configuration is invalid: %v", err)
+			}
+			if !reflect.DeepEqual(config.Spec.Hosts, tt.expected) || !reflect.DeepEqual(config.Spec.Roles, tt.roles) ||
+				config.Spec.Default != tt.isDefault {
+				t.Errorf("Got hosts %v, roles %v, default %v", config.Spec.Hosts, config.Spec.Roles, config.Spec.Default)
+			}
+
+			// GET returns the same targets in BSS form
+			legacy := ConvertBootConfigurationToLegacy(config)
+			returned, _, _, _ := ConvertLegacyHosts(legacy.Hosts)
+			if !reflect.DeepEqual(returned, tt.expected) || len(legacy.Hosts) != len(tt.hosts) {
+				t.Errorf("Hosts changed in round trip: sent %v, returned %v", tt.hosts, legacy.Hosts)
+			}
+		})
+	}
+}
+
+func TestAmbiguousRoleHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		valid bool
+	}{
+		{"HSM spelling", []string{"Compute", "Storage"}, true},
+		{"Nodes", []string{"x1000c0s0b0n0", "Default", "group:compute-gpu"}, true},
+		{"Lowercase role", []string{"x1000c0s0b0n0", "compute"}, false},
+		{"Uppercase role", []string{"SERVICE"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLegacyTargets(tt.hosts, nil)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateLegacyTargets(%v) = %v, expected valid %v", tt.hosts, err, tt.valid)
+			}
+		})
+	}
+
+	// Only the HSM spelling becomes a role target
+	hosts, roles, _, _ := ConvertLegacyHosts([]string{"Compute", "compute"})
+	if !reflect.DeepEqual(roles, []string{"Compute"}) || !reflect.DeepEqual(hosts, []string{"compute"}) {
+		t.Errorf("Expected only Compute to become a role, got hosts %v, roles %v", hosts, roles)
+	}
+}
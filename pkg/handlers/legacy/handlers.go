@@ -8,16 +8,23 @@ package legacy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/openchami/boot-service/pkg/client"
 	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+	"github.com/openchami/boot-service/pkg/handlers/health"
 	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+	"github.com/openchami/boot-service/pkg/validation"
 )
 
 // BootController interface for boot script generation
@@ -25,28 +32,83 @@ type BootController interface {
 	GenerateBootScript(ctx context.Context, identifier string) (string, error)
 }
 
+// IPResolver is implemented by controllers that can identify a node from the
+// IP address a boot script request came from
+type IPResolver = bootscript.IPResolver
+
+// ScriptInvalidator is implemented by controllers that cache boot scripts,
+// which must be dropped when boot parameters change
+type ScriptInvalidator interface {
+	InvalidateNode(identifier string) int
+	InvalidateConfig(name string) int
+}
+
+// NodeLister is implemented by controllers that can list the nodes known to
+// the boot service and its node provider
+type NodeLister interface {
+	ListNodes(ctx context.Context) ([]node.Node, error)
+}
+
+// HistoryProvider is implemented by controllers that record when nodes fetch
+// their boot script and cloud-init data
+type HistoryProvider interface {
+	EndpointHistory(name, endpoint string) []bootscript.EndpointAccess
+}
+
+// HandlerConfig holds optional behavior settings for the legacy API handler
+type HandlerConfig struct {
+	// IPFallback identifies nodes by the requester's IP address when a boot
+	// script request carries no host, mac or nid. The requester address is
+	// taken from the request's RemoteAddr, so proxies are only honored when
+	// the server's RealIP middleware is configured to trust them.
+	IPFallback bool `yaml:"ip_fallback"`
+
+	// Status provides the dependency checks and build info reported by the
+	// service endpoints. When nil, only build info from the binary is reported.
+	Status StatusChecker `yaml:"-"`
+}
+
+// StatusChecker reports the service's dependency checks and build info
+type StatusChecker interface {
+	Check(ctx context.Context) health.Report
+	Build() health.BuildInfo
+}
+
+// DefaultHandlerConfig returns the default legacy handler configuration
+func DefaultHandlerConfig() HandlerConfig {
+	return HandlerConfig{
+		IPFallback: true,
+	}
+}
+
 // LegacyHandler handles legacy BSS API requests
 type LegacyHandler struct { //nolint:revive
 	client     client.Client
 	controller BootController
+	config     HandlerConfig
 	logger     *log.Logger
 }
 
 // NewLegacyHandler creates a new legacy API handler with standard controller
 func NewLegacyHandler(c client.Client, logger *log.Logger) *LegacyHandler {
 	controller := bootscript.NewBootScriptController(c, logger)
-	return &LegacyHandler{
-		client:     c,
-		controller: controller,
-		logger:     logger,
-	}
+	return NewLegacyHandlerWithConfig(c, controller, DefaultHandlerConfig(), logger)
 }
 
 // NewLegacyHandlerWithController creates a new legacy API handler with a custom controller
 func NewLegacyHandlerWithController(c client.Client, controller BootController, logger *log.Logger) *LegacyHandler {
+	return NewLegacyHandlerWithConfig(c, controller, DefaultHandlerConfig(), logger)
+}
+
+// NewLegacyHandlerWithConfig creates a new legacy API handler with a custom controller and configuration
+func NewLegacyHandlerWithConfig(c client.Client, controller BootController, config HandlerConfig, logger *log.Logger) *LegacyHandler {
+	if config.Status == nil {
+		config.Status = health.NewChecker(health.DefaultConfig(), health.NewBuildInfo("", "", ""))
+	}
 	return &LegacyHandler{
 		client:     c,
 		controller: controller,
+		config:     config,
 		logger:     logger,
 	}
 }
@@ -59,15 +121,27 @@ func (h *LegacyHandler) RegisterRoutes(r chi.Router) {
 			r.Get("/", h.GetBootParameters)
 			r.Post("/", h.CreateBootParameters)
 			r.Put("/", h.UpdateBootParameters)
+			r.Patch("/", h.PatchBootParameters)
 			r.Delete("/", h.DeleteBootParameters)
 		})
 
 		// Boot script endpoint
 		r.Get("/bootscript", h.GetBootScript)
 
+		// Inventory and state endpoints
+		r.Get("/hosts", h.GetHosts)
+		r.Get("/endpoint-history", h.GetEndpointHistory)
+		r.Get("/dumpstate", h.GetDumpState)
+
+		// Migration endpoint
+		r.Post("/import", h.ImportBootParameters)
+
 		// Service endpoints
 		r.Route("/service", func(r chi.Router) {
 			r.Get("/status", h.GetServiceStatus)
+			r.Get("/status/all", h.GetServiceStatusAll)
+			r.Get("/storage", h.GetServiceStorage)
+			r.Get("/hsm", h.GetServiceHSM)
 			r.Get("/version", h.GetServiceVersion)
 		})
 	})
@@ -90,20 +164,18 @@ func (h *LegacyHandler) GetBootParameters(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Filter configurations based on query parameters
-	var filteredConfigs []bootconfiguration.BootConfiguration
+	// Filtered entries list only the targets the query names, as BSS stores
+	// boot parameters per target
+	var legacyParams []BootParameters
 	if host != "" || mac != "" || nid != "" || name != "" {
 		identifiers := ParseNodeIdentifiersFromQuery(host, mac, nid, name)
-		filteredConfigs = h.filterConfigurationsByIdentifiers(configs, identifiers)
+		for _, match := range matchConfigurations(configs, identifiers) {
+			legacyParams = append(legacyParams, match.legacy())
+		}
 	} else {
-		filteredConfigs = configs
-	}
-
-	// Convert to legacy format
-	var legacyParams []BootParameters
-	for _, config := range filteredConfigs {
-		legacyParam := ConvertBootConfigurationToLegacy(&config)
-		legacyParams = append(legacyParams, legacyParam)
+		for _, config := range configs {
+			legacyParams = append(legacyParams, ConvertBootConfigurationToLegacy(&config))
+		}
 	}
 
 	response := BootParametersResponse{
@@ -123,6 +195,11 @@ func (h *LegacyHandler) CreateBootParameters(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if err := ValidateLegacyTargets(req.Hosts, req.Nids); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
 	// Generate a name for the configuration
 	name := h.generateConfigName(req)
 
@@ -151,8 +228,25 @@ func (h *LegacyHandler) CreateBootParameters(w http.ResponseWriter, r *http.Requ
 	h.writeJSON(w, http.StatusCreated, response)
 }
 
-// UpdateBootParameters handles PUT /boot/v1/bootparameters
+// UpdateBootParameters handles PUT /boot/v1/bootparameters. As in BSS, the
+// boot parameters of every listed host, MAC and NID are replaced, and listed
+// targets without boot parameters get them.
 func (h *LegacyHandler) UpdateBootParameters(w http.ResponseWriter, r *http.Request) {
+	h.applyBootParameters(w, r, false)
+}
+
+// PatchBootParameters handles PATCH /boot/v1/bootparameters. As in BSS, only
+// the fields set in the request change, and every listed target must already
+// have boot parameters.
+func (h *LegacyHandler) PatchBootParameters(w http.ResponseWriter, r *http.Request) {
+	h.applyBootParameters(w, r, true)
+}
+
+// applyBootParameters applies a PUT or PATCH request to the listed targets.
+// Configurations whose targets are all listed are updated in place; listed
+// targets are split off configurations they share with unlisted targets. A
+// request that fails partway through is rolled back.
+func (h *LegacyHandler) applyBootParameters(w http.ResponseWriter, r *http.Request, patch bool) {
 	ctx := r.Context()
 
 	var req BootParametersRequest
@@ -161,61 +255,191 @@ func (h *LegacyHandler) UpdateBootParameters(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// For update, we need to find existing configurations that match the identifiers
-	// This is a simplified implementation - in a real scenario, you might want more sophisticated matching
+	targets, err := requestTargets(req)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+	if !patch && req.Kernel == "" {
+		h.writeError(w, http.StatusBadRequest, "Invalid request format", "kernel is required")
+		return
+	}
+
 	configs, err := h.client.GetBootConfigurations(ctx)
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve existing configurations", err.Error())
 		return
 	}
 
-	// Find configurations that match any of the provided identifiers
-	identifiers := append(req.Hosts, req.Macs...)
-	identifiers = append(identifiers, req.Nids...)
+	listed := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		listed[target.key()] = true
+	}
 
-	matchingConfigs := h.filterConfigurationsByIdentifiers(configs, identifiers)
+	type change struct {
+		config        bootconfiguration.BootConfiguration
+		spec          bootconfiguration.BootConfigurationSpec // Boot parameters of the covered targets
+		covered, kept []legacyTarget
+	}
+	var changes []change
+	matched := make(map[string]bool)
+	for _, config := range configs {
+		covered, kept := partitionTargets(specTargets(config.Spec), listed)
+		if len(covered) == 0 {
+			continue
+		}
+		for _, target := range covered {
+			matched[target.key()] = true
+		}
+		spec := replaceBootParameters(config.Spec, req)
+		if patch {
+			spec = mergeBootParameters(config.Spec, req)
+		}
+		changes = append(changes, change{config, spec, covered, kept})
+	}
 
-	if len(matchingConfigs) == 0 {
-		h.writeError(w, http.StatusNotFound, "No matching boot parameters found", "")
+	var unmatched []legacyTarget
+	for _, target := range targets {
+		if !matched[target.key()] {
+			unmatched = append(unmatched, target)
+		}
+	}
+	if patch && len(unmatched) > 0 {
+		var missing []string
+		for _, target := range unmatched {
+			missing = append(missing, target.value)
+		}
+		h.writeError(w, http.StatusNotFound, "No matching boot parameters found", strings.Join(missing, ", "))
 		return
 	}
 
-	// Update the first matching configuration (simplified approach)
-	configToUpdate := matchingConfigs[0]
-	updateReq := client.UpdateBootConfigurationRequest{
-		BootConfigurationSpec: bootconfiguration.BootConfigurationSpec{
-			Hosts:    req.Hosts,
-			MACs:     req.Macs,
-			Groups:   configToUpdate.Spec.Groups, // Preserve existing groups
-			Kernel:   req.Kernel,
-			Initrd:   req.Initrd,
-			Params:   req.Params,
-			Priority: configToUpdate.Spec.Priority, // Preserve existing priority
-		},
-	}
-
-	// Convert string NIDs to int32
-	for _, nidStr := range req.Nids {
-		if nid, err := strconv.Atoi(nidStr); err == nil {
-			updateReq.NIDs = append(updateReq.NIDs, int32(nid))
+	names := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		names[config.Metadata.Name] = true
+	}
+	// Scripts rendered from the changed configurations or for listed targets are stale
+	invalidated := []string{}
+	for _, change := range changes {
+		invalidated = append(invalidated, change.config.Metadata.Name)
+	}
+	defer func() { h.invalidateScripts(targets, invalidated) }()
+
+	// All new configurations are created before any existing one changes, and
+	// everything applied is rolled back when a later step fails, so a failed
+	// request leaves the boot parameters as they were
+	var created []*bootconfiguration.BootConfiguration
+	var updated []bootconfiguration.BootConfiguration // Existing configurations as they were before the update
+	fail := func(err error) {
+		h.rollbackBootParameters(context.WithoutCancel(ctx), created, updated)
+		h.writeError(w, http.StatusInternalServerError, "Failed to update boot parameters", err.Error())
+	}
+
+	// Listed targets are split off configurations they share with unlisted targets
+	results := make([]BootParameters, len(changes))
+	for i, change := range changes {
+		if len(change.kept) == 0 {
+			continue
 		}
+		config, err := h.createTargetConfiguration(ctx, change.spec, change.covered, names)
+		if err != nil {
+			fail(err)
+			return
+		}
+		created = append(created, config)
+		results[i] = ConvertBootConfigurationToLegacy(config)
 	}
 
-	updatedConfig, err := h.client.UpdateBootConfiguration(ctx, configToUpdate.Metadata.UID, updateReq)
-	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to update boot parameters", err.Error())
-		return
+	// PUT gives listed targets without boot parameters their own configuration
+	if len(unmatched) > 0 {
+		spec := replaceBootParameters(bootconfiguration.BootConfigurationSpec{}, req)
+		config, err := h.createTargetConfiguration(ctx, spec, unmatched, names)
+		if err != nil {
+			fail(err)
+			return
+		}
+		created = append(created, config)
+		results = append(results, ConvertBootConfigurationToLegacy(config))
+	}
+
+	// Configurations that only serve listed targets are updated in place, and
+	// shared ones keep only their unlisted targets
+	for i, change := range changes {
+		spec := change.spec
+		if len(change.kept) > 0 {
+			spec = withTargets(change.config.Spec, change.kept)
+		}
+		config, err := h.client.UpdateBootConfiguration(ctx, change.config.Metadata.UID,
+			client.UpdateBootConfigurationRequest{BootConfigurationSpec: spec})
+		if err != nil {
+			fail(err)
+			return
+		}
+		updated = append(updated, change.config)
+		if len(change.kept) == 0 {
+			results[i] = ConvertBootConfigurationToLegacy(config)
+		} else {
+			h.logger.Printf("Split %d targets off shared boot configuration %s", len(change.covered), change.config.Metadata.Name)
+		}
 	}
 
-	// Convert back to legacy format and return
-	legacyParam := ConvertBootConfigurationToLegacy(updatedConfig)
 	response := BootParametersResponse{
-		BootParameters: []BootParameters{legacyParam},
+		BootParameters: results,
 	}
 
 	h.writeJSON(w, http.StatusOK, response)
 }
 
+// createTargetConfiguration creates a configuration from spec serving exactly
+// targets, named apart from the configuration names in use
+func (h *LegacyHandler) createTargetConfiguration(ctx context.Context, spec bootconfiguration.BootConfigurationSpec, targets []legacyTarget, names map[string]bool) (*bootconfiguration.BootConfiguration, error) {
+	spec = withTargets(spec, targets)
+
+	return h.client.CreateBootConfiguration(ctx, client.CreateBootConfigurationRequest{
+		Name:                  configNameForTargets(targets, names),
+		BootConfigurationSpec: spec,
+	})
+}
+
+// rollbackBootParameters restores updated configurations and deletes created
+// ones after a boot parameters request failed partway through
+func (h *LegacyHandler) rollbackBootParameters(ctx context.Context, created []*bootconfiguration.BootConfiguration, updated []bootconfiguration.BootConfiguration) {
+	for _, original := range updated {
+		if _, err := h.client.UpdateBootConfiguration(ctx, original.Metadata.UID,
+			client.UpdateBootConfigurationRequest{BootConfigurationSpec: original.Spec}); err != nil {
+			h.logger.Printf("Warning: Failed to roll back configuration %s: %v", original.Metadata.Name, err)
+		}
+	}
+	for _, config := range created {
+		if err := h.client.DeleteBootConfiguration(ctx, config.Metadata.UID); err != nil {
+			h.logger.Printf("Warning: Failed to roll back configuration %s: %v", config.Metadata.Name, err)
+		}
+	}
+}
+
+// invalidateScripts drops the cached boot scripts of targets and of scripts
+// rendered from the named configurations. Role, group and default targets
+// name no node, so their scripts are found by configuration.
+func (h *LegacyHandler) invalidateScripts(targets []legacyTarget, configNames []string) {
+	invalidator, ok := h.controller.(ScriptInvalidator)
+	if !ok {
+		return
+	}
+
+	removed := 0
+	for _, target := range targets {
+		switch target.kind {
+		case targetHost, targetMAC, targetNID:
+			removed += invalidator.InvalidateNode(target.value)
+		}
+	}
+	for _, name := range configNames {
+		removed += invalidator.InvalidateConfig(name)
+	}
+	if removed > 0 {
+		h.logger.Printf("Invalidated %d cached boot scripts after boot parameters changed", removed)
+	}
+}
+
 // DeleteBootParameters handles DELETE /boot/v1/bootparameters
 func (h *LegacyHandler) DeleteBootParameters(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -239,24 +463,35 @@ func (h *LegacyHandler) DeleteBootParameters(w http.ResponseWriter, r *http.Requ
 	}
 
 	identifiers := ParseNodeIdentifiersFromQuery(host, mac, nid, name)
-	matchingConfigs := h.filterConfigurationsByIdentifiers(configs, identifiers)
+	matches := matchConfigurations(configs, identifiers)
 
-	if len(matchingConfigs) == 0 {
+	if len(matches) == 0 {
 		h.writeError(w, http.StatusNotFound, "No matching boot parameters found", "")
 		return
 	}
 
-	// Delete all matching configurations
+	// Remove the boot parameters of the named targets. Configurations shared
+	// with other targets keep serving those.
 	var deletedConfigs []BootParameters
-	for _, config := range matchingConfigs {
-		err := h.client.DeleteBootConfiguration(ctx, config.Metadata.UID)
-		if err != nil {
-			h.logger.Printf("Warning: Failed to delete configuration %s: %v", config.Metadata.UID, err)
+	var deletedTargets []legacyTarget
+	var deletedNames []string
+	for _, match := range matches {
+		config := match.config
+		if len(match.kept) == 0 {
+			if err := h.client.DeleteBootConfiguration(ctx, config.Metadata.UID); err != nil {
+				h.logger.Printf("Warning: Failed to delete configuration %s: %v", config.Metadata.UID, err)
+				continue
+			}
+		} else if _, err := h.client.UpdateBootConfiguration(ctx, config.Metadata.UID,
+			client.UpdateBootConfigurationRequest{BootConfigurationSpec: withTargets(config.Spec, match.kept)}); err != nil {
+			h.logger.Printf("Warning: Failed to remove targets from configuration %s: %v", config.Metadata.UID, err)
 			continue
 		}
-		legacyParam := ConvertBootConfigurationToLegacy(&config)
-		deletedConfigs = append(deletedConfigs, legacyParam)
+		deletedConfigs = append(deletedConfigs, match.legacy())
+		deletedTargets = append(deletedTargets, match.covered...)
+		deletedNames = append(deletedNames, config.Metadata.Name)
 	}
+	h.invalidateScripts(deletedTargets, deletedNames)
 
 	response := BootParametersResponse{
 		BootParameters: deletedConfigs,
@@ -267,7 +502,9 @@ func (h *LegacyHandler) DeleteBootParameters(w http.ResponseWriter, r *http.Requ
 
 // GetBootScript handles GET /boot/v1/bootscript
 func (h *LegacyHandler) GetBootScript(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	// Record where the request came from so the controller can verify the requester
+	requester := bootscript.RequesterIP(r)
+	ctx := bootscript.WithRequesterIP(r.Context(), requester)
 
 	// Parse query parameters for node identification
 	host := r.URL.Query().Get("host")
@@ -285,12 +522,21 @@ func (h *LegacyHandler) GetBootScript(w http.ResponseWriter, r *http.Request) {
 	// Extract the node identifier
 	identifier := ExtractNodeIdentifier(req)
 	if identifier == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing node identifier", "At least one node identifier (host, mac, or nid) must be provided")
-		return
+		var err error
+		identifier, err = h.identifyRequester(ctx, requester)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "Missing node identifier",
+				fmt.Sprintf("At least one node identifier (host, mac, or nid) must be provided: %v", err))
+			return
+		}
 	}
 
 	// Generate the boot script using our boot logic
 	script, err := h.controller.GenerateBootScript(ctx, identifier)
+	if errors.Is(err, bootscript.ErrRequesterMismatch) {
+		h.writeError(w, http.StatusForbidden, "Boot script request rejected", err.Error())
+		return
+	}
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Failed to generate boot script", err.Error())
 		return
@@ -302,18 +548,198 @@ func (h *LegacyHandler) GetBootScript(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(script)) //nolint:errcheck
 }
 
+// identifyRequester identifies the requesting node by its IP address when IP fallback is enabled
+func (h *LegacyHandler) identifyRequester(ctx context.Context, ip string) (string, error) {
+	resolver, ok := h.controller.(IPResolver)
+	if !h.config.IPFallback || !ok {
+		return "", fmt.Errorf("requester IP fallback is disabled")
+	}
+
+	identifier, err := resolver.ResolveNodeByIP(ctx, ip)
+	if err != nil {
+		return "", fmt.Errorf("requester %s not identified: %w", ip, err)
+	}
+
+	h.logger.Printf("Boot script request without identifier from %s resolved to node %s", ip, identifier)
+	return identifier, nil
+}
+
+// GetHosts handles GET /boot/v1/hosts, optionally filtered by name
+// (xname or hostname), mac or nid
+func (h *LegacyHandler) GetHosts(w http.ResponseWriter, r *http.Request) {
+	hosts, err := h.listHosts(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve hosts", err.Error())
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	mac := r.URL.Query().Get("mac")
+	nid := r.URL.Query().Get("nid")
+
+	filtered := make([]HostComponent, 0, len(hosts))
+	for _, host := range hosts {
+		if hostMatches(host, name, mac, nid) {
+			filtered = append(filtered, host)
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, filtered)
+}
+
+// GetEndpointHistory handles GET /boot/v1/endpoint-history, optionally
+// filtered by node name and endpoint ("bootscript", "cloud-init" or "ignition")
+func (h *LegacyHandler) GetEndpointHistory(w http.ResponseWriter, r *http.Request) {
+	var history []bootscript.EndpointAccess
+	if provider, ok := h.controller.(HistoryProvider); ok {
+		history = provider.EndpointHistory(r.URL.Query().Get("name"), r.URL.Query().Get("endpoint"))
+	}
+	if history == nil {
+		history = []bootscript.EndpointAccess{} // BSS returns an empty list, not null
+	}
+
+	h.writeJSON(w, http.StatusOK, history)
+}
+
+// GetDumpState handles GET /boot/v1/dumpstate
+func (h *LegacyHandler) GetDumpState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	hosts, err := h.listHosts(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve hosts", err.Error())
+		return
+	}
+
+	configs, err := h.client.GetBootConfigurations(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve boot parameters", err.Error())
+		return
+	}
+	params := make([]BootParameters, 0, len(configs))
+	for i := range configs {
+		params = append(params, ConvertBootConfigurationToLegacy(&configs[i]))
+	}
+
+	h.writeJSON(w, http.StatusOK, DumpState{Components: hosts, Params: params})
+}
+
+// ImportBootParameters handles POST /boot/v1/import. The body is a BSS
+// dumpstate or bootparameters export; with ?dry-run=true the planned
+// configurations are reported without being created.
+func (h *LegacyHandler) ImportBootParameters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, "Export too large",
+				fmt.Sprintf("exports are limited to %d bytes", tooLarge.Limit))
+			return
+		}
+		h.writeError(w, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+	entries, err := ParseBSSExport(data)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry-run"))
+	plan := PlanImport(ctx, entries)
+	result, err := ApplyImport(ctx, h.client, plan, dryRun)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "Failed to import boot parameters", err.Error())
+		return
+	}
+
+	h.logger.Printf("Imported %d BSS entries: %d configurations planned, %d created, %d existing, %d conflicting, %d overlapping targets, %d failed, %d skipped (dry run: %v)",
+		plan.Entries, len(plan.Configurations), len(result.Created), len(result.Existing), len(result.Conflicts), len(result.Overlaps), len(result.Failed), len(plan.Skipped), dryRun)
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// listHosts lists the known nodes as legacy hosts, using the controller's node
+// provider when it has one and the boot service's nodes otherwise
+func (h *LegacyHandler) listHosts(ctx context.Context) ([]HostComponent, error) {
+	var nodes []node.Node
+	var err error
+	if lister, ok := h.controller.(NodeLister); ok {
+		nodes, err = lister.ListNodes(ctx)
+	} else {
+		nodes, err = h.client.GetNodes(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]HostComponent, 0, len(nodes))
+	for i := range nodes {
+		// Discovered nodes have no xname until they are approved
+		if nodes[i].Spec.XName == "" {
+			continue
+		}
+		hosts = append(hosts, ConvertNodeToLegacyHost(&nodes[i]))
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].ID < hosts[j].ID })
+	return hosts, nil
+}
+
+// hostMatches reports whether a host matches every filter that is set
+func hostMatches(host HostComponent, name, mac, nid string) bool {
+	if name != "" && name != host.ID && !strings.EqualFold(name, host.FQDN) &&
+		!strings.EqualFold(name, validation.ShortHostname(host.FQDN)) {
+		return false
+	}
+	if mac != "" && !slices.ContainsFunc(host.MAC, func(hostMAC string) bool { return validation.EqualMAC(hostMAC, mac) }) {
+		return false
+	}
+	if nid != "" && nid != strconv.Itoa(int(host.NID)) {
+		return false
+	}
+	return true
+}
+
 // GetServiceStatus handles GET /boot/v1/service/status
-func (h *LegacyHandler) GetServiceStatus(w http.ResponseWriter, r *http.Request) { //nolint:revive
-	status := CreateServiceStatus("2.0.0-fabrica")
-	h.writeJSON(w, http.StatusOK, status)
+func (h *LegacyHandler) GetServiceStatus(w http.ResponseWriter, r *http.Request) {
+	status := CreateServiceStatus(h.config.Status.Check(r.Context()))
+	h.writeServiceStatus(w, ServiceStatus{Status: status.Status})
+}
+
+// GetServiceStatusAll handles GET /boot/v1/service/status/all
+func (h *LegacyHandler) GetServiceStatusAll(w http.ResponseWriter, r *http.Request) {
+	h.writeServiceStatus(w, CreateServiceStatus(h.config.Status.Check(r.Context())))
+}
+
+// GetServiceStorage handles GET /boot/v1/service/storage
+func (h *LegacyHandler) GetServiceStorage(w http.ResponseWriter, r *http.Request) {
+	status := CreateServiceStatus(h.config.Status.Check(r.Context()))
+	h.writeServiceStatus(w, ServiceStatus{StorageStatus: status.StorageStatus})
+}
+
+// GetServiceHSM handles GET /boot/v1/service/hsm
+func (h *LegacyHandler) GetServiceHSM(w http.ResponseWriter, r *http.Request) {
+	status := CreateServiceStatus(h.config.Status.Check(r.Context()))
+	h.writeServiceStatus(w, ServiceStatus{HSMStatus: status.HSMStatus})
 }
 
 // GetServiceVersion handles GET /boot/v1/service/version
 func (h *LegacyHandler) GetServiceVersion(w http.ResponseWriter, r *http.Request) { //nolint:revive
-	version := CreateServiceVersion("2.0.0-fabrica", "2025-10-08", "main")
+	build := h.config.Status.Build()
+	version := CreateServiceVersion(build.Version, build.Date, build.Commit)
 	h.writeJSON(w, http.StatusOK, version)
 }
 
+// writeServiceStatus writes a service status, with 503 when any reported part has failed
+func (h *LegacyHandler) writeServiceStatus(w http.ResponseWriter, status ServiceStatus) {
+	code := http.StatusOK
+	if status.Status == ServiceError || status.StorageStatus == ServiceError || status.HSMStatus == ServiceError {
+		code = http.StatusServiceUnavailable
+	}
+	h.writeJSON(w, code, status)
+}
+
 // Helper methods
 
 func (h *LegacyHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -343,50 +769,34 @@ func (h *LegacyHandler) generateConfigName(req BootParametersRequest) string {
 	return fmt.Sprintf("legacy-config-%d", len(req.Hosts)+len(req.Macs)+len(req.Nids))
 }
 
-func (h *LegacyHandler) filterConfigurationsByIdentifiers(configs []bootconfiguration.BootConfiguration, identifiers []string) []bootconfiguration.BootConfiguration {
-	var matching []bootconfiguration.BootConfiguration
-
-	for _, config := range configs {
-		if h.configMatchesIdentifiers(config, identifiers) {
-			matching = append(matching, config)
-		}
-	}
-
-	return matching
+// targetMatch is a configuration with the targets a query names and the rest
+type targetMatch struct {
+	config        bootconfiguration.BootConfiguration
+	covered, kept []legacyTarget
 }
 
-func (h *LegacyHandler) configMatchesIdentifiers(config bootconfiguration.BootConfiguration, identifiers []string) bool {
-	for _, identifier := range identifiers {
-		// Check hosts
-		for _, host := range config.Spec.Hosts {
-			if host == identifier {
-				return true
-			}
-		}
-
-		// Check MACs
-		for _, mac := range config.Spec.MACs {
-			if mac == identifier {
-				return true
-			}
-		}
+// legacy returns the boot parameters of the matched targets
+func (m targetMatch) legacy() BootParameters {
+	config := m.config
+	config.Spec = withTargets(config.Spec, m.covered)
+	return ConvertBootConfigurationToLegacy(&config)
+}
 
-		// Check NIDs
-		if nid, err := strconv.Atoi(identifier); err == nil {
-			for _, configNid := range config.Spec.NIDs {
-				if int32(nid) == configNid {
-					return true
-				}
+// matchConfigurations finds the configurations with targets named by identifiers
+func matchConfigurations(configs []bootconfiguration.BootConfiguration, identifiers []string) []targetMatch {
+	var matches []targetMatch
+	for _, config := range configs {
+		var covered, kept []legacyTarget
+		for _, target := range specTargets(config.Spec) {
+			if slices.ContainsFunc(identifiers, target.matches) {
+				covered = append(covered, target)
+			} else {
+				kept = append(kept, target)
 			}
 		}
-
-		// Check groups
-		for _, group := range config.Spec.Groups {
-			if group == identifier {
-				return true
-			}
+		if len(covered) > 0 {
+			matches = append(matches, targetMatch{config, covered, kept})
 		}
 	}
-
-	return false
+	return matches
 }
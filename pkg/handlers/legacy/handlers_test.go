@@ -0,0 +1,460 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/controllers/bootscript"
+	"github.com/openchami/boot-service/pkg/handlers/health"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/resources/node"
+)
+
+// fakeBootController renders a script naming the identifier and resolves one known IP
+type fakeBootController struct {
+	nodes     map[string]string // IP -> node identifier
+	reject    bool              // refuse every request as spoofed
+	inventory []node.Node
+	history   []bootscript.EndpointAccess
+
+	invalidatedNodes   []string
+	invalidatedConfigs []string
+}
+
+func (f *fakeBootController) InvalidateNode(identifier string) int {
+	f.invalidatedNodes = append(f.invalidatedNodes, identifier)
+	return 0
+}
+
+func (f *fakeBootController) InvalidateConfig(name string) int {
+	f.invalidatedConfigs = append(f.invalidatedConfigs, name)
+	return 0
+}
+
+func (f *fakeBootController) ListNodes(ctx context.Context) ([]node.Node, error) { //nolint:revive
+	return f.inventory, nil
+}
+
+func (f *fakeBootController) EndpointHistory(name, endpoint string) []bootscript.EndpointAccess {
+	var entries []bootscript.EndpointAccess
+	for _, access := range f.history {
+		if (name == "" || access.Name == name) && (endpoint == "" || access.Endpoint == endpoint) {
+			entries = append(entries, access)
+		}
+	}
+	return entries
+}
+
+func (f *fakeBootController) GenerateBootScript(ctx context.Context, identifier string) (string, error) { //nolint:revive
+	if f.reject {
+		return "", fmt.Errorf("%w: requester is not an interface of node %s", bootscript.ErrRequesterMismatch, identifier)
+	}
+	return "#!ipxe\necho " + identifier + "\n", nil
+}
+
+func (f *fakeBootController) ResolveNodeByIP(ctx context.Context, ip string) (string, error) { //nolint:revive
+	if identifier, found := f.nodes[ip]; found {
+		return identifier, nil
+	}
+	return "", fmt.Errorf("no node has interface address %s", ip)
+}
+
+func TestBootScriptIPFallback(t *testing.T) {
+	controller := &fakeBootController{nodes: map[string]string{"10.0.0.5": "x1000c0s0b0n0"}}
+	logger := log.New(io.Discard, "", 0)
+
+	request := func(config HandlerConfig, remoteAddr string) *httptest.ResponseRecorder {
+		handler := NewLegacyHandlerWithConfig(client.Client{}, controller, config, logger)
+		req := httptest.NewRequest(http.MethodGet, "/boot/v1/bootscript", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.GetBootScript(rec, req)
+		return rec
+	}
+
+	enabled := DefaultHandlerConfig()
+	rec := request(enabled, "10.0.0.5:41234")
+	if rec.Code != http.StatusOK || rec.Body.String() != "#!ipxe\necho x1000c0s0b0n0\n" {
+		t.Errorf("Expected script for requester's node, got %d: %q", rec.Code, rec.Body.String())
+	}
+
+	if rec := request(enabled, "10.0.0.6:41234"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown requester IP, got %d", rec.Code)
+	}
+
+	disabled := HandlerConfig{IPFallback: false}
+	if rec := request(disabled, "10.0.0.5:41234"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 with IP fallback disabled, got %d", rec.Code)
+	}
+}
+
+func TestBootScriptSpoofRejection(t *testing.T) {
+	controller := &fakeBootController{reject: true}
+	handler := NewLegacyHandlerWithController(client.Client{}, controller, log.New(io.Discard, "", 0))
+
+	req := httptest.NewRequest(http.MethodGet, "/boot/v1/bootscript?mac=aa:bb:cc:dd:ee:01", nil)
+	rec := httptest.NewRecorder()
+	handler.GetBootScript(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for rejected requester, got %d", rec.Code)
+	}
+}
+
+func TestGetPutIdempotent(t *testing.T) {
+	tests := []struct {
+		name string
+		spec bootconfiguration.BootConfigurationSpec
+	}{
+		{"Hosts", bootconfiguration.BootConfigurationSpec{Hosts: []string{"x1000c0s0b0n0", "x1000c0s0b0n1"}}},
+		{"MACs", bootconfiguration.BootConfigurationSpec{MACs: []string{"aa:bb:cc:dd:ee:01"}}},
+		{"NIDs", bootconfiguration.BootConfigurationSpec{NIDs: []int32{1, 2}}},
+		{"Groups", bootconfiguration.BootConfigurationSpec{Groups: []string{"compute-gpu", "login"}}},
+		{"Roles", bootconfiguration.BootConfigurationSpec{Roles: []string{"Compute"}}},
+		{"Default", bootconfiguration.BootConfigurationSpec{Default: true}},
+		{"Mixed", bootconfiguration.BootConfigurationSpec{
+			Hosts:   []string{"x1000c0s0b0n0"},
+			MACs:    []string{"aa:bb:cc:dd:ee:01"},
+			NIDs:    []int32{3},
+			Groups:  []string{"compute-gpu"},
+			Roles:   []string{"Application"},
+			Default: true,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			spec.Kernel = "http://files.example.com/vmlinuz"
+			spec.Params = "console=ttyS0"
+			spec.CloudInit = &bootconfiguration.CloudInit{UserData: json.RawMessage(`{"runcmd":["echo hi"]}`)}
+
+			store := newFakeConfigurationStore()
+			store.add("original", spec)
+			backend := httptest.NewServer(store.routes())
+			defer backend.Close()
+			bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("Failed to create boot client: %v", err)
+			}
+			r := chi.NewRouter()
+			NewLegacyHandlerWithController(*bootClient, &fakeBootController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+			get := func() []byte {
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boot/v1/bootparameters", nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET failed with %d: %s", rec.Code, rec.Body.String())
+				}
+				return rec.Body.Bytes()
+			}
+
+			before := get()
+			var response BootParametersResponse
+			json.Unmarshal(before, &response) //nolint:errcheck
+			for _, params := range response.BootParameters {
+				body, _ := json.Marshal(params)
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/boot/v1/bootparameters", bytes.NewReader(body)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("PUT of GET response failed with %d: %s", rec.Code, rec.Body.String())
+				}
+			}
+
+			if after := get(); !bytes.Equal(before, after) {
+				t.Errorf("GET changed after PUT of its own response:\nbefore: %s\nafter:  %s", before, after)
+			}
+			if len(store.configs) != 1 {
+				t.Fatalf("Expected the configuration to be updated in place, have %d configurations", len(store.configs))
+			}
+			for _, config := range store.configs {
+				if !reflect.DeepEqual(config.Spec, spec) {
+					t.Errorf("Configuration changed:\nbefore: %+v\nafter:  %+v", spec, config.Spec)
+				}
+			}
+		})
+	}
+}
+
+func TestPutSplitsSharedConfiguration(t *testing.T) {
+	store := newFakeConfigurationStore()
+	store.add("legacy-x1000c0s0b0n0", bootconfiguration.BootConfigurationSpec{
+		Hosts:  []string{"x1000c0s0b0n0", "x1000c0s0b0n1"},
+		Kernel: "http://files.example.com/k1",
+	})
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	controller := &fakeBootController{}
+	r := chi.NewRouter()
+	NewLegacyHandlerWithController(*bootClient, controller, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/boot/v1/bootparameters", bytes.NewReader([]byte(body))))
+		return rec
+	}
+
+	// A failed shrink of the shared configuration leaves no second configuration behind
+	store.failUpdates = true
+	if rec := put(`{"hosts": ["x1000c0s0b0n0"], "kernel": "http://files.example.com/k2"}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the failed split to fail the request, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.configs) != 1 {
+		t.Fatalf("Expected the created configuration to be rolled back, have %d configurations", len(store.configs))
+	}
+	store.failUpdates = false
+
+	// The split-off configuration is named apart from the one named after the same host
+	if rec := put(`{"hosts": ["x1000c0s0b0n0"], "kernel": "http://files.example.com/k2"}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT failed with %d: %s", rec.Code, rec.Body.String())
+	}
+	names := make(map[string][]string)
+	for _, config := range store.configs {
+		names[config.Metadata.Name] = config.Spec.Hosts
+	}
+	expected := map[string][]string{
+		"legacy-x1000c0s0b0n0":   {"x1000c0s0b0n1"},
+		"legacy-x1000c0s0b0n0-2": {"x1000c0s0b0n0"},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Unexpected configurations after split: %v", names)
+	}
+
+	// Cached scripts of the listed hosts and of the changed configurations are dropped
+	if !slices.Contains(controller.invalidatedNodes, "x1000c0s0b0n0") ||
+		!slices.Contains(controller.invalidatedConfigs, "legacy-x1000c0s0b0n0") {
+		t.Errorf("Expected scripts to be invalidated, got nodes %v and configurations %v",
+			controller.invalidatedNodes, controller.invalidatedConfigs)
+	}
+
+	controller.invalidatedNodes, controller.invalidatedConfigs = nil, nil
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/boot/v1/bootparameters?host=x1000c0s0b0n1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE failed with %d: %s", rec.Code, rec.Body.String())
+	}
+	if !slices.Contains(controller.invalidatedNodes, "x1000c0s0b0n1") ||
+		!slices.Contains(controller.invalidatedConfigs, "legacy-x1000c0s0b0n0") {
+		t.Errorf("Expected deleted scripts to be invalidated, got nodes %v and configurations %v",
+			controller.invalidatedNodes, controller.invalidatedConfigs)
+	}
+}
+
+func TestAmbiguousRoleHostRejected(t *testing.T) {
+	store := newFakeConfigurationStore()
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	r := chi.NewRouter()
+	NewLegacyHandlerWithController(*bootClient, &fakeBootController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	// "compute" could be a host or the Compute role, so it is rejected rather than guessed at
+	body := `{"hosts": ["compute"], "kernel": "http://files.example.com/vmlinuz"}`
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, "/boot/v1/bootparameters", bytes.NewReader([]byte(body))))
+		if rec.Code != http.StatusBadRequest || !bytes.Contains(rec.Body.Bytes(), []byte(`use \"Compute\"`)) {
+			t.Errorf("%s: expected 400 naming the Compute role, got %d: %s", method, rec.Code, rec.Body.String())
+		}
+	}
+	if len(store.configs) != 0 {
+		t.Errorf("Expected nothing to be stored, got %d configurations", len(store.configs))
+	}
+}
+
+func TestPutRollsBackPartialChanges(t *testing.T) {
+	store := newFakeConfigurationStore()
+	store.add("legacy-x1000c0s0b0n0", bootconfiguration.BootConfigurationSpec{
+		Hosts:  []string{"x1000c0s0b0n0"},
+		Kernel: "http://files.example.com/k1",
+	})
+	store.add("legacy-x1000c0s0b0n1", bootconfiguration.BootConfigurationSpec{
+		Hosts:  []string{"x1000c0s0b0n1", "x1000c0s0b0n2"},
+		Kernel: "http://files.example.com/k1",
+	})
+	before := make(map[string]bootconfiguration.BootConfiguration)
+	for uid, config := range store.configs {
+		before[uid] = *config
+	}
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	r := chi.NewRouter()
+	NewLegacyHandlerWithController(*bootClient, &fakeBootController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	// The first configuration is updated in place, then shrinking the second one fails
+	store.failUpdate = 2
+	body := `{"hosts": ["x1000c0s0b0n0", "x1000c0s0b0n1", "x1000c0s0b0n3"], "kernel": "http://files.example.com/k2"}`
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/boot/v1/bootparameters", bytes.NewReader([]byte(body))))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the failed change to fail the request, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if store.updates != 3 {
+		t.Fatalf("Expected two updates and one rollback, got %d updates", store.updates)
+	}
+
+	// Created configurations are deleted and the applied update is reverted
+	after := make(map[string]bootconfiguration.BootConfiguration)
+	for uid, config := range store.configs {
+		after[uid] = *config
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("Expected the configurations to be restored:\nbefore: %+v\nafter:  %+v", before, after)
+	}
+}
+
+func TestInventoryEndpoints(t *testing.T) {
+	controller := &fakeBootController{
+		inventory: []node.Node{
+			{Spec: node.NodeSpec{XName: "x1000c0s0b0n1", NID: 2, Role: "Compute", BootMAC: "aa:bb:cc:dd:ee:02"}},
+			{
+				Spec: node.NodeSpec{
+					XName: "x1000c0s0b0n0", NID: 1, Role: "Compute", Hostname: "nid000001.cluster.local",
+					BootMAC:    "aa:bb:cc:dd:ee:01",
+					Interfaces: []node.Interface{{MAC: "AA:BB:CC:DD:EE:01"}, {MAC: "aa:bb:cc:dd:ee:11"}},
+				},
+				Status: node.NodeStatus{State: "Ready"},
+			},
+			{Spec: node.NodeSpec{BootMAC: "aa:bb:cc:dd:ee:99"}}, // Discovered, not yet approved
+		},
+		history: []bootscript.EndpointAccess{
+			{Name: "x1000c0s0b0n0", Endpoint: bootscript.EndpointBootScript, LastEpoch: 1700000000},
+			{Name: "x1000c0s0b0n0", Endpoint: bootscript.EndpointCloudInit, LastEpoch: 1700000060},
+		},
+	}
+
+	store := newFakeConfigurationStore()
+	store.add("compute", bootconfiguration.BootConfigurationSpec{
+		Roles:  []string{"Compute"},
+		Kernel: "http://files.example.com/vmlinuz",
+	})
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+	r := chi.NewRouter()
+	NewLegacyHandlerWithController(*bootClient, controller, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	get := func(target string, result interface{}) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s failed with %d: %s", target, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+			t.Fatalf("GET %s returned invalid JSON: %v", target, err)
+		}
+	}
+
+	var hosts []HostComponent
+	get("/boot/v1/hosts", &hosts)
+	if len(hosts) != 2 || hosts[0].ID != "x1000c0s0b0n0" || hosts[1].ID != "x1000c0s0b0n1" {
+		t.Fatalf("Expected the two inventoried nodes in xname order, got %+v", hosts)
+	}
+	if !reflect.DeepEqual(hosts[0].MAC, []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:11"}) ||
+		hosts[0].Type != "Node" || hosts[0].State != "Ready" || hosts[0].FQDN != "nid000001.cluster.local" {
+		t.Errorf("Unexpected host: %+v", hosts[0])
+	}
+
+	for _, filter := range []string{"name=x1000c0s0b0n0", "name=nid000001", "mac=aabb.ccdd.ee11", "nid=1"} {
+		get("/boot/v1/hosts?"+filter, &hosts)
+		if len(hosts) != 1 || hosts[0].ID != "x1000c0s0b0n0" {
+			t.Errorf("Filter %s: expected x1000c0s0b0n0, got %+v", filter, hosts)
+		}
+	}
+
+	var history []bootscript.EndpointAccess
+	get("/boot/v1/endpoint-history?endpoint=cloud-init", &history)
+	if len(history) != 1 || history[0].LastEpoch != 1700000060 {
+		t.Errorf("Expected the cloud-init fetch, got %+v", history)
+	}
+	get("/boot/v1/endpoint-history?name=x1000c0s0b0n1", &history)
+	if history == nil || len(history) != 0 {
+		t.Errorf("Expected an empty list for a node that never booted, got %+v", history)
+	}
+
+	var state DumpState
+	get("/boot/v1/dumpstate", &state)
+	if len(state.Components) != 2 || len(state.Params) != 1 || !reflect.DeepEqual(state.Params[0].Hosts, []string{"Compute"}) {
+		t.Errorf("Unexpected dump state: %+v", state)
+	}
+}
+
+func TestServiceStatus(t *testing.T) {
+	var syncErr error
+	checker := health.NewChecker(health.DefaultConfig(), health.BuildInfo{Version: "1.2.3", Commit: "abc123", Date: "2025-10-08"})
+	checker.Register(health.CheckStorage, func(ctx context.Context) error { return nil })          //nolint:revive
+	checker.Register(health.CheckNodeProvider, func(ctx context.Context) error { return nil })     //nolint:revive
+	checker.Register(health.CheckProviderSync, func(ctx context.Context) error { return syncErr }) //nolint:revive
+
+	config := DefaultHandlerConfig()
+	config.Status = checker
+	r := chi.NewRouter()
+	NewLegacyHandlerWithConfig(client.Client{}, &fakeBootController{}, config, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	get := func(path string) (int, map[string]string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boot/v1/service/"+path, nil))
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: failed to decode response: %v", path, err)
+		}
+		return rec.Code, body
+	}
+
+	tests := []struct {
+		path     string
+		code     int
+		expected map[string]string
+		syncErr  error
+	}{
+		{"status", http.StatusOK, map[string]string{"bss-status": "running"}, nil},
+		{"status/all", http.StatusOK, map[string]string{
+			"bss-status": "running", "bss-version": "1.2.3", "bss-status-storage": "connected", "bss-status-hsm": "connected",
+		}, nil},
+		{"status", http.StatusServiceUnavailable, map[string]string{"bss-status": "error"}, errors.New("stale")},
+		{"hsm", http.StatusServiceUnavailable, map[string]string{"bss-status-hsm": "error"}, errors.New("stale")},
+		{"storage", http.StatusOK, map[string]string{"bss-status-storage": "connected"}, errors.New("stale")},
+		{"version", http.StatusOK, map[string]string{
+			"service_name": "boot-script-service", "service_version": "1.2.3", "build_date": "2025-10-08", "git_commit": "abc123",
+		}, nil},
+	}
+	for _, tt := range tests {
+		syncErr = tt.syncErr
+		if code, body := get(tt.path); code != tt.code || !reflect.DeepEqual(body, tt.expected) {
+			t.Errorf("GET %s (sync error %v): expected %d %v, got %d %v", tt.path, tt.syncErr, tt.code, tt.expected, code, body)
+		}
+	}
+}
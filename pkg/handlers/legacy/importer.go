@@ -0,0 +1,303 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+)
+
+// maxImportSize bounds the export accepted by the import endpoint; a
+// 10,000 entry BSS export with cloud-init data is well below it. Larger
+// exports are rejected with 413 rather than truncated.
+var maxImportSize int64 = 256 << 20
+
+// ImportPlan describes the boot configurations a BSS export converts to.
+// Entries with identical kernel, initrd, params and cloud-init data are
+// merged into one configuration targeting all of their hosts, MACs and NIDs.
+type ImportPlan struct {
+	Entries        int                   `json:"entries"` // BSS entries read
+	Configurations []ImportConfiguration `json:"configurations"`
+	Skipped        []ImportSkip          `json:"skipped,omitempty"`
+}
+
+// ImportConfiguration is a boot configuration planned from one or more BSS entries
+type ImportConfiguration struct {
+	Name    string                                  `json:"name"`
+	Spec    bootconfiguration.BootConfigurationSpec `json:"spec"`
+	Sources int                                     `json:"sources"` // BSS entries merged into it
+}
+
+// ImportSkip reports a BSS entry that cannot be represented as a boot configuration
+type ImportSkip struct {
+	Entry  int      `json:"entry"` // Index in the export
+	Hosts  []string `json:"hosts,omitempty"`
+	Macs   []string `json:"macs,omitempty"`
+	Nids   []string `json:"nids,omitempty"`
+	Reason string   `json:"reason"`
+}
+
+// ImportResult reports the outcome of applying an import plan
+type ImportResult struct {
+	ImportPlan
+	DryRun    bool            `json:"dryRun"`
+	Created   []string        `json:"created,omitempty"`
+	Existing  []string        `json:"existing,omitempty"`  // Planned names that already exist and were left alone
+	Conflicts []string        `json:"conflicts,omitempty"` // Planned names that already exist with a different spec and were left alone
+	Overlaps  []ImportOverlap `json:"overlaps,omitempty"`  // Planned targets already set by another configuration
+	Failed    []ImportFailure `json:"failed,omitempty"`
+}
+
+// ImportOverlap reports a host, MAC or NID of a planned configuration that an
+// existing configuration already targets. The planned configuration is left
+// alone, as the node would otherwise match both.
+type ImportOverlap struct {
+	Name          string `json:"name"`          // Planned configuration
+	Target        string `json:"target"`        // Overlapping target, e.g. "mac:aa:bb:cc:dd:ee:ff"
+	Configuration string `json:"configuration"` // Existing configuration targeting it
+}
+
+// ImportFailure reports a planned configuration that could not be created
+type ImportFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ParseBSSExport reads boot parameters from a BSS export: a dumpstate
+// document, a GET /boot/v1/bootparameters array, or this service's
+// {"boot-parameters": [...]} response
+func ParseBSSExport(data []byte) ([]BootParameters, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("empty export")
+	}
+
+	if data[0] == '[' {
+		var params []BootParameters
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("decoding boot parameters: %w", err)
+		}
+		return params, nil
+	}
+
+	var export struct {
+		Params         []BootParameters `json:"Params"`
+		BootParameters []BootParameters `json:"boot-parameters"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("decoding export: %w", err)
+	}
+	if export.Params != nil {
+		return export.Params, nil
+	}
+	if export.BootParameters != nil {
+		return export.BootParameters, nil
+	}
+	return nil, errors.New("export has no Params or boot-parameters list")
+}
+
+// PlanImport converts BSS entries to boot configurations without creating them
+func PlanImport(ctx context.Context, entries []BootParameters) ImportPlan {
+	plan := ImportPlan{Entries: len(entries), Configurations: []ImportConfiguration{}}
+
+	byContent := make(map[string]int) // content key -> index in plan.Configurations
+	owner := make(map[string]int)     // target key -> entry that claimed it
+	for i, entry := range entries {
+		skip := func(reason string) {
+			plan.Skipped = append(plan.Skipped, ImportSkip{
+				Entry: i, Hosts: entry.Hosts, Macs: entry.Macs, Nids: entry.Nids, Reason: reason,
+			})
+		}
+
+		if err := ValidateLegacyTargets(entry.Hosts, entry.Nids); err != nil {
+			skip(err.Error())
+			continue
+		}
+		config := ConvertLegacyToBootConfiguration(entry)
+		if err := config.Validate(ctx); err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		// BSS keeps one set of parameters per target, so a target listed twice is ambiguous
+		targets := specTargets(config.Spec)
+		if conflict := claimedTarget(targets, owner); conflict != "" {
+			skip(fmt.Sprintf("target %s is already set by entry %d", conflict, owner[conflict]))
+			continue
+		}
+		for _, target := range targets {
+			owner[target.key()] = i
+		}
+
+		key := contentKey(config.Spec)
+		index, found := byContent[key]
+		if !found {
+			byContent[key] = len(plan.Configurations)
+			plan.Configurations = append(plan.Configurations, ImportConfiguration{Spec: config.Spec, Sources: 1})
+			continue
+		}
+
+		merged := &plan.Configurations[index]
+		merged.Spec = withTargets(merged.Spec, append(specTargets(merged.Spec), targets...))
+		merged.Sources++
+	}
+
+	for i := range plan.Configurations {
+		plan.Configurations[i].Name = importConfigName(plan.Configurations[i])
+	}
+	return plan
+}
+
+// claimedTarget returns the key of the first target already claimed by an earlier entry
+func claimedTarget(targets []legacyTarget, owner map[string]int) string {
+	for _, target := range targets {
+		if _, claimed := owner[target.key()]; claimed {
+			return target.key()
+		}
+	}
+	return ""
+}
+
+// contentKey identifies the boot parameters of a configuration, ignoring its targets
+func contentKey(spec bootconfiguration.BootConfigurationSpec) string {
+	cloudInit, _ := json.Marshal(spec.CloudInit)
+	return strings.Join([]string{spec.Kernel, spec.Initrd, spec.Params, string(cloudInit)}, "\x00")
+}
+
+// importConfigName names a planned configuration. Configurations for a single
+// target are named like those created through the legacy API; shared ones are
+// named by their content so repeated imports plan the same names.
+func importConfigName(config ImportConfiguration) string {
+	targets := specTargets(config.Spec)
+	if len(targets) == 1 {
+		return targetConfigName(targets[0])
+	}
+	sum := sha256.Sum256([]byte(contentKey(config.Spec)))
+	return "legacy-shared-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// ApplyImport creates the planned configurations. Configurations whose name
+// already exists are left alone, so an interrupted import can be run again;
+// those whose existing spec differs from the planned one are reported as
+// conflicts. Configurations with a host, MAC or NID that another existing
+// configuration targets are reported as overlaps and left alone too. A dry
+// run reports existing names, conflicts and overlaps without creating.
+func ApplyImport(ctx context.Context, c client.Client, plan ImportPlan, dryRun bool) (ImportResult, error) {
+	result := ImportResult{ImportPlan: plan, DryRun: dryRun}
+
+	existing, err := c.GetBootConfigurations(ctx)
+	if err != nil {
+		return result, fmt.Errorf("getting existing configurations: %w", err)
+	}
+	specs := make(map[string]bootconfiguration.BootConfigurationSpec, len(existing))
+	owner := make(map[string]string) // node target key -> existing configuration
+	for _, config := range existing {
+		specs[config.Metadata.Name] = config.Spec
+		for _, target := range specTargets(config.Spec) {
+			if _, claimed := owner[target.key()]; !claimed && target.isNode() {
+				owner[target.key()] = config.Metadata.Name
+			}
+		}
+	}
+
+	for _, config := range plan.Configurations {
+		if spec, found := specs[config.Name]; found {
+			if reflect.DeepEqual(spec, config.Spec) {
+				result.Existing = append(result.Existing, config.Name)
+			} else {
+				result.Conflicts = append(result.Conflicts, config.Name)
+			}
+			continue
+		}
+		if overlaps := overlappingTargets(config, owner); len(overlaps) > 0 {
+			result.Overlaps = append(result.Overlaps, overlaps...)
+			continue
+		}
+		if dryRun {
+			continue
+		}
+		if _, err := c.CreateBootConfiguration(ctx, client.CreateBootConfigurationRequest{
+			Name:                  config.Name,
+			BootConfigurationSpec: config.Spec,
+		}); err != nil {
+			result.Failed = append(result.Failed, ImportFailure{Name: config.Name, Error: err.Error()})
+			continue
+		}
+		result.Created = append(result.Created, config.Name)
+	}
+	return result, nil
+}
+
+// overlappingTargets lists the node targets of a planned configuration that
+// existing configurations already target
+func overlappingTargets(config ImportConfiguration, owner map[string]string) []ImportOverlap {
+	var overlaps []ImportOverlap
+	for _, target := range specTargets(config.Spec) {
+		if existing, claimed := owner[target.key()]; claimed && target.isNode() {
+			overlaps = append(overlaps, ImportOverlap{Name: config.Name, Target: target.key(), Configuration: existing})
+		}
+	}
+	return overlaps
+}
+
+// WriteImportReport writes a human-readable summary of an import
+func WriteImportReport(w io.Writer, result ImportResult) {
+	shared := 0
+	for _, config := range result.Configurations {
+		if config.Sources > 1 {
+			shared++
+		}
+	}
+
+	fmt.Fprintf(w, "Read %d BSS entries\n", result.Entries)                                            //nolint:errcheck
+	fmt.Fprintf(w, "Planned %d boot configurations (%d shared)\n", len(result.Configurations), shared) //nolint:errcheck
+	for _, config := range result.Configurations {
+		fmt.Fprintf(w, "  %s: %d entries, %d targets, kernel %s\n", //nolint:errcheck
+			config.Name, config.Sources, len(specTargets(config.Spec)), config.Spec.Kernel)
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(w, "Skipped %d entries that cannot be represented\n", len(result.Skipped)) //nolint:errcheck
+		for _, skip := range result.Skipped {
+			targets := append(append(append([]string{}, skip.Hosts...), skip.Macs...), skip.Nids...)
+			fmt.Fprintf(w, "  entry %d (%s): %s\n", skip.Entry, strings.Join(targets, ", "), skip.Reason) //nolint:errcheck
+		}
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Fprintf(w, "%d configurations already exist with different boot parameters and were left alone\n", len(result.Conflicts)) //nolint:errcheck
+		for _, name := range result.Conflicts {
+			fmt.Fprintf(w, "  %s\n", name) //nolint:errcheck
+		}
+	}
+
+	if len(result.Overlaps) > 0 {
+		fmt.Fprintf(w, "%d targets are already set by other configurations; their planned configurations were left alone\n", len(result.Overlaps)) //nolint:errcheck
+		for _, overlap := range result.Overlaps {
+			fmt.Fprintf(w, "  %s: %s is targeted by %s\n", overlap.Name, overlap.Target, overlap.Configuration) //nolint:errcheck
+		}
+	}
+
+	if result.DryRun {
+		fmt.Fprintf(w, "Dry run: nothing was created (%d already existing)\n", len(result.Existing)) //nolint:errcheck
+		return
+	}
+	fmt.Fprintf(w, "Created %d, already existing %d, conflicting %d, overlapping %d, failed %d\n", //nolint:errcheck
+		len(result.Created), len(result.Existing), len(result.Conflicts), len(result.Overlaps), len(result.Failed))
+	for _, failure := range result.Failed {
+		fmt.Fprintf(w, "  %s: %s\n", failure.Name, failure.Error) //nolint:errcheck
+	}
+}
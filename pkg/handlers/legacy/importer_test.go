@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/boot-service/pkg/client"
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+)
+
+const testExport = `{
+	"Components": [{"ID": "x1000c0s0b0n0", "Type": "Node"}],
+	"Params": [
+		{"hosts": ["x1000c0s0b0n0"], "kernel": "http://files.example.com/vmlinuz", "initrd": "http://files.example.com/initrd", "params": "console=ttyS0"},
+		{"hosts": ["x1000c0s0b0n1"], "nids": ["2"], "kernel": "http://files.example.com/vmlinuz", "initrd": "http://files.example.com/initrd", "params": "console=ttyS0"},
+		{"macs": ["aa:bb:cc:dd:ee:03"], "kernel": "http://files.example.com/vmlinuz", "initrd": "http://files.example.com/initrd", "params": "console=ttyS0 debug"},
+		{"hosts": ["Default"], "kernel": "http://files.example.com/vmlinuz-rescue"},
+		{"hosts": ["x1000c0s0b0n0"], "kernel": "http://files.example.com/vmlinuz-other"},
+		{"hosts": ["x1000c0s0b0n4"], "kernel": "s3://boot-images/vmlinuz"},
+		{"nids": ["n5"], "kernel": "http://files.example.com/vmlinuz"},
+		{"hosts": ["compute"], "kernel": "http://files.example.com/vmlinuz"}
+	]
+}`
+
+func TestParseBSSExport(t *testing.T) {
+	entry := `{"hosts": ["x1000c0s0b0n0"], "kernel": "http://files.example.com/vmlinuz"}`
+	for name, export := range map[string]string{
+		"Dumpstate":      `{"Components": [], "Params": [` + entry + `]}`,
+		"BootParameters": `[` + entry + `]`,
+		"Response":       `{"boot-parameters": [` + entry + `]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			entries, err := ParseBSSExport([]byte(export))
+			if err != nil {
+				t.Fatalf("Failed to parse export: %v", err)
+			}
+			if len(entries) != 1 || entries[0].Hosts[0] != "x1000c0s0b0n0" {
+				t.Errorf("Unexpected entries: %+v", entries)
+			}
+		})
+	}
+
+	if _, err := ParseBSSExport([]byte(`{"Components": []}`)); err == nil {
+		t.Errorf("Expected an export without boot parameters to be rejected")
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	entries, err := ParseBSSExport([]byte(testExport))
+	if err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	plan := PlanImport(context.Background(), entries)
+
+	if plan.Entries != 8 || len(plan.Configurations) != 3 {
+		t.Fatalf("Expected 3 configurations from 8 entries, got %d from %d", len(plan.Configurations), plan.Entries)
+	}
+
+	shared := plan.Configurations[0]
+	if shared.Sources != 2 || !reflect.DeepEqual(shared.Spec.Hosts, []string{"x1000c0s0b0n0", "x1000c0s0b0n1"}) ||
+		!reflect.DeepEqual(shared.Spec.NIDs, []int32{2}) || !strings.HasPrefix(shared.Name, "legacy-shared-") {
+		t.Errorf("Expected identical entries to be merged, got %+v", shared)
+	}
+	if plan.Configurations[1].Spec.Params != "console=ttyS0 debug" || plan.Configurations[1].Sources != 1 {
+		t.Errorf("Expected different params to stay separate, got %+v", plan.Configurations[1])
+	}
+	if !plan.Configurations[2].Spec.Default {
+		t.Errorf("Expected the Default entry to become a catch-all configuration, got %+v", plan.Configurations[2])
+	}
+
+	// Conflicting targets, unsupported kernels, invalid NIDs and hosts that
+	// could be roles cannot be represented
+	var skipped []int
+	for _, skip := range plan.Skipped {
+		skipped = append(skipped, skip.Entry)
+	}
+	if !reflect.DeepEqual(skipped, []int{4, 5, 6, 7}) {
+		t.Errorf("Expected entries 4, 5, 6 and 7 to be skipped, got %+v", plan.Skipped)
+	}
+
+	// Names are stable so repeated imports plan the same configurations
+	if again := PlanImport(context.Background(), entries); !reflect.DeepEqual(again, plan) {
+		t.Errorf("Expected planning to be deterministic")
+	}
+}
+
+func TestImportEndpoint(t *testing.T) {
+	store := newFakeConfigurationStore()
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	r := chi.NewRouter()
+	NewLegacyHandlerWithController(*bootClient, &fakeBootController{}, log.New(io.Discard, "", 0)).RegisterRoutes(r)
+
+	post := func(target string) ImportResult {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(testExport)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: expected status 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var result ImportResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		return result
+	}
+
+	result := post("/boot/v1/import?dry-run=true")
+	if !result.DryRun || len(result.Configurations) != 3 || len(result.Created) != 0 || len(store.configs) != 0 {
+		t.Fatalf("Expected a dry run to plan without creating, got %+v with %d stored", result, len(store.configs))
+	}
+
+	result = post("/boot/v1/import")
+	if len(result.Created) != 3 || len(result.Failed) != 0 || len(store.configs) != 3 {
+		t.Fatalf("Expected 3 configurations to be created, got %+v with %d stored", result, len(store.configs))
+	}
+
+	// Importing again leaves the existing configurations alone
+	result = post("/boot/v1/import")
+	if len(result.Created) != 0 || len(result.Existing) != 3 || len(store.configs) != 3 {
+		t.Errorf("Expected a repeated import to create nothing, got %+v with %d stored", result, len(store.configs))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/boot/v1/import", strings.NewReader(`{"broken"`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid export to be rejected with 400, got %d", rec.Code)
+	}
+
+	// An export over the size limit is rejected rather than truncated
+	defer func(limit int64) { maxImportSize = limit }(maxImportSize)
+	maxImportSize = int64(len(testExport) - 1)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/boot/v1/import?dry-run=true", strings.NewReader(testExport)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected an oversized export to be rejected with 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	store := newFakeConfigurationStore()
+	store.add("legacy-default", bootconfiguration.BootConfigurationSpec{
+		Default: true,
+		Kernel:  "http://files.example.com/vmlinuz-edited",
+	})
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	entries, err := ParseBSSExport([]byte(testExport))
+	if err != nil {
+		t.Fatalf("ParseBSSExport failed: %v", err)
+	}
+	ctx := context.Background()
+	plan := PlanImport(ctx, entries)
+
+	// A dry run reports the conflict without creating anything
+	result, err := ApplyImport(ctx, *bootClient, plan, true)
+	if err != nil {
+		t.Fatalf("ApplyImport failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Conflicts, []string{"legacy-default"}) || len(result.Existing) != 0 || len(result.Created) != 0 {
+		t.Errorf("Expected the dry run to report legacy-default as a conflict, got %+v", result)
+	}
+	var report strings.Builder
+	WriteImportReport(&report, result)
+	if !strings.Contains(report.String(), "legacy-default") {
+		t.Errorf("Expected the report to name the conflict, got:\n%s", report.String())
+	}
+	if len(store.configs) != 1 {
+		t.Fatalf("Expected the dry run to create nothing, got %d stored", len(store.configs))
+	}
+
+	// Importing creates the others and leaves the conflicting configuration alone
+	result, err = ApplyImport(ctx, *bootClient, plan, false)
+	if err != nil {
+		t.Fatalf("ApplyImport failed: %v", err)
+	}
+	if len(result.Created) != 2 || len(result.Conflicts) != 1 || len(result.Existing) != 0 {
+		t.Errorf("Expected 2 created and 1 conflict, got %+v", result)
+	}
+	for _, config := range store.configs {
+		if config.Metadata.Name == "legacy-default" && config.Spec.Kernel != "http://files.example.com/vmlinuz-edited" {
+			t.Errorf("Expected the conflicting configuration to be left alone, got kernel %s", config.Spec.Kernel)
+		}
+	}
+
+	// Configurations created by the import are existing, not conflicting, on a rerun
+	result, err = ApplyImport(ctx, *bootClient, plan, true)
+	if err != nil {
+		t.Fatalf("ApplyImport failed: %v", err)
+	}
+	if len(result.Existing) != 2 || len(result.Conflicts) != 1 {
+		t.Errorf("Expected 2 existing and 1 conflict on a rerun, got %+v", result)
+	}
+}
+
+func TestImportOverlaps(t *testing.T) {
+	store := newFakeConfigurationStore()
+	store.add("site-compute", bootconfiguration.BootConfigurationSpec{
+		Hosts:  []string{"x1000c0s0b0n1"},
+		MACs:   []string{"AA-BB-CC-DD-EE-03"},
+		Kernel: "http://files.example.com/vmlinuz-site",
+	})
+	backend := httptest.NewServer(store.routes())
+	defer backend.Close()
+	bootClient, err := client.NewClient(backend.URL, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create boot client: %v", err)
+	}
+
+	entries, err := ParseBSSExport([]byte(testExport))
+	if err != nil {
+		t.Fatalf("ParseBSSExport failed: %v", err)
+	}
+	ctx := context.Background()
+	plan := PlanImport(ctx, entries)
+	shared := ""
+	for _, config := range plan.Configurations {
+		if config.Sources > 1 {
+			shared = config.Name
+		}
+	}
+
+	// The shared configuration targets x1000c0s0b0n1 and the MAC one targets
+	// the same MAC in another notation; a dry run reports both
+	want := []ImportOverlap{
+		{Name: shared, Target: "host:x1000c0s0b0n1", Configuration: "site-compute"},
+		{Name: "legacy-aa-bb-cc-dd-ee-03", Target: "mac:aa:bb:cc:dd:ee:03", Configuration: "site-compute"},
+	}
+	result, err := ApplyImport(ctx, *bootClient, plan, true)
+	if err != nil {
+		t.Fatalf("ApplyImport failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Overlaps, want) {
+		t.Errorf("Expected the dry run to report overlaps %+v, got %+v", want, result.Overlaps)
+	}
+	var report strings.Builder
+	WriteImportReport(&report, result)
+	if !strings.Contains(report.String(), "host:x1000c0s0b0n1 is targeted by site-compute") {
+		t.Errorf("Expected the report to name the overlap, got:\n%s", report.String())
+	}
+
+	// Importing creates only the configuration without overlaps
+	result, err = ApplyImport(ctx, *bootClient, plan, false)
+	if err != nil {
+		t.Fatalf("ApplyImport failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Created, []string{"legacy-default"}) || !reflect.DeepEqual(result.Overlaps, want) {
+		t.Errorf("Expected only legacy-default to be created, got %+v", result)
+	}
+	if len(store.configs) != 2 {
+		t.Errorf("Expected 2 stored configurations, got %d", len(store.configs))
+	}
+}
@@ -59,15 +59,11 @@ func TestLegacyServiceEndpoints(t *testing.T) {
 			t.Fatalf("Failed to decode status response: %v", err)
 		}
 
-		if status.ServiceName != "boot-script-service" {
-			t.Errorf("Expected service name 'boot-script-service', got '%s'", status.ServiceName)
+		if status.Status != "running" {
+			t.Errorf("Expected service status 'running', got '%s'", status.Status)
 		}
 
-		if status.ServiceStatus != "running" {
-			t.Errorf("Expected service status 'running', got '%s'", status.ServiceStatus)
-		}
-
-		t.Logf("✅ Service status: %s %s", status.ServiceName, status.ServiceVersion)
+		t.Logf("✅ Service status: %s", status.Status)
 	})
 
 	t.Run("Service Version", func(t *testing.T) {
@@ -90,8 +86,8 @@ func TestLegacyServiceEndpoints(t *testing.T) {
 			t.Errorf("Expected service name 'boot-script-service', got '%s'", version.ServiceName)
 		}
 
-		if !strings.Contains(version.ServiceVersion, "fabrica") {
-			t.Errorf("Expected version to contain 'fabrica', got '%s'", version.ServiceVersion)
+		if version.ServiceVersion == "" {
+			t.Errorf("Expected a service version from the build info")
 		}
 
 		t.Logf("✅ Service version: %s %s", version.ServiceName, version.ServiceVersion)
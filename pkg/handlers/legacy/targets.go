@@ -0,0 +1,221 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package legacy
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/openchami/boot-service/pkg/resources/bootconfiguration"
+	"github.com/openchami/boot-service/pkg/validation"
+)
+
+// Kinds of targets BSS stores boot parameters for
+const (
+	targetHost    = "host"
+	targetMAC     = "mac"
+	targetNID     = "nid"
+	targetRole    = "role"
+	targetGroup   = "group"
+	targetDefault = "default"
+)
+
+// legacyTarget is one target BSS stores boot parameters for: a host, MAC, NID,
+// role, inventory group or the "Default" fallback
+type legacyTarget struct {
+	kind  string
+	value string
+}
+
+// key identifies the target regardless of notation
+func (t legacyTarget) key() string {
+	switch t.kind {
+	case targetMAC:
+		if mac, err := validation.NormalizeMAC(t.value); err == nil {
+			return targetMAC + ":" + mac
+		}
+		return targetMAC + ":" + strings.ToLower(t.value)
+	case targetRole:
+		return targetRole + ":" + strings.ToLower(t.value)
+	}
+	return t.kind + ":" + t.value
+}
+
+// isNode reports whether the target names a single node: a host, MAC or NID
+func (t legacyTarget) isNode() bool {
+	return t.kind == targetHost || t.kind == targetMAC || t.kind == targetNID
+}
+
+// matches reports whether a host, mac or nid query value names the target.
+// Groups are named with or without the "group:" prefix.
+func (t legacyTarget) matches(identifier string) bool {
+	switch t.kind {
+	case targetHost:
+		return t.value == identifier
+	case targetMAC:
+		return validation.EqualMAC(t.value, identifier)
+	case targetNID:
+		nid, err := strconv.Atoi(identifier)
+		return err == nil && strconv.Itoa(nid) == t.value
+	case targetRole:
+		return strings.EqualFold(t.value, identifier)
+	case targetGroup:
+		return t.value == strings.TrimPrefix(identifier, LegacyGroupPrefix)
+	case targetDefault:
+		return strings.EqualFold(identifier, LegacyDefaultHost)
+	}
+	return false
+}
+
+// specTargets lists the BSS targets of a configuration
+func specTargets(spec bootconfiguration.BootConfigurationSpec) []legacyTarget {
+	var targets []legacyTarget
+	for _, host := range spec.Hosts {
+		targets = append(targets, legacyTarget{targetHost, host})
+	}
+	for _, mac := range spec.MACs {
+		targets = append(targets, legacyTarget{targetMAC, mac})
+	}
+	for _, nid := range spec.NIDs {
+		targets = append(targets, legacyTarget{targetNID, strconv.Itoa(int(nid))})
+	}
+	for _, role := range spec.Roles {
+		targets = append(targets, legacyTarget{targetRole, role})
+	}
+	for _, group := range spec.Groups {
+		targets = append(targets, legacyTarget{targetGroup, group})
+	}
+	if spec.Default {
+		targets = append(targets, legacyTarget{targetDefault, LegacyDefaultHost})
+	}
+	return targets
+}
+
+// requestTargets lists the BSS targets named by an update request
+func requestTargets(req BootParametersRequest) ([]legacyTarget, error) {
+	if err := ValidateLegacyTargets(req.Hosts, req.Nids); err != nil {
+		return nil, err
+	}
+
+	targets := specTargets(ConvertLegacyRequestToBootConfiguration(req).Spec)
+	if len(targets) == 0 {
+		return nil, errors.New("at least one host, mac or nid must be provided")
+	}
+	return targets, nil
+}
+
+// partitionTargets splits targets into those listed by key and the rest
+func partitionTargets(targets []legacyTarget, listed map[string]bool) (covered, kept []legacyTarget) {
+	for _, target := range targets {
+		if listed[target.key()] {
+			covered = append(covered, target)
+		} else {
+			kept = append(kept, target)
+		}
+	}
+	return covered, kept
+}
+
+// withTargets returns spec with its targets replaced by targets
+func withTargets(spec bootconfiguration.BootConfigurationSpec, targets []legacyTarget) bootconfiguration.BootConfigurationSpec {
+	spec.Hosts, spec.MACs, spec.NIDs, spec.Groups, spec.Roles, spec.Default = nil, nil, nil, nil, nil, false
+	for _, target := range targets {
+		switch target.kind {
+		case targetHost:
+			spec.Hosts = append(spec.Hosts, target.value)
+		case targetMAC:
+			spec.MACs = append(spec.MACs, target.value)
+		case targetNID:
+			nid, _ := strconv.Atoi(target.value)
+			spec.NIDs = append(spec.NIDs, int32(nid))
+		case targetRole:
+			spec.Roles = append(spec.Roles, target.value)
+		case targetGroup:
+			spec.Groups = append(spec.Groups, target.value)
+		case targetDefault:
+			spec.Default = true
+		}
+	}
+	return spec
+}
+
+// targetConfigName names a configuration after a target, like BSS entries
+// created through the legacy API
+func targetConfigName(target legacyTarget) string {
+	switch target.kind {
+	case targetHost:
+		return "legacy-" + strings.ReplaceAll(target.value, ".", "-")
+	case targetMAC:
+		return "legacy-" + strings.ReplaceAll(target.value, ":", "-")
+	case targetNID:
+		return "legacy-nid-" + target.value
+	case targetRole:
+		return "legacy-role-" + strings.ToLower(target.value)
+	case targetGroup:
+		return "legacy-group-" + target.value
+	}
+	return "legacy-default"
+}
+
+// configNameForTargets names a configuration created for targets after its
+// first target, adding a numeric suffix until the name is not in names. The
+// chosen name is added to names.
+func configNameForTargets(targets []legacyTarget, names map[string]bool) string {
+	base := targetConfigName(targets[0])
+	name := base
+	for i := 2; names[name]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	names[name] = true
+	return name
+}
+
+// replaceBootParameters sets the BSS fields of spec to those of a PUT request
+func replaceBootParameters(spec bootconfiguration.BootConfigurationSpec, req BootParametersRequest) bootconfiguration.BootConfigurationSpec {
+	spec.Kernel = req.Kernel
+	spec.Initrd = req.Initrd
+	spec.Params = req.Params
+	spec.CloudInit = ConvertLegacyCloudInit(req.CloudInit)
+	return spec
+}
+
+// mergeBootParameters sets the BSS fields of spec that a PATCH request sets.
+// Cloud-init documents are replaced one by one.
+func mergeBootParameters(spec bootconfiguration.BootConfigurationSpec, req BootParametersRequest) bootconfiguration.BootConfigurationSpec {
+	if req.Kernel != "" {
+		spec.Kernel = req.Kernel
+	}
+	if req.Initrd != "" {
+		spec.Initrd = req.Initrd
+	}
+	if req.Params != "" {
+		spec.Params = req.Params
+	}
+
+	if patch := ConvertLegacyCloudInit(req.CloudInit); patch != nil {
+		var merged bootconfiguration.CloudInit
+		if spec.CloudInit != nil {
+			merged = *spec.CloudInit
+		}
+		if len(patch.MetaData) > 0 {
+			merged.MetaData = patch.MetaData
+		}
+		if len(patch.UserData) > 0 {
+			merged.UserData = patch.UserData
+		}
+		if len(patch.VendorData) > 0 {
+			merged.VendorData = patch.VendorData
+		}
+		if len(patch.NetworkData) > 0 {
+			merged.NetworkData = patch.NetworkData
+		}
+		if patch.PhoneHomeURL != "" {
+			merged.PhoneHomeURL = patch.PhoneHomeURL
+		}
+		spec.CloudInit = &merged
+	}
+	return spec
+}
@@ -7,6 +7,7 @@
 package legacy
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -22,13 +23,14 @@ type BootParameters struct {
 	Meta      MetaData        `json:"meta,omitempty"`
 }
 
-// CloudInitConfig represents cloud-init configuration in legacy format
+// CloudInitConfig represents cloud-init configuration in legacy format.
+// Documents are kept as raw JSON so they round-trip exactly.
 type CloudInitConfig struct {
-	MetaData     interface{} `json:"meta-data,omitempty"`
-	UserData     interface{} `json:"user-data,omitempty"`
-	VendorData   interface{} `json:"vendor-data,omitempty"`
-	NetworkData  interface{} `json:"network-data,omitempty"`
-	PhoneHomeURL string      `json:"phone-home-url,omitempty"`
+	MetaData     json.RawMessage `json:"meta-data,omitempty"`
+	UserData     json.RawMessage `json:"user-data,omitempty"`
+	VendorData   json.RawMessage `json:"vendor-data,omitempty"`
+	NetworkData  json.RawMessage `json:"network-data,omitempty"`
+	PhoneHomeURL string          `json:"phone-home-url,omitempty"`
 }
 
 // MetaData represents metadata in legacy BSS format
@@ -56,6 +58,25 @@ type BootParametersResponse struct {
 	BootParameters []BootParameters `json:"boot-parameters"`
 }
 
+// HostComponent represents a host in the legacy BSS /hosts format: an HSM
+// component with the MAC addresses it boots from
+type HostComponent struct {
+	ID      string   `json:"ID"`
+	Type    string   `json:"Type"`
+	State   string   `json:"State,omitempty"`
+	Role    string   `json:"Role,omitempty"`
+	SubRole string   `json:"SubRole,omitempty"`
+	NID     int32    `json:"NID,omitempty"`
+	FQDN    string   `json:"FQDN,omitempty"`
+	MAC     []string `json:"MAC,omitempty"`
+}
+
+// DumpState represents the legacy BSS /dumpstate response
+type DumpState struct {
+	Components []HostComponent  `json:"Components"`
+	Params     []BootParameters `json:"Params"`
+}
+
 // BootScriptRequest represents a request for boot script generation
 type BootScriptRequest struct {
 	// Node identifiers - at least one must be provided
@@ -69,12 +90,13 @@ type BootScriptRequest struct {
 	Format string `json:"format,omitempty"` // defaults to "ipxe"
 }
 
-// ServiceStatus represents the legacy service status format
+// ServiceStatus represents the BSS service status format. Dependency fields
+// are omitted unless requested, as BSS does for /service/status.
 type ServiceStatus struct {
-	ServiceName    string            `json:"service_name"`
-	ServiceVersion string            `json:"service_version"`
-	ServiceStatus  string            `json:"service_status"`
-	Details        map[string]string `json:"details,omitempty"`
+	Status        string `json:"bss-status,omitempty"`
+	Version       string `json:"bss-version,omitempty"`
+	StorageStatus string `json:"bss-status-storage,omitempty"`
+	HSMStatus     string `json:"bss-status-hsm,omitempty"`
 }
 
 // ServiceVersion represents the legacy service version format
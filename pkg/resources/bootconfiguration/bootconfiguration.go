@@ -7,7 +7,11 @@ package bootconfiguration
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/url"
+	"strings"
+	"text/template"
 
 	"github.com/openchami/boot-service/pkg/validation"
 	"github.com/openchami/fabrica/pkg/resource"
@@ -27,6 +31,10 @@ type BootConfigurationSpec struct { // nolint:revive
 	MACs   []string `json:"macs,omitempty"`
 	NIDs   []int32  `json:"nids,omitempty"`
 	Groups []string `json:"groups,omitempty"` // Support for inventory service groups
+	Roles  []string `json:"roles,omitempty"`  // Nodes whose HSM role matches (e.g., "Compute")
+
+	// Default marks a catch-all configuration for nodes no other configuration targets
+	Default bool `json:"default,omitempty"`
 
 	// Boot configuration (kernel required)
 	Kernel string `json:"kernel"`
@@ -35,6 +43,108 @@ type BootConfigurationSpec struct { // nolint:revive
 
 	// Priority for conflict resolution
 	Priority int `json:"priority,omitempty"`
+
+	// Cloud-init data for nodes using this configuration
+	CloudInit *CloudInit `json:"cloudInit,omitempty"`
+
+	// Ignition config for nodes using this configuration
+	Ignition *Ignition `json:"ignition,omitempty"`
+}
+
+// CloudInit holds cloud-init data. Documents are kept as the raw JSON they were
+// submitted as (an object or a string), so they are returned exactly as stored.
+type CloudInit struct {
+	MetaData     json.RawMessage `json:"metaData,omitempty"`
+	UserData     json.RawMessage `json:"userData,omitempty"`
+	VendorData   json.RawMessage `json:"vendorData,omitempty"`
+	NetworkData  json.RawMessage `json:"networkData,omitempty"`
+	PhoneHomeURL string          `json:"phoneHomeURL,omitempty"`
+
+	// Template executes the documents' string values as Go templates over node
+	// facts (e.g., "{{.XName}}"); otherwise they are served literally
+	Template bool `json:"template,omitempty"`
+}
+
+// DefaultIgnitionVersion is the spec version of Ignition configs built from references alone
+const DefaultIgnitionVersion = "3.4.0"
+
+// Ignition describes the Ignition config served to nodes. The config is given
+// inline or as a template over node facts; merge and replace references are
+// added to its "ignition.config" section when it is served.
+type Ignition struct {
+	// Config is an inline Ignition config (a JSON object)
+	Config json.RawMessage `json:"config,omitempty"`
+
+	// Template is an Ignition config as a Go template over node facts
+	// (e.g., "{{.XName}}"); it must render to a JSON object
+	Template string `json:"template,omitempty"`
+
+	// Merge lists configs that are merged into this one
+	Merge []IgnitionReference `json:"merge,omitempty"`
+
+	// Replace references a config that replaces this one
+	Replace *IgnitionReference `json:"replace,omitempty"`
+}
+
+// IgnitionReference references an external Ignition config
+type IgnitionReference struct {
+	Source string `json:"source"`
+	// Hash verifies the referenced config (e.g., "sha512-<hex>")
+	Hash string `json:"hash,omitempty"`
+}
+
+// validate checks an Ignition option for consistency and supported spec versions
+func (i *Ignition) validate() error {
+	if len(i.Config) > 0 && i.Template != "" {
+		return errors.New("ignition config and template are mutually exclusive")
+	}
+	if len(i.Config) == 0 && i.Template == "" && len(i.Merge) == 0 && i.Replace == nil {
+		return errors.New("ignition requires a config, a template, or merge/replace references")
+	}
+	if len(i.Merge) > 0 && i.Replace != nil {
+		return errors.New("ignition merge and replace references are mutually exclusive")
+	}
+
+	if len(i.Config) > 0 {
+		var config struct {
+			Ignition struct {
+				Version string `json:"version"`
+			} `json:"ignition"`
+		}
+		if err := json.Unmarshal(i.Config, &config); err != nil {
+			return errors.New("invalid ignition config: " + err.Error())
+		}
+		if !validation.ValidateIgnitionVersion(config.Ignition.Version) {
+			return errors.New("unsupported ignition spec version: " + config.Ignition.Version)
+		}
+	}
+
+	if i.Template != "" {
+		if _, err := template.New("ignition").Parse(i.Template); err != nil {
+			return errors.New("invalid ignition template: " + err.Error())
+		}
+	}
+
+	references := i.Merge
+	if i.Replace != nil {
+		references = append(references, *i.Replace)
+	}
+	for _, reference := range references {
+		if u, err := url.Parse(reference.Source); err != nil || u.Scheme == "" {
+			return errors.New("invalid ignition reference source: " + reference.Source)
+		}
+		if reference.Hash != "" && !strings.HasPrefix(reference.Hash, "sha512-") && !strings.HasPrefix(reference.Hash, "sha256-") {
+			return errors.New("invalid ignition reference hash: " + reference.Hash)
+		}
+	}
+
+	return nil
+}
+
+// IsEmpty reports whether no cloud-init data is set
+func (c *CloudInit) IsEmpty() bool {
+	return c == nil || (len(c.MetaData) == 0 && len(c.UserData) == 0 && len(c.VendorData) == 0 &&
+		len(c.NetworkData) == 0 && c.PhoneHomeURL == "")
 }
 
 // BootConfigurationStatus defines the observed state of BootConfiguration
@@ -59,6 +169,13 @@ func (r *BootConfiguration) Validate(ctx context.Context) error { //nolint:reviv
 		}
 	}
 
+	// Validate roles
+	for _, role := range r.Spec.Roles {
+		if strings.TrimSpace(role) == "" {
+			return errors.New("role must not be empty")
+		}
+	}
+
 	// Validate MAC addresses
 	for _, mac := range r.Spec.MACs {
 		if !validation.ValidateMAC(mac) {
@@ -76,14 +193,39 @@ func (r *BootConfiguration) Validate(ctx context.Context) error { //nolint:reviv
 		return errors.New("invalid initrd URL or path: " + r.Spec.Initrd)
 	}
 
+	// Validate cloud-init documents
+	if ci := r.Spec.CloudInit; ci != nil {
+		for name, document := range map[string]json.RawMessage{
+			"metaData":    ci.MetaData,
+			"userData":    ci.UserData,
+			"vendorData":  ci.VendorData,
+			"networkData": ci.NetworkData,
+		} {
+			if len(document) > 0 && !json.Valid(document) {
+				return errors.New("invalid cloud-init " + name + ": not valid JSON")
+			}
+		}
+		if ci.PhoneHomeURL != "" && !validation.ValidateURLOrPath(ci.PhoneHomeURL) {
+			return errors.New("invalid cloud-init phone home URL: " + ci.PhoneHomeURL)
+		}
+	}
+
+	// Validate Ignition config
+	if r.Spec.Ignition != nil {
+		if err := r.Spec.Ignition.validate(); err != nil {
+			return err
+		}
+	}
+
 	// Validate priority range
 	if r.Spec.Priority < 0 || r.Spec.Priority > 100 {
 		return errors.New("priority must be between 0 and 100")
 	}
 
 	// Ensure at least one targeting method is specified
-	if len(r.Spec.Hosts) == 0 && len(r.Spec.MACs) == 0 && len(r.Spec.NIDs) == 0 && len(r.Spec.Groups) == 0 {
-		return errors.New("at least one targeting method (hosts, macs, nids, or groups) must be specified")
+	if len(r.Spec.Hosts) == 0 && len(r.Spec.MACs) == 0 && len(r.Spec.NIDs) == 0 && len(r.Spec.Groups) == 0 &&
+		len(r.Spec.Roles) == 0 && !r.Spec.Default {
+		return errors.New("at least one targeting method (hosts, macs, nids, groups, roles, or default) must be specified")
 	}
 
 	return nil
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package bootconfiguration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestIgnitionValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		ignition Ignition
+		valid    bool
+	}{
+		{"Inline config", Ignition{Config: json.RawMessage(`{"ignition": {"version": "3.4.0"}}`)}, true},
+		{"Template", Ignition{Template: `{"ignition": {"version": "3.3.0"}, "passwd": {"users": [{"name": "{{.XName}}"}]}}`}, true},
+		{"Merge references only", Ignition{Merge: []IgnitionReference{{Source: "https://files.example.com/base.ign"}}}, true},
+		{"Replace reference", Ignition{Replace: &IgnitionReference{Source: "tftp://10.0.0.1/node.ign", Hash: "sha256-abc"}}, true},
+		{"Unsupported spec version", Ignition{Config: json.RawMessage(`{"ignition": {"version": "2.2.0"}}`)}, false},
+		{"Missing spec version", Ignition{Config: json.RawMessage(`{"storage": {}}`)}, false},
+		{"Config and template", Ignition{Config: json.RawMessage(`{"ignition": {"version": "3.4.0"}}`), Template: "{}"}, false},
+		{"Merge and replace", Ignition{
+			Merge:   []IgnitionReference{{Source: "https://files.example.com/a.ign"}},
+			Replace: &IgnitionReference{Source: "https://files.example.com/b.ign"},
+		}, false},
+		{"Relative reference", Ignition{Merge: []IgnitionReference{{Source: "base.ign"}}}, false},
+		{"Unknown hash", Ignition{Merge: []IgnitionReference{{Source: "https://files.example.com/a.ign", Hash: "md5-abc"}}}, false},
+		{"Broken template", Ignition{Template: "{{.XName"}, false},
+		{"Empty", Ignition{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &BootConfiguration{Spec: BootConfigurationSpec{
+				Hosts:    []string{"x1000c0s0b0n0"},
+				Kernel:   "http://files.example.com/vmlinuz",
+				Ignition: &tt.ignition,
+			}}
+			if err := config.Validate(context.Background()); (err == nil) != tt.valid {
+				t.Errorf("Validate() error = %v, expected valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
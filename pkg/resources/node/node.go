@@ -30,13 +30,29 @@ type NodeSpec struct { // nolint:revive
 	Hostname   string      `json:"hostname,omitempty"`
 	Interfaces []Interface `json:"interfaces,omitempty"`
 	Groups     []string    `json:"groups,omitempty"` // Groups from inventory service
+
+	// Discovery holds facts reported by the node during PXE discovery (nil for inventoried nodes)
+	Discovery *DiscoveryInfo `json:"discovery,omitempty"`
+}
+
+// DiscoveryInfo holds hardware facts reported by iPXE when an unknown node registers
+type DiscoveryInfo struct {
+	UUID         string `json:"uuid,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Product      string `json:"product,omitempty"`
+	Platform     string `json:"platform,omitempty"`     // e.g., "efi", "pcbios"
+	BuildArch    string `json:"buildArch,omitempty"`    // e.g., "x86_64", "arm64"
+	DiscoveredAt string `json:"discoveredAt,omitempty"` // RFC3339 timestamp
+	ApprovedAt   string `json:"approvedAt,omitempty"`   // RFC3339 timestamp
 }
 
 // Interface represents a network interface
 type Interface struct {
-	MAC  string `json:"mac,omitempty"`
-	IP   string `json:"ip,omitempty"`
-	Type string `json:"type,omitempty"` // e.g., "management", "data"
+	MAC         string `json:"mac,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	Type        string `json:"type,omitempty"` // e.g., "management", "data"
+	Description string `json:"description,omitempty"`
 }
 
 // NodeStatus defines the observed state of Node
@@ -46,12 +62,38 @@ type NodeStatus struct { // nolint:revive
 	State             string `json:"state,omitempty"`             // Ready, Booting, Failed
 	LastHSMSync       string `json:"lastHSMSync,omitempty"`       // Last sync with HSM
 	Error             string `json:"error,omitempty"`             // Error message if any
+
+	// HSM component state recorded by the HSM sync, used by boot state policies
+	HSMState   string `json:"hsmState,omitempty"`   // e.g., Ready, Standby, Off
+	HSMFlag    string `json:"hsmFlag,omitempty"`    // e.g., OK, Warning, Alert
+	HSMEnabled *bool  `json:"hsmEnabled,omitempty"` // Administrative enable in HSM
+}
+
+// Node lifecycle states recorded in NodeStatus.State
+const (
+	StateReady   = "Ready"
+	StatePending = "Pending" // Discovered, awaiting operator approval
+	StateRemoved = "Removed" // Deleted from the inventory, kept as a tombstone
+)
+
+// IsRemoved reports whether the node was tombstoned after leaving the inventory
+func (r *Node) IsRemoved() bool {
+	return r.Status.State == StateRemoved
+}
+
+// IsPendingDiscovery reports whether the node was discovered and not yet approved
+func (r *Node) IsPendingDiscovery() bool {
+	return r.Spec.Discovery != nil && r.Spec.Discovery.ApprovedAt == ""
 }
 
 // Validate implements custom validation logic for Node
 func (r *Node) Validate(ctx context.Context) error { //nolint:revive
-	// Validate XName format
-	if !validation.ValidateXName(r.Spec.XName) {
+	// Discovered nodes are identified by MAC until an operator assigns an XName
+	if r.IsPendingDiscovery() && r.Spec.XName == "" {
+		if r.Spec.BootMAC == "" {
+			return errors.New("discovered node requires a BootMAC")
+		}
+	} else if !validation.ValidateXName(r.Spec.XName) {
 		return errors.New("invalid XName format: " + r.Spec.XName)
 	}
 
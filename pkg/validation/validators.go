@@ -6,9 +6,11 @@
 package validation
 
 import (
+	"fmt"
 	"net"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -47,16 +49,119 @@ func ValidateXNameOrDefault(xname string) bool {
 	return ValidateXName(xname)
 }
 
-// ValidateMAC validates MAC address format
+// ValidateMAC validates MAC address format.
+// Colon, dash, Cisco dotted and unseparated notations are accepted.
 func ValidateMAC(mac string) bool {
 	if mac == "" {
 		return true // Optional field
 	}
 
-	_, err := net.ParseMAC(mac)
+	_, err := NormalizeMAC(mac)
 	return err == nil
 }
 
+// hexMACPattern matches a MAC address written without separators
+var hexMACPattern = regexp.MustCompile(`^[0-9A-Fa-f]{12}$`)
+
+// NormalizeMAC converts a MAC address in colon (aa:bb:cc:dd:ee:ff), dash
+// (AA-BB-CC-DD-EE-FF), Cisco dotted (aabb.ccdd.eeff) or unseparated
+// (aabbccddeeff) notation to lowercase colon-separated form. Only 48-bit
+// addresses are accepted. Any 12 hex digits are a MAC address, so a hostname
+// such as "deadbeefcafe" normalizes to de:ad:be:ef:ca:fe.
+func NormalizeMAC(mac string) (string, error) {
+	mac = strings.TrimSpace(mac)
+
+	if hexMACPattern.MatchString(mac) {
+		mac = mac[0:2] + ":" + mac[2:4] + ":" + mac[4:6] + ":" + mac[6:8] + ":" + mac[8:10] + ":" + mac[10:12]
+	}
+
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+	// net.ParseMAC also accepts EUI-64 and 20-octet IP over InfiniBand addresses
+	if len(hw) != 6 {
+		return "", fmt.Errorf("invalid MAC address %s: %d octets instead of 6", mac, len(hw))
+	}
+	return hw.String(), nil
+}
+
+// EqualMAC reports whether two MAC addresses are the same regardless of notation
+func EqualMAC(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	normalizedA, errA := NormalizeMAC(a)
+	normalizedB, errB := NormalizeMAC(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return normalizedA == normalizedB
+}
+
+// EqualIP reports whether two IP addresses are the same regardless of notation
+func EqualIP(a, b string) bool {
+	ipA := net.ParseIP(strings.TrimSpace(a))
+	ipB := net.ParseIP(strings.TrimSpace(b))
+	if ipA == nil || ipB == nil {
+		return false
+	}
+	return ipA.Equal(ipB)
+}
+
+// hostnamePattern matches an RFC 1123 hostname or FQDN
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+
+// ValidateHostname validates a short hostname or fully qualified domain name
+func ValidateHostname(hostname string) bool {
+	if hostname == "" || len(hostname) > 253 {
+		return false
+	}
+
+	return hostnamePattern.MatchString(hostname)
+}
+
+// ShortHostname returns the first label of a hostname (e.g., "nid000001" for "nid000001.cluster.local")
+func ShortHostname(hostname string) string {
+	short, _, _ := strings.Cut(hostname, ".")
+	return short
+}
+
+// legacyNIDHostnamePattern matches legacy BSS host names such as "nid001234"
+var legacyNIDHostnamePattern = regexp.MustCompile(`(?i)^nid(\d+)$`)
+
+// ParseLegacyNIDHostname returns the NID encoded in a legacy BSS host name
+// (e.g., 1234 for "nid001234" or "nid001234.cluster.local")
+func ParseLegacyNIDHostname(hostname string) (int32, bool) {
+	match := legacyNIDHostnamePattern.FindStringSubmatch(ShortHostname(hostname))
+	if match == nil {
+		return 0, false
+	}
+	nid, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(nid), true
+}
+
+// nodeRoles lists the HSM node roles, keyed by lowercase name
+var nodeRoles = map[string]string{
+	"compute":     "Compute",
+	"service":     "Service",
+	"system":      "System",
+	"application": "Application",
+	"storage":     "Storage",
+	"management":  "Management",
+}
+
+// CanonicalNodeRole returns the HSM spelling of a node role name (e.g., "Compute"
+// for "compute") and whether the name is a known role
+func CanonicalNodeRole(name string) (string, bool) {
+	role, ok := nodeRoles[strings.ToLower(name)]
+	return role, ok
+}
+
 // ValidateURLOrPath validates URL format or file path
 func ValidateURLOrPath(value string) bool {
 	if value == "" {
@@ -87,3 +192,18 @@ func ValidateURLOrPathOptional(value string) bool {
 
 	return ValidateURLOrPath(value)
 }
+
+// supportedIgnitionVersions lists the Ignition config spec versions that can be served
+var supportedIgnitionVersions = map[string]bool{
+	"3.0.0": true,
+	"3.1.0": true,
+	"3.2.0": true,
+	"3.3.0": true,
+	"3.4.0": true,
+	"3.5.0": true,
+}
+
+// ValidateIgnitionVersion reports whether an Ignition config spec version is supported
+func ValidateIgnitionVersion(version string) bool {
+	return supportedIgnitionVersions[version]
+}
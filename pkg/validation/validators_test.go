@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 OpenCHAMI Contributors
+//
+// SPDX-License-Identifier: MIT
+
+package validation
+
+import "testing"
+
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"aa:bb:cc:dd:ee:ff", "aa:bb:cc:dd:ee:ff", true},
+		{"AA:BB:CC:DD:EE:FF", "aa:bb:cc:dd:ee:ff", true},
+		{"AA-BB-CC-DD-EE-FF", "aa:bb:cc:dd:ee:ff", true},
+		{"aabb.ccdd.eeff", "aa:bb:cc:dd:ee:ff", true},
+		{"AABBCCDDEEFF", "aa:bb:cc:dd:ee:ff", true},
+		{" aa:bb:cc:dd:ee:ff ", "aa:bb:cc:dd:ee:ff", true},
+		{"aa:bb:cc:dd:ee", "", false},
+		{"aabbccddeeffgg", "", false},
+		{"x1000c0s0b0n0", "", false},
+		{"deadbeefcafe", "de:ad:be:ef:ca:fe", true},                      // Hex hostnames read as MAC addresses
+		{"02:00:5e:10:00:00:00:01", "", false},                           // EUI-64
+		{"0000.0000.fe80.0000.0000.0000.0200.5e10.0000.0001", "", false}, // IP over InfiniBand
+	}
+
+	for _, tt := range tests {
+		result, err := NormalizeMAC(tt.input)
+		if (err == nil) != tt.valid {
+			t.Errorf("NormalizeMAC(%q) error = %v, expected valid=%v", tt.input, err, tt.valid)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("NormalizeMAC(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+
+	if !EqualMAC("aabb.ccdd.eeff", "AA-BB-CC-DD-EE-FF") {
+		t.Errorf("Expected Cisco and dashed notations to be equal")
+	}
+	if EqualMAC("", "") {
+		t.Errorf("Expected empty MACs not to match")
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"nid001234", true},
+		{"compute-01.cluster.local", true},
+		{"login01", true},
+		{"-bad", false},
+		{"bad-.example.com", false},
+		{"under_score", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := ValidateHostname(tt.input); result != tt.valid {
+			t.Errorf("ValidateHostname(%q) = %v, expected %v", tt.input, result, tt.valid)
+		}
+	}
+
+	if short := ShortHostname("nid001234.cluster.local"); short != "nid001234" {
+		t.Errorf("ShortHostname returned %q", short)
+	}
+
+	nidTests := []struct {
+		input string
+		nid   int32
+		ok    bool
+	}{
+		{"nid001234", 1234, true},
+		{"NID000042.cluster.local", 42, true},
+		{"nid", 0, false},
+		{"nid99999999999", 0, false},
+		{"login01", 0, false},
+	}
+	for _, tt := range nidTests {
+		if nid, ok := ParseLegacyNIDHostname(tt.input); nid != tt.nid || ok != tt.ok {
+			t.Errorf("ParseLegacyNIDHostname(%q) = %d, %v, expected %d, %v", tt.input, nid, ok, tt.nid, tt.ok)
+		}
+	}
+}